@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
+	"github.com/spf13/cobra"
+)
+
+var apikeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage API keys for CI and scripts",
+	Long: `Create, list, rotate and revoke API keys.
+
+These commands need an OAuth login ('stompy login'); they refuse to run when
+authenticated by an API key, so a leaked key cannot mint or replace keys.`,
+}
+
+var apikeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an API key and print its secret once",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireOAuthSession(); err != nil {
+			return err
+		}
+		name, _ := cmd.Flags().GetString("name")
+		scopes, _ := cmd.Flags().GetStringSlice("scopes")
+		expires, _ := cmd.Flags().GetString("expires")
+		if name == "" {
+			return fmt.Errorf("%w: --name is required", stompy.ErrValidation)
+		}
+
+		expiresAt, err := parseExpires(expires, time.Now())
+		if err != nil {
+			return fmt.Errorf("%w: --expires: %w", stompy.ErrValidation, err)
+		}
+
+		resp, err := apiClient.CreateAPIKey(cmd.Context(), stompy.APIKeyCreate{
+			Name:      name,
+			Scopes:    scopes,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		printAPIKeySecret(resp)
+		return nil
+	},
+}
+
+var apikeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API keys",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireOAuthSession(); err != nil {
+			return err
+		}
+		resp, err := apiClient.ListAPIKeys(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		f := getFormatter()
+		headers := []string{"ID", "NAME", "PREFIX", "SCOPES", "CREATED", "EXPIRES", "LAST USED"}
+		var rows [][]string
+		for _, k := range resp.APIKeys {
+			expires := formatExpiry(k.ExpiresAt)
+			if isTableOutput() && k.ExpiresAt != nil && k.ExpiresAt.Before(time.Now()) {
+				expires = output.Warn(expires + " (expired)")
+			}
+			lastUsed := ""
+			if k.LastUsedAt != nil {
+				lastUsed = k.LastUsedAt.Local().Format("2006-01-02")
+			}
+			rows = append(rows, []string{
+				k.ID,
+				k.Name,
+				k.Prefix,
+				strings.Join(k.Scopes, ","),
+				k.CreatedAt.Local().Format("2006-01-02"),
+				expires,
+				lastUsed,
+			})
+		}
+
+		fmt.Print(f.FormatTable(headers, rows))
+		if isTableOutput() {
+			fmt.Printf("\nTotal: %d API keys\n", resp.Total)
+		}
+		return nil
+	},
+}
+
+var apikeyRotateCmd = &cobra.Command{
+	Use:   "rotate <id>",
+	Short: "Replace an API key's secret and print the new one once",
+	Long: `Replace an API key's secret, keeping its name, scopes and expiry. The old
+secret stops working immediately, so update the systems using it right away.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireOAuthSession(); err != nil {
+			return err
+		}
+		resp, err := apiClient.RotateAPIKey(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		printAPIKeySecret(resp)
+		return nil
+	},
+}
+
+var apikeyRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an API key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireOAuthSession(); err != nil {
+			return err
+		}
+		if err := apiClient.RevokeAPIKey(cmd.Context(), args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("%s API key revoked: %s\n", output.Success("✓"), output.Teal(args[0]))
+		return nil
+	},
+}
+
+// requireOAuthSession refuses API key management unless the command runs
+// with the OAuth login.
+func requireOAuthSession() error {
+	if !oauthSession {
+		return fmt.Errorf("%w: API keys can only be managed from an OAuth login, not with an API key; run 'stompy login' and drop --api-key / STOMPY_API_KEY", stompy.ErrForbidden)
+	}
+	return nil
+}
+
+// printAPIKeySecret shows a newly minted key, including the secret the
+// server will not return again.
+func printAPIKeySecret(k *stompy.APIKeySecretResponse) {
+	fmt.Print(getFormatter().FormatSingle([]output.KeyValue{
+		{Key: "ID", Value: k.ID},
+		{Key: "Name", Value: k.Name},
+		{Key: "Scopes", Value: strings.Join(k.Scopes, ",")},
+		{Key: "Expires", Value: formatExpiry(k.ExpiresAt)},
+		{Key: "Secret", Value: k.Secret},
+	}))
+	if isTableOutput() {
+		fmt.Fprintln(os.Stderr, output.Warn("Store the secret now; it will not be shown again."))
+	}
+}
+
+// formatExpiry renders an optional expiry date.
+func formatExpiry(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02")
+}
+
+// parseExpires turns --expires into an expiry time: "never" (or empty) for
+// none, a lifetime such as 90d, 12w or 36h, or a date (2006-01-02).
+func parseExpires(s string, now time.Time) (*time.Time, error) {
+	if s == "" || s == "never" {
+		return nil, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		if !t.After(now) {
+			return nil, fmt.Errorf("%s is in the past", s)
+		}
+		return &t, nil
+	}
+
+	var d time.Duration
+	if n, unit := s[:len(s)-1], s[len(s)-1]; unit == 'd' || unit == 'w' {
+		days, err := strconv.Atoi(n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lifetime %q", s)
+		}
+		if unit == 'w' {
+			days *= 7
+		}
+		d = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return nil, fmt.Errorf("invalid lifetime %q: use e.g. 90d, 12w, 36h, a date (2006-01-02) or never", s)
+		}
+	}
+	if d <= 0 {
+		return nil, fmt.Errorf("lifetime %q must be positive", s)
+	}
+	t := now.Add(d)
+	return &t, nil
+}
+
+func init() {
+	apikeyCreateCmd.Flags().String("name", "", "Name of the key, e.g. the system that uses it (required)")
+	apikeyCreateCmd.Flags().StringSlice("scopes", nil, "Scopes to grant, comma-separated or repeated (default: the server's default scopes)")
+	apikeyCreateCmd.Flags().String("expires", "90d", "Lifetime (90d, 12w, 36h), expiry date (2006-01-02) or never")
+
+	apikeyCmd.AddCommand(apikeyCreateCmd)
+	apikeyCmd.AddCommand(apikeyListCmd)
+	apikeyCmd.AddCommand(apikeyRotateCmd)
+	apikeyCmd.AddCommand(apikeyRevokeCmd)
+	rootCmd.AddCommand(apikeyCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExpires(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	cases := []struct {
+		in      string
+		want    time.Time // zero for never
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"never", time.Time{}, false},
+		{"90d", now.Add(90 * 24 * time.Hour), false},
+		{"2w", now.Add(14 * 24 * time.Hour), false},
+		{"36h", now.Add(36 * time.Hour), false},
+		{"2026-06-01", time.Date(2026, 6, 1, 0, 0, 0, 0, time.Local), false},
+		{"2025-01-01", time.Time{}, true},
+		{"0d", time.Time{}, true},
+		{"-5h", time.Time{}, true},
+		{"xd", time.Time{}, true},
+		{"soon", time.Time{}, true},
+	}
+	for _, tc := range cases {
+		got, err := parseExpires(tc.in, now)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseExpires(%q) error = %v, wantErr %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if tc.wantErr {
+			continue
+		}
+		if tc.want.IsZero() != (got == nil) || (got != nil && !got.Equal(tc.want)) {
+			t.Errorf("parseExpires(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
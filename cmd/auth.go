@@ -1,44 +1,102 @@
 package cmd
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/banton/stompy-cli/internal/auth"
 	"github.com/banton/stompy-cli/internal/config"
 	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
 var loginCmd = &cobra.Command{
 	Use:   "login",
-	Short: "Authenticate via OAuth 2.0 browser-based login (PKCE)",
+	Short: "Authenticate via OAuth 2.0 browser login (PKCE) or device code",
+	Long: `Authenticate via OAuth 2.0.
+
+By default a browser is opened for the PKCE flow. Without a display (over SSH,
+in containers) the device flow is used instead: enter the printed code at the
+shown URL on any device. --no-browser prints the browser URL and accepts the
+redirect address pasted back from a browser on another machine.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		apiURL := flagAPIURL
 		if apiURL == "" {
 			apiURL = config.GetAPIURL()
 		}
 
-		tokenResp, err := auth.Login(apiURL)
+		device, _ := cmd.Flags().GetBool("device")
+		noBrowser, _ := cmd.Flags().GetBool("no-browser")
+		browser, _ := cmd.Flags().GetBool("browser")
+
+		var tokenResp *auth.TokenResponse
+		var err error
+		switch {
+		case device:
+			tokenResp, err = auth.LoginDevice(cmd.Context(), apiURL)
+		case noBrowser || browser || auth.HasDisplay():
+			tokenResp, err = auth.Login(cmd.Context(), apiURL, auth.LoginOptions{NoBrowser: noBrowser, In: os.Stdin})
+		default:
+			fmt.Println("No display detected; using device login (pass --no-browser to paste a redirect URL instead).")
+			fmt.Println()
+			tokenResp, err = auth.LoginDevice(cmd.Context(), apiURL)
+			if errors.Is(err, auth.ErrDeviceFlowUnsupported) {
+				fmt.Println("The server does not support device login; falling back to --no-browser.")
+				fmt.Println()
+				tokenResp, err = auth.Login(cmd.Context(), apiURL, auth.LoginOptions{NoBrowser: true, In: os.Stdin})
+			}
+		}
 		if err != nil {
 			return fmt.Errorf("login failed: %w", err)
 		}
 
-		expiry := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-		if err := config.SaveTokens(tokenResp.AccessToken, tokenResp.RefreshToken, expiry, "", ""); err != nil {
+		store := auth.CurrentStore()
+		err = store.Save(&auth.Credentials{
+			AccessToken:  tokenResp.AccessToken,
+			RefreshToken: tokenResp.RefreshToken,
+			Expiry:       time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+		})
+		if err != nil {
 			return fmt.Errorf("saving tokens: %w", err)
 		}
 
-		fmt.Println("Login successful! Token saved to", config.GetConfigPath())
+		fmt.Println("Login successful! Token saved to the", store.Name())
 		return nil
 	},
 }
 
 var logoutCmd = &cobra.Command{
 	Use:   "logout",
-	Short: "Clear stored authentication tokens",
+	Short: "Revoke and clear stored authentication tokens",
+	Long: `Revoke the OAuth tokens on the server (RFC 7009) and remove them from the
+credential store. With --local the tokens are only removed locally and stay
+valid on the server until they expire.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := config.ClearTokens(); err != nil {
+		local, _ := cmd.Flags().GetBool("local")
+
+		creds, err := auth.LoadCredentials()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, output.Warn("Warning: could not read tokens to revoke them: "+err.Error()))
+		}
+		if creds != nil && !local {
+			switch err := auth.RevokeCredentials(cmd.Context(), resolveAPIURL(), creds); {
+			case err == nil:
+				fmt.Println("Tokens revoked on the server.")
+			case errors.Is(err, auth.ErrRevocationUnsupported):
+				fmt.Fprintln(os.Stderr, output.Dim("The server does not support token revocation; the tokens stay valid until they expire."))
+			default:
+				fmt.Fprintln(os.Stderr, output.Warn("Warning: could not revoke tokens on the server: "+err.Error()))
+				fmt.Fprintln(os.Stderr, output.Dim("Revoke the session from another machine with 'stompy auth revoke'."))
+			}
+		}
+
+		if err := auth.ClearCredentials(); err != nil {
 			return fmt.Errorf("clearing tokens: %w", err)
 		}
 		fmt.Println("Logged out. Tokens cleared.")
@@ -48,54 +106,130 @@ var logoutCmd = &cobra.Command{
 
 var whoamiCmd = &cobra.Command{
 	Use:   "whoami",
-	Short: "Show current authentication status",
+	Short: "Show the identity, scopes and expiry of the current credential",
+	Long: `Show who the current credential authenticates as, checked against the
+server: user, email, organization, scopes and expiry, plus the effective
+project, API URL and server version. OAuth token claims (issuer, issue and
+expiry times) are decoded locally for display and are not verified.
+
+With --check, exit non-zero when the server rejects the credential or cannot
+be reached, e.g. as a CI preflight step.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := config.Load(); err != nil {
+		check, _ := cmd.Flags().GetBool("check")
+
+		fields, err := whoami(cmd.Context())
+		fmt.Print(getFormatter().FormatSingle(fields))
+		if check {
 			return err
 		}
+		return nil
+	},
+}
 
-		f := getFormatter()
-
-		// Check API key first
-		if flagAPIKey != "" || config.GetAPIKey() != "" {
-			fmt.Print(f.FormatSingle([]output.KeyValue{
-				{Key: "Auth Method", Value: "API Key"},
-				{Key: "Status", Value: "Authenticated"},
-			}))
-			return nil
+// whoami describes the credential apiClient uses. The returned error is set
+// when the server did not confirm the credential.
+func whoami(ctx context.Context) ([]output.KeyValue, error) {
+	var fields []output.KeyValue
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, output.KeyValue{Key: key, Value: value})
 		}
-
-		// Check OAuth tokens
-		token := config.GetAccessToken()
-		if token == "" {
-			fmt.Println("Not authenticated. Run 'stompy login' to authenticate.")
-			return nil
+	}
+	addTime := func(key string, t time.Time) {
+		if !t.IsZero() {
+			add(key, t.Local().Format(time.RFC3339))
 		}
+	}
 
-		expiry := config.GetTokenExpiry()
-		email := config.GetEmail()
-		status := "Valid"
-		if auth.IsExpired(expiry) {
-			status = "Expired (will auto-refresh on next command)"
+	// Local view: how we authenticate and, for OAuth, what the token claims
+	method := "API Key (" + apiKeySource() + ")"
+	claims := &auth.Claims{}
+	var creds *auth.Credentials
+	switch {
+	case oauthSession:
+		method = "OAuth 2.0"
+		if c, err := auth.DecodeClaims(auth.AccessToken()); err == nil {
+			claims = c
 		}
+		creds, _ = auth.LoadCredentials()
+	case apiClient.AuthToken == "":
+		method = "None"
+	}
+	if creds == nil {
+		creds = &auth.Credentials{}
+	}
 
-		fields := []output.KeyValue{
-			{Key: "Auth Method", Value: "OAuth 2.0 (PKCE)"},
-			{Key: "Status", Value: status},
-		}
-		if email != "" {
-			fields = append(fields, output.KeyValue{Key: "Email", Value: email})
-		}
-		if !expiry.IsZero() {
-			fields = append(fields, output.KeyValue{Key: "Token Expiry", Value: expiry.Local().Format(time.RFC3339)})
+	// Server view: /me, or on older servers any authenticated request
+	status := "Valid"
+	me, err := apiClient.Me(ctx)
+	if errors.Is(err, stompy.ErrNotFound) {
+		me = nil
+		if _, err = apiClient.ListProjects(ctx, false); err == nil {
+			status = "Valid (server does not report identity)"
 		}
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, stompy.ErrUnauthorized):
+		status = "Invalid: rejected by the server"
+		err = fmt.Errorf("credential rejected: %w", err)
+	default:
+		status = "Unverified: " + err.Error()
+		err = fmt.Errorf("verifying credential: %w", err)
+	}
+	if me == nil {
+		me = &stompy.MeResponse{}
+	}
 
-		fmt.Print(f.FormatSingle(fields))
-		return nil
-	},
+	add("Profile", config.ActiveProfile())
+	add("Auth Method", method)
+	add("Status", status)
+	add("User ID", cmp.Or(me.UserID, claims.Subject, creds.UserID))
+	add("Email", cmp.Or(me.Email, claims.Email, creds.Email))
+	add("Name", me.Name)
+	add("Organization", cmp.Or(me.Org, claims.Org))
+	scopes := me.Scopes
+	if len(scopes) == 0 {
+		scopes = claims.Scopes
+	}
+	add("Scopes", strings.Join(scopes, " "))
+	add("API Key ID", me.APIKeyID)
+	add("Issuer", claims.Issuer)
+	addTime("Issued At", claims.IssuedAt)
+	expiry := cmp.Or(claims.ExpiresAt, creds.Expiry)
+	if me.ExpiresAt != nil {
+		expiry = *me.ExpiresAt
+	}
+	addTime("Expires", expiry)
+	if oauthSession {
+		add("Credential Store", auth.CurrentStore().Name())
+	}
+	project, _ := getProject()
+	add("Project", cmp.Or(project, "(none)"))
+	add("API URL", apiClient.BaseURL)
+	add("Server Version", cmp.Or(apiClient.APIVersion(), "unknown"))
+	return fields, err
+}
+
+// apiKeySource names where resolveAuthToken found the API key.
+func apiKeySource() string {
+	switch {
+	case flagAPIKey != "":
+		return "--api-key"
+	case os.Getenv("STOMPY_API_KEY") != "":
+		return "STOMPY_API_KEY"
+	}
+	return "config"
 }
 
 func init() {
+	loginCmd.Flags().Bool("device", false, "Log in with a code entered on another device (RFC 8628)")
+	loginCmd.Flags().Bool("no-browser", false, "Print the login URL and paste the redirect URL back instead of opening a browser")
+	loginCmd.Flags().Bool("browser", false, "Open a browser even when no display is detected")
+	loginCmd.MarkFlagsMutuallyExclusive("device", "no-browser", "browser")
+	logoutCmd.Flags().Bool("local", false, "Only remove the tokens locally, without revoking them on the server")
+	whoamiCmd.Flags().Bool("check", false, "Exit non-zero unless the server accepts the credential")
 	rootCmd.AddCommand(loginCmd)
 	rootCmd.AddCommand(logoutCmd)
 	rootCmd.AddCommand(whoamiCmd)
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/banton/stompy-cli/internal/auth"
+	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage login sessions",
+}
+
+var authSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List active login sessions and devices",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := apiClient.ListSessions(cmd.Context())
+		if err != nil {
+			return sessionsError(err)
+		}
+
+		f := getFormatter()
+		headers := []string{"CURRENT", "ID", "DEVICE", "CLIENT", "IP", "CREATED", "LAST USED"}
+		var rows [][]string
+		for _, s := range resp.Sessions {
+			current, lastUsed := "", ""
+			if s.Current {
+				current = "*"
+			}
+			if s.LastUsedAt != nil {
+				lastUsed = s.LastUsedAt.Local().Format("2006-01-02 15:04")
+			}
+			rows = append(rows, []string{
+				current,
+				s.ID,
+				s.DeviceName,
+				s.ClientID,
+				s.IPAddress,
+				s.CreatedAt.Local().Format("2006-01-02 15:04"),
+				lastUsed,
+			})
+		}
+
+		fmt.Print(f.FormatTable(headers, rows))
+		if isTableOutput() {
+			fmt.Printf("\nTotal: %d sessions\n", resp.Total)
+		}
+		return nil
+	},
+}
+
+var authRevokeCmd = &cobra.Command{
+	Use:   "revoke [<id>]",
+	Short: "Revoke a login session, or every session with --all-devices",
+	Long: `Revoke a login session so its tokens stop working, e.g. for a lost laptop.
+Find session IDs with 'stompy auth sessions'.
+
+--all-devices revokes every session, this one included, and logs this machine
+out as well.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all-devices")
+		if all == (len(args) == 1) {
+			return fmt.Errorf("%w: pass a session ID or --all-devices", stompy.ErrValidation)
+		}
+
+		if all {
+			resp, err := apiClient.RevokeAllSessions(cmd.Context())
+			if err != nil {
+				return sessionsError(err)
+			}
+			fmt.Printf("%s Revoked %d sessions.\n", output.Success("✓"), resp.Revoked)
+			clearRevokedCredentials()
+			return nil
+		}
+
+		// Look the session up first so revoking this machine's own session
+		// also clears its now useless tokens.
+		id := args[0]
+		resp, err := apiClient.ListSessions(cmd.Context())
+		if err != nil {
+			return sessionsError(err)
+		}
+		var session *stompy.SessionResponse
+		for i := range resp.Sessions {
+			if resp.Sessions[i].ID == id {
+				session = &resp.Sessions[i]
+			}
+		}
+		if session == nil {
+			return fmt.Errorf("%w: no active session %q", stompy.ErrNotFound, id)
+		}
+
+		if err := apiClient.RevokeSession(cmd.Context(), id); err != nil {
+			return err
+		}
+		fmt.Printf("%s Session revoked: %s\n", output.Success("✓"), output.Teal(id))
+		if session.Current {
+			clearRevokedCredentials()
+		}
+		return nil
+	},
+}
+
+// sessionsError explains a 404 from the sessions endpoints, which older
+// servers do not have.
+func sessionsError(err error) error {
+	if errors.Is(err, stompy.ErrNotFound) {
+		return fmt.Errorf("%w (the server may not support session management)", err)
+	}
+	return err
+}
+
+// clearRevokedCredentials drops the local OAuth tokens after this machine's
+// session was revoked on the server.
+func clearRevokedCredentials() {
+	if creds, err := auth.LoadCredentials(); err != nil || creds == nil {
+		return
+	}
+	if err := auth.ClearCredentials(); err != nil {
+		fmt.Fprintln(os.Stderr, output.Warn("Warning: could not clear local tokens: "+err.Error()))
+		return
+	}
+	fmt.Println("This machine is logged out too. Run 'stompy login' to sign in again.")
+}
+
+func init() {
+	authRevokeCmd.Flags().Bool("all-devices", false, "Revoke every session, including this one")
+
+	authCmd.AddCommand(authSessionsCmd)
+	authCmd.AddCommand(authRevokeCmd)
+	rootCmd.AddCommand(authCmd)
+}
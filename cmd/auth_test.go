@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/banton/stompy-cli/internal/fakestompy"
+	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+func TestWhoami(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("STOMPY_API_KEY", "")
+
+	s := fakestompy.New()
+	srv, baseURL := fakestompy.NewTestServer(s)
+	defer srv.Close()
+
+	defer func(c *stompy.Client, oauth bool, key, project string) {
+		apiClient, oauthSession, flagAPIKey, flagProject = c, oauth, key, project
+	}(apiClient, oauthSession, flagAPIKey, flagProject)
+	apiClient = stompy.NewClient(baseURL, stompy.WithToken("sk_test"))
+	oauthSession, flagAPIKey, flagProject = false, "sk_test", "demo"
+
+	value := func(fields []output.KeyValue, key string) string {
+		for _, f := range fields {
+			if f.Key == key {
+				return f.Value
+			}
+		}
+		return ""
+	}
+
+	fields, err := whoami(context.Background())
+	if err != nil {
+		t.Fatalf("whoami() error: %v", err)
+	}
+	for key, want := range map[string]string{
+		"Auth Method":    "API Key (--api-key)",
+		"Status":         "Valid",
+		"User ID":        "usr_demo",
+		"Project":        "demo",
+		"Server Version": fakestompy.APIVersion,
+	} {
+		if got := value(fields, key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	s.Token = "another-key"
+	fields, err = whoami(context.Background())
+	if !errors.Is(err, stompy.ErrUnauthorized) {
+		t.Errorf("whoami() with a rejected key: error = %v, want ErrUnauthorized", err)
+	}
+	if got := value(fields, "Status"); got != "Invalid: rejected by the server" {
+		t.Errorf("Status = %q", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
@@ -23,10 +24,14 @@ var bugListCmd = &cobra.Command{
 		}
 
 		status, _ := cmd.Flags().GetString("status")
-		limit, _ := cmd.Flags().GetInt("limit")
-		offset, _ := cmd.Flags().GetInt("offset")
 
-		resp, err := apiClient.ListBugReports(project, status, limit, offset)
+		bugs, total, err := listItems(cmd, func(limit, offset int) ([]stompy.BugReportResponse, int, error) {
+			resp, err := apiClient.ListBugReports(cmd.Context(), project, status, limit, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			return resp.BugReports, resp.Total, nil
+		})
 		if err != nil {
 			return err
 		}
@@ -34,7 +39,7 @@ var bugListCmd = &cobra.Command{
 		f := getFormatter()
 		headers := []string{"ID", "TITLE", "STATUS", "SEVERITY", "CREATED"}
 		var rows [][]string
-		for _, b := range resp.BugReports {
+		for _, b := range bugs {
 			statusStr := b.Status
 			severityStr := b.Severity
 			if isTableOutput() {
@@ -52,7 +57,7 @@ var bugListCmd = &cobra.Command{
 
 		fmt.Print(f.FormatTable(headers, rows))
 		if isTableOutput() {
-			fmt.Printf("\nTotal: %d bug reports\n", resp.Total)
+			fmt.Printf("\nTotal: %d bug reports\n", total)
 		}
 		return nil
 	},
@@ -73,7 +78,7 @@ var bugGetCmd = &cobra.Command{
 			return fmt.Errorf("invalid bug report ID: %s", args[0])
 		}
 
-		resp, err := apiClient.GetBugReport(project, id)
+		resp, err := apiClient.GetBugReport(cmd.Context(), project, id)
 		if err != nil {
 			return err
 		}
@@ -108,6 +113,7 @@ func init() {
 	bugListCmd.Flags().String("status", "", "Filter by status (new, confirmed, in_progress, fixed, wont_fix)")
 	bugListCmd.Flags().Int("limit", 0, "Limit results")
 	bugListCmd.Flags().Int("offset", 0, "Offset for pagination")
+	addPaginationFlags(bugListCmd)
 
 	bugCmd.AddCommand(bugListCmd)
 	bugCmd.AddCommand(bugGetCmd)
@@ -54,6 +54,7 @@ var configShowCmd = &cobra.Command{
 
 		fmt.Print(f.FormatSingle(fields))
 		fmt.Printf("\nConfig file: %s\n", config.GetConfigPath())
+		fmt.Printf("Profile: %s\n", config.ActiveProfile())
 		return nil
 	},
 }
@@ -5,8 +5,8 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/banton/stompy-cli/internal/api"
 	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
@@ -25,10 +25,14 @@ var conflictListCmd = &cobra.Command{
 		}
 
 		status, _ := cmd.Flags().GetString("status")
-		limit, _ := cmd.Flags().GetInt("limit")
-		offset, _ := cmd.Flags().GetInt("offset")
 
-		resp, err := apiClient.ListConflicts(project, status, limit, offset)
+		conflicts, total, err := listItems(cmd, func(limit, offset int) ([]stompy.ConflictResponse, int, error) {
+			resp, err := apiClient.ListConflicts(cmd.Context(), project, status, limit, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			return resp.Conflicts, resp.Total, nil
+		})
 		if err != nil {
 			return err
 		}
@@ -36,7 +40,7 @@ var conflictListCmd = &cobra.Command{
 		f := getFormatter()
 		headers := []string{"ID", "CONTEXT A", "CONTEXT B", "TYPE", "SEVERITY", "STATUS"}
 		var rows [][]string
-		for _, c := range resp.Conflicts {
+		for _, c := range conflicts {
 			row := []string{
 				fmt.Sprintf("%d", c.ID),
 				c.ContextATopic,
@@ -56,7 +60,7 @@ var conflictListCmd = &cobra.Command{
 
 		fmt.Print(f.FormatTable(headers, rows))
 		if isTableOutput() {
-			fmt.Printf("\nTotal: %d conflicts\n", resp.Total)
+			fmt.Printf("\nTotal: %d conflicts\n", total)
 		}
 		return nil
 	},
@@ -77,7 +81,7 @@ var conflictGetCmd = &cobra.Command{
 			return fmt.Errorf("invalid conflict ID: %s", args[0])
 		}
 
-		resp, err := apiClient.GetConflict(project, id)
+		resp, err := apiClient.GetConflict(cmd.Context(), project, id)
 		if err != nil {
 			return err
 		}
@@ -115,9 +119,9 @@ var conflictDetectCmd = &cobra.Command{
 		}
 
 		scope, _ := cmd.Flags().GetString("scope")
-		req := api.ConflictDetectRequest{Scope: scope}
+		req := stompy.ConflictDetectRequest{Scope: scope}
 
-		resp, err := apiClient.DetectConflicts(project, req)
+		resp, err := apiClient.DetectConflicts(cmd.Context(), project, req)
 		if err != nil {
 			return err
 		}
@@ -155,8 +159,8 @@ var conflictResolveCmd = &cobra.Command{
 			return fmt.Errorf("invalid resolution %q: must be one of dismiss, keep_a, keep_b, merge", resolution)
 		}
 
-		req := api.ConflictResolveRequest{Resolution: resolution}
-		resp, err := apiClient.ResolveConflict(project, id, req)
+		req := stompy.ConflictResolveRequest{Resolution: resolution}
+		resp, err := apiClient.ResolveConflict(cmd.Context(), project, id, req)
 		if err != nil {
 			return err
 		}
@@ -170,6 +174,7 @@ func init() {
 	conflictListCmd.Flags().String("status", "", "Filter by status (unresolved, resolved, dismissed)")
 	conflictListCmd.Flags().Int("limit", 0, "Limit results")
 	conflictListCmd.Flags().Int("offset", 0, "Offset for pagination")
+	addPaginationFlags(conflictListCmd)
 
 	conflictDetectCmd.Flags().String("scope", "", "Detection scope (all, recent)")
 
@@ -6,8 +6,8 @@ import (
 	"os"
 	"strings"
 
-	"github.com/banton/stompy-cli/internal/api"
 	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
@@ -75,7 +75,7 @@ var contextLockCmd = &cobra.Command{
 		priority, _ := cmd.Flags().GetString("priority")
 		force, _ := cmd.Flags().GetBool("force")
 
-		req := api.ContextCreateRequest{
+		req := stompy.ContextCreateRequest{
 			Topic:      topic,
 			Content:    content,
 			Tags:       tags,
@@ -83,7 +83,7 @@ var contextLockCmd = &cobra.Command{
 			ForceStore: force,
 		}
 
-		resp, err := apiClient.LockContext(project, req)
+		resp, err := apiClient.LockContext(cmd.Context(), project, req)
 		if err != nil {
 			return err
 		}
@@ -118,7 +118,7 @@ var contextRecallCmd = &cobra.Command{
 			version = versionRef
 		}
 
-		resp, err := apiClient.GetContext(project, topic, version)
+		resp, err := apiClient.GetContext(cmd.Context(), project, topic, version)
 		if err != nil {
 			return err
 		}
@@ -159,7 +159,7 @@ var contextUnlockCmd = &cobra.Command{
 		force, _ := cmd.Flags().GetBool("force")
 		noArchive, _ := cmd.Flags().GetBool("no-archive")
 
-		resp, err := apiClient.UnlockContext(project, topic, version, force, noArchive)
+		resp, err := apiClient.UnlockContext(cmd.Context(), project, topic, version, force, noArchive)
 		if err != nil {
 			return err
 		}
@@ -184,15 +184,20 @@ var contextListCmd = &cobra.Command{
 
 		priority, _ := cmd.Flags().GetString("priority")
 		tags, _ := cmd.Flags().GetString("tags")
-		limit, _ := cmd.Flags().GetInt("limit")
-		offset, _ := cmd.Flags().GetInt("offset")
 		fresh, _ := cmd.Flags().GetBool("fresh")
 
+		ctx := cmd.Context()
 		if fresh {
-			apiClient.NoCache = true
+			ctx = stompy.WithNoCache(ctx)
 		}
 
-		resp, err := apiClient.ListContexts(project, priority, tags, limit, offset)
+		contexts, total, err := listItems(cmd, func(limit, offset int) ([]stompy.ContextResponse, int, error) {
+			resp, err := apiClient.ListContexts(ctx, project, priority, tags, limit, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			return resp.Contexts, resp.Total, nil
+		})
 		if err != nil {
 			return err
 		}
@@ -200,7 +205,7 @@ var contextListCmd = &cobra.Command{
 		f := getFormatter()
 		headers := []string{"ID", "TOPIC", "VERSION", "PRIORITY", "TAGS", "ACCESS COUNT"}
 		var rows [][]string
-		for _, c := range resp.Contexts {
+		for _, c := range contexts {
 			rows = append(rows, []string{
 				fmt.Sprintf("%d", c.ID),
 				c.Topic,
@@ -213,7 +218,7 @@ var contextListCmd = &cobra.Command{
 
 		fmt.Print(f.FormatTable(headers, rows))
 		if isTableOutput() {
-			fmt.Printf("\nTotal: %d contexts\n", resp.Total)
+			fmt.Printf("\nTotal: %d contexts\n", total)
 		}
 		return nil
 	},
@@ -231,7 +236,7 @@ var contextSearchCmd = &cobra.Command{
 
 		limit, _ := cmd.Flags().GetInt("limit")
 
-		resp, err := apiClient.SearchContexts(project, args[0], limit)
+		resp, err := apiClient.SearchContexts(cmd.Context(), project, args[0], limit)
 		if err != nil {
 			return err
 		}
@@ -287,13 +292,13 @@ var contextUpdateCmd = &cobra.Command{
 		priority, _ := cmd.Flags().GetString("priority")
 		tags, _ := cmd.Flags().GetString("tags")
 
-		req := api.ContextUpdateRequest{
+		req := stompy.ContextUpdateRequest{
 			Content:  content,
 			Priority: priority,
 			Tags:     tags,
 		}
 
-		resp, err := apiClient.UpdateContext(project, topic, req)
+		resp, err := apiClient.UpdateContext(cmd.Context(), project, topic, req)
 		if err != nil {
 			return err
 		}
@@ -324,7 +329,7 @@ var contextMoveCmd = &cobra.Command{
 			return fmt.Errorf("--to flag is required")
 		}
 
-		resp, err := apiClient.MoveContext(project, topic, target)
+		resp, err := apiClient.MoveContext(cmd.Context(), project, topic, target)
 		if err != nil {
 			return err
 		}
@@ -369,7 +374,7 @@ var contextExploreCmd = &cobra.Command{
 		}
 
 		var resp ContextExploreResponse
-		if err := mcpClient.CallToolTyped("context_explore", mcpArgs, &resp); err != nil {
+		if err := mcpClient.CallToolTyped(cmd.Context(), "context_explore", mcpArgs, &resp); err != nil {
 			return err
 		}
 
@@ -431,7 +436,7 @@ var contextDashboardCmd = &cobra.Command{
 		}
 
 		var resp ContextDashboardResponse
-		if err := mcpClient.CallToolTyped("context_dashboard", mcpArgs, &resp); err != nil {
+		if err := mcpClient.CallToolTyped(cmd.Context(), "context_dashboard", mcpArgs, &resp); err != nil {
 			return err
 		}
 
@@ -490,7 +495,7 @@ var contextBatchCmd = &cobra.Command{
 		}
 
 		var resp RecallBatchResponse
-		if err := mcpClient.CallToolTyped("recall_batch", mcpArgs, &resp); err != nil {
+		if err := mcpClient.CallToolTyped(cmd.Context(), "recall_batch", mcpArgs, &resp); err != nil {
 			return err
 		}
 
@@ -553,6 +558,7 @@ func init() {
 	contextListCmd.Flags().Int("limit", 0, "Limit results")
 	contextListCmd.Flags().Int("offset", 0, "Offset for pagination")
 	contextListCmd.Flags().Bool("fresh", false, "Bypass server cache for fresh results")
+	addPaginationFlags(contextListCmd)
 
 	contextSearchCmd.Flags().Int("limit", 0, "Limit results")
 
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/fakestompy"
+	"github.com/banton/stompy-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var devCmd = &cobra.Command{
+	Use:    "dev",
+	Short:  "Developer tools",
+	Hidden: true,
+}
+
+var devFakeServerCmd = &cobra.Command{
+	Use:   "fake-server",
+	Short: "Run an in-memory Stompy API for offline development",
+	Long: `Serve the Stompy REST and MCP APIs from memory. Nothing is persisted.
+
+Point the CLI at it with:
+  stompy --api-url http://127.0.0.1:8787/api/v1 project list`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		seed, _ := cmd.Flags().GetBool("seed")
+		sse, _ := cmd.Flags().GetBool("sse")
+
+		fake := fakestompy.New()
+		fake.Token = token
+		fake.StreamMCP = sse
+		if seed {
+			fake.Seed()
+		}
+
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("listening on %s: %w", addr, err)
+		}
+		srv := &http.Server{Handler: fake, ReadHeaderTimeout: 10 * time.Second}
+
+		fmt.Printf("%s Fake Stompy API listening on http://%s/api/v1\n", output.Success("✓"), ln.Addr())
+		if seed {
+			fmt.Printf("  Seeded project %q\n", fakestompy.DemoProject)
+		}
+		fmt.Println(output.Dim("  Press Ctrl-C to stop."))
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- srv.Serve(ln) }()
+
+		select {
+		case err := <-errCh:
+			return err
+		case <-cmd.Context().Done():
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	devFakeServerCmd.Flags().String("addr", "127.0.0.1:8787", "Address to listen on")
+	devFakeServerCmd.Flags().String("token", "", "Require this Bearer token on every request")
+	devFakeServerCmd.Flags().Bool("seed", false, "Populate a demo project with sample data")
+	devFakeServerCmd.Flags().Bool("sse", false, "Answer MCP requests with text/event-stream responses")
+
+	devCmd.AddCommand(devFakeServerCmd)
+	rootCmd.AddCommand(devCmd)
+}
@@ -0,0 +1,425 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/auth"
+	"github.com/banton/stompy-cli/internal/config"
+	"github.com/banton/stompy-cli/internal/httpclient"
+	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/internal/update"
+	"github.com/banton/stompy-cli/pkg/stompy"
+	"github.com/spf13/cobra"
+)
+
+// Check outcomes reported by stompy doctor.
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+const (
+	// probeTimeout bounds each endpoint probe.
+	probeTimeout = 10 * time.Second
+	// Clock skew beyond skewWarn is reported; beyond skewFail token expiry
+	// checks become unreliable.
+	skewWarn = 1 * time.Minute
+	skewFail = auth.TokenExpiryBuffer
+)
+
+// doctorCheck is one line of the doctor report.
+type doctorCheck struct {
+	Name   string `json:"name" yaml:"name"`
+	Status string `json:"status" yaml:"status"`
+	Detail string `json:"detail" yaml:"detail"`
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose configuration, credentials and connectivity",
+	Long: `Check the config file, credentials, reachability of the REST, MCP and
+OAuth endpoints, clock skew, server compatibility, the default project and
+update status. Exits non-zero when any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		checks := runDoctor(cmd.Context())
+
+		failed := 0
+		for _, c := range checks {
+			if c.Status == checkFail {
+				failed++
+			}
+		}
+
+		if !isTableOutput() {
+			fmt.Println(getFormatter().FormatRaw(checks))
+		} else {
+			rows := make([][]string, 0, len(checks))
+			for _, c := range checks {
+				rows = append(rows, []string{c.Name, colorCheck(c.Status), c.Detail})
+			}
+			fmt.Print(getFormatter().FormatTable([]string{"CHECK", "STATUS", "DETAIL"}, rows))
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// runDoctor performs every check in report order.
+func runDoctor(ctx context.Context) []doctorCheck {
+	apiURL := resolveAPIURL()
+	checks := []doctorCheck{checkConfig(), checkNetworkSettings(), checkAuth()}
+
+	rest, health := probeEndpoint(ctx, "REST API", apiURL+"/health")
+	mcp, _ := probeEndpoint(ctx, "MCP endpoint", stompy.MCPBaseURL(apiURL))
+	oauth, _ := probeEndpoint(ctx, "OAuth endpoint", strings.TrimSuffix(apiURL, "/api/v1")+"/oauth/token")
+	checks = append(checks, rest, mcp, oauth)
+
+	if health != nil {
+		checks = append(checks, checkClock(health), checkServerVersion(health))
+	} else {
+		checks = append(checks,
+			doctorCheck{"Clock", checkWarn, "not checked: REST API unreachable"},
+			doctorCheck{"API version", checkWarn, "not checked: REST API unreachable"},
+		)
+	}
+
+	return append(checks, checkProject(ctx, apiURL, health != nil), checkUpdate())
+}
+
+// checkConfig reports whether the config file parses and is private to the user.
+func checkConfig() doctorCheck {
+	c := doctorCheck{Name: "Config file"}
+	path := config.GetConfigPath()
+
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		c.Status, c.Detail = checkPass, path+" not found; using defaults and environment"
+		return c
+	case err != nil:
+		c.Status, c.Detail = checkFail, err.Error()
+		return c
+	}
+	if err := config.Load(); err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		return c
+	}
+
+	// The file holds tokens and API keys
+	if mode := info.Mode().Perm(); runtime.GOOS != "windows" && mode&0o077 != 0 {
+		c.Status, c.Detail = checkWarn, fmt.Sprintf("%s is accessible by other users (mode %04o); run: chmod 600 %s", path, mode, path)
+		return c
+	}
+	c.Status, c.Detail = checkPass, path
+	if p := config.ActiveProfile(); p != config.DefaultProfile {
+		c.Detail += " (profile " + p + ")"
+	}
+	return c
+}
+
+// checkNetworkSettings validates the proxy and TLS settings.
+func checkNetworkSettings() doctorCheck {
+	c := doctorCheck{Name: "Proxy and TLS"}
+	opts := httpOptions()
+	if _, err := httpclient.NewTransport(opts); err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		return c
+	}
+
+	var parts []string
+	if opts.Proxy != "" {
+		parts = append(parts, "proxy "+redactURL(opts.Proxy))
+	} else if env := firstEnv("HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"); env != "" {
+		parts = append(parts, "proxy "+redactURL(env)+" (environment)")
+	} else {
+		parts = append(parts, "no proxy")
+	}
+	if opts.CAFile != "" {
+		parts = append(parts, "CA bundle "+opts.CAFile)
+	}
+	if opts.CertFile != "" {
+		parts = append(parts, "client certificate "+opts.CertFile)
+	}
+	if opts.MinTLSVersion != "" {
+		parts = append(parts, "TLS >= "+opts.MinTLSVersion)
+	}
+	c.Status, c.Detail = checkPass, strings.Join(parts, ", ")
+	return c
+}
+
+// checkAuth reports which credential will be used and whether it is still
+// valid, following the same precedence as resolveAuthToken. It never
+// refreshes the token itself.
+func checkAuth() doctorCheck {
+	c := doctorCheck{Name: "Authentication"}
+	switch {
+	case flagAPIKey != "":
+		c.Status, c.Detail = checkPass, "API key (--api-key)"
+		return c
+	case os.Getenv("STOMPY_API_KEY") != "":
+		c.Status, c.Detail = checkPass, "API key (STOMPY_API_KEY)"
+		return c
+	}
+
+	creds, err := auth.LoadCredentials()
+	if err != nil {
+		c.Status, c.Detail = checkFail, "credential store: "+err.Error()
+		return c
+	}
+	if creds != nil {
+		who := " (" + auth.CurrentStore().Name() + ")"
+		if creds.Email != "" {
+			who = " as " + creds.Email + who
+		}
+		switch {
+		case creds.Expiry.IsZero():
+			c.Status, c.Detail = checkWarn, "OAuth"+who+"; token has no recorded expiry"
+		case !auth.IsExpired(creds.Expiry):
+			c.Status, c.Detail = checkPass, fmt.Sprintf("OAuth%s; token expires in %s", who, time.Until(creds.Expiry).Round(time.Minute))
+		case creds.RefreshToken != "":
+			c.Status, c.Detail = checkWarn, "OAuth"+who+"; token expired, will be refreshed on the next command"
+		case config.GetAPIKey() != "":
+			c.Status, c.Detail = checkWarn, "OAuth token expired and cannot be refreshed; falling back to api_key from config"
+		default:
+			c.Status, c.Detail = checkFail, "OAuth token expired and cannot be refreshed; run 'stompy login'"
+		}
+		return c
+	}
+
+	if config.GetAPIKey() != "" {
+		c.Status, c.Detail = checkPass, "API key (config)"
+		return c
+	}
+	c.Status, c.Detail = checkFail, "not authenticated; run 'stompy login', or set STOMPY_API_KEY / --api-key"
+	return c
+}
+
+// probeEndpoint sends an unauthenticated GET to rawURL. Any HTTP response
+// below 500 means the endpoint is reachable; the response is returned for
+// further checks when one arrived.
+func probeEndpoint(ctx context.Context, name, rawURL string) (doctorCheck, *http.Response) {
+	c := doctorCheck{Name: name}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		return c, nil
+	}
+	req.Header.Set("User-Agent", "stompy-cli/"+Version)
+
+	start := time.Now()
+	resp, err := httpclient.New(0).Do(req)
+	if err != nil {
+		c.Status, c.Detail = checkFail, rawURL+": "+describeNetError(err)
+		return c, nil
+	}
+	resp.Body.Close()
+	elapsed := time.Since(start)
+
+	var parts []string
+	parts = append(parts, fmt.Sprintf("HTTP %d", resp.StatusCode))
+	if resp.TLS != nil {
+		parts = append(parts, tls.VersionName(resp.TLS.Version))
+	}
+	parts = append(parts, formatMillis(float64(elapsed.Microseconds())/1000))
+	c.Detail = rawURL + " (" + strings.Join(parts, ", ") + ")"
+	c.Status = checkPass
+	if resp.StatusCode >= 500 {
+		c.Status = checkWarn
+	}
+	return c, resp
+}
+
+// describeNetError names the stage a request failed at: DNS, TLS or connect.
+func describeNetError(err error) string {
+	var dnsErr *net.DNSError
+	var unknownCA x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var certErr x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	switch {
+	case errors.As(err, &dnsErr):
+		return "DNS lookup failed: " + dnsErr.Error()
+	case errors.As(err, &unknownCA):
+		return "TLS: certificate signed by an unknown authority (see --ca-bundle)"
+	case errors.As(err, &hostErr):
+		return "TLS: " + hostErr.Error()
+	case errors.As(err, &certErr):
+		return "TLS: " + certErr.Error()
+	case errors.As(err, &recordErr):
+		return "TLS: server did not answer with TLS"
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Sprintf("no response within %s", probeTimeout)
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return "connection failed: " + opErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// checkClock compares the local clock with the server's Date header.
+func checkClock(resp *http.Response) doctorCheck {
+	c := doctorCheck{Name: "Clock"}
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		c.Status, c.Detail = checkWarn, "not checked: server sent no Date header"
+		return c
+	}
+	c.Status, c.Detail = clockSkewStatus(time.Now().Sub(serverTime))
+	return c
+}
+
+// clockSkewStatus grades the difference between the local and server clocks.
+// The Date header has one-second resolution, so smaller differences are noise.
+func clockSkewStatus(skew time.Duration) (status, detail string) {
+	abs := skew.Abs().Round(time.Second)
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	switch {
+	case abs <= time.Second:
+		return checkPass, "in sync with the server"
+	case abs > skewFail:
+		return checkFail, fmt.Sprintf("local clock is %s %s the server; token expiry checks will be wrong, sync your clock", abs, direction)
+	case abs > skewWarn:
+		return checkWarn, fmt.Sprintf("local clock is %s %s the server", abs, direction)
+	}
+	return checkPass, fmt.Sprintf("local clock is %s %s the server", abs, direction)
+}
+
+// checkServerVersion reports the server's API version and whether it still
+// supports this CLI.
+func checkServerVersion(resp *http.Response) doctorCheck {
+	c := doctorCheck{Name: "API version"}
+	apiVer := resp.Header.Get("X-Stompy-API-Version")
+	minCLI := resp.Header.Get("X-Stompy-Min-CLI-Version")
+
+	if warn := stompy.CheckCompat(Version, minCLI); warn != "" {
+		c.Status, c.Detail = checkFail, fmt.Sprintf("stompy-cli %s is below the server's minimum %s; run 'stompy update'", Version, minCLI)
+		return c
+	}
+	if apiVer == "" {
+		c.Status, c.Detail = checkWarn, "server did not report its API version"
+		return c
+	}
+	c.Status, c.Detail = checkPass, "server "+apiVer
+	if minCLI != "" {
+		c.Detail += ", requires stompy-cli >= " + minCLI
+	}
+	return c
+}
+
+// checkProject confirms the default project exists and the credentials can
+// read it.
+func checkProject(ctx context.Context, apiURL string, reachable bool) doctorCheck {
+	c := doctorCheck{Name: "Default project"}
+	project, err := getProject()
+	if err != nil {
+		c.Status, c.Detail = checkWarn, "none set; run 'stompy project use <name>'"
+		return c
+	}
+	if !reachable {
+		c.Status, c.Detail = checkWarn, project+": not checked, REST API unreachable"
+		return c
+	}
+	token, err := resolveAuthToken(ctx)
+	if err != nil {
+		c.Status, c.Detail = checkWarn, project+": not checked, not authenticated"
+		return c
+	}
+
+	opts := []stompy.Option{stompy.WithToken(token), stompy.WithVersion(Version), stompy.WithRetries(0)}
+	if token == auth.AccessToken() {
+		opts = append(opts, stompy.WithTokenSource(&auth.ConfigTokenSource{APIURL: apiURL}))
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	p, err := stompy.NewClient(apiURL, opts...).GetProject(ctx, project, false)
+	switch {
+	case errors.Is(err, stompy.ErrNotFound):
+		c.Status, c.Detail = checkFail, fmt.Sprintf("project %q does not exist; run 'stompy project list'", project)
+	case errors.Is(err, stompy.ErrUnauthorized):
+		c.Status, c.Detail = checkFail, project+": credentials were rejected; run 'stompy login'"
+	case errors.Is(err, stompy.ErrForbidden):
+		c.Status, c.Detail = checkFail, project+": your account has no access to this project"
+	case err != nil:
+		c.Status, c.Detail = checkFail, project+": "+err.Error()
+	default:
+		c.Status, c.Detail = checkPass, p.Name
+		if p.Role != "" {
+			c.Detail += " (" + p.Role + ")"
+		}
+	}
+	return c
+}
+
+// checkUpdate reports whether a newer release is available.
+func checkUpdate() doctorCheck {
+	c := doctorCheck{Name: "Update"}
+	if Version == "dev" {
+		c.Status, c.Detail = checkPass, "development build, not checked"
+		return c
+	}
+	if latest := update.CheckForUpdate(Version, config.GetConfigDir()); latest != "" {
+		c.Status, c.Detail = checkWarn, fmt.Sprintf("stompy-cli %s available (running %s); run 'stompy update'", latest, Version)
+		return c
+	}
+	c.Status, c.Detail = checkPass, "stompy-cli "+Version+" is the latest release"
+	return c
+}
+
+// colorCheck colors a check status for table output.
+func colorCheck(status string) string {
+	switch status {
+	case checkPass:
+		return output.Success(status)
+	case checkWarn:
+		return output.Warn(status)
+	case checkFail:
+		return output.Error(status)
+	}
+	return status
+}
+
+// redactURL hides the password in a proxy URL.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+// firstEnv returns the first non-empty environment variable among names.
+func firstEnv(names ...string) string {
+	for _, n := range names {
+		if v := os.Getenv(n); v != "" {
+			return v
+		}
+	}
+	return ""
+}
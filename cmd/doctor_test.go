@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/fakestompy"
+)
+
+func TestClockSkewStatus(t *testing.T) {
+	cases := []struct {
+		skew time.Duration
+		want string
+	}{
+		{0, checkPass},
+		{-800 * time.Millisecond, checkPass},
+		{30 * time.Second, checkPass},
+		{-2 * time.Minute, checkWarn},
+		{10 * time.Minute, checkFail},
+	}
+	for _, tc := range cases {
+		if got, detail := clockSkewStatus(tc.skew); got != tc.want {
+			t.Errorf("clockSkewStatus(%s) = %s (%s), want %s", tc.skew, got, detail, tc.want)
+		}
+	}
+}
+
+func TestRunDoctor(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("STOMPY_API_KEY", "")
+	t.Setenv("STOMPY_PROJECT", "")
+
+	s := fakestompy.New()
+	s.Seed()
+	srv, baseURL := fakestompy.NewTestServer(s)
+	defer srv.Close()
+
+	defer func(url, key, project string) { flagAPIURL, flagAPIKey, flagProject = url, key, project }(flagAPIURL, flagAPIKey, flagProject)
+	flagAPIURL, flagAPIKey, flagProject = baseURL, "key", "demo"
+
+	statuses := func() map[string]string {
+		got := map[string]string{}
+		for _, c := range runDoctor(context.Background()) {
+			got[c.Name] = c.Status
+		}
+		return got
+	}
+
+	got := statuses()
+	for _, name := range []string{"Config file", "Proxy and TLS", "Authentication", "REST API", "MCP endpoint", "OAuth endpoint", "Clock", "API version", "Default project", "Update"} {
+		if got[name] != checkPass {
+			t.Errorf("%s = %q, want pass", name, got[name])
+		}
+	}
+
+	flagProject = "missing"
+	if got := statuses()["Default project"]; got != checkFail {
+		t.Errorf("missing project = %q, want fail", got)
+	}
+
+	srv.Close()
+	got = statuses()
+	if got["REST API"] != checkFail || got["Clock"] != checkWarn {
+		t.Errorf("server down: REST API = %q, Clock = %q; want fail, warn", got["REST API"], got["Clock"])
+	}
+}
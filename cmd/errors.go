@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// Exit codes. These are part of the CLI's public contract: scripts rely on
+// them to tell "the topic doesn't exist" apart from "the API is down", so
+// existing values must never change meaning.
+const (
+	exitError        = 1   // unclassified failure
+	exitUnauthorized = 3   // missing, invalid or expired credentials
+	exitForbidden    = 4   // authenticated but not allowed
+	exitNotFound     = 5   // project, topic, ticket, ... does not exist
+	exitConflict     = 6   // already exists or was modified concurrently
+	exitValidation   = 7   // the server rejected the request's input
+	exitRateLimited  = 8   // still throttled after retries
+	exitNetwork      = 9   // the API could not be reached
+	exitServer       = 10  // the API failed (5xx)
+	exitTimeout      = 124 // matches coreutils timeout(1)
+	exitInterrupted  = 130 // 128 + SIGINT
+)
+
+// errorClasses maps each error category to its exit code and a remediation
+// hint, checked in order.
+var errorClasses = []struct {
+	err  error
+	code int
+	hint string
+}{
+	{stompy.ErrUnauthorized, exitUnauthorized, "run 'stompy login', or set STOMPY_API_KEY / --api-key"},
+	{stompy.ErrForbidden, exitForbidden, "your account lacks access to this resource; check the project name and your role"},
+	{stompy.ErrNotFound, exitNotFound, "check the name or ID; the matching 'list' command shows what exists"},
+	{stompy.ErrConflict, exitConflict, "the resource already exists or changed since you read it; re-fetch and retry"},
+	{stompy.ErrValidation, exitValidation, "check the flags and values you passed (see --help)"},
+	{stompy.ErrRateLimited, exitRateLimited, "wait a moment and retry, or lower rate_limit in your config"},
+	{stompy.ErrNetwork, exitNetwork, "could not reach the API; check your connection, proxy settings and --api-url"},
+	{context.DeadlineExceeded, exitNetwork, "the API did not respond in time; check your connection and retry"},
+	{stompy.ErrServer, exitServer, "the Stompy API is having trouble; retry shortly, or rerun with --verbose to report it"},
+}
+
+// classifyError returns the exit code and remediation hint for err.
+func classifyError(err error) (code int, hint string) {
+	for _, c := range errorClasses {
+		if errors.Is(err, c.err) {
+			return c.code, c.hint
+		}
+	}
+	return exitError, ""
+}
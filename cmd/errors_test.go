@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantHint bool
+	}{
+		{"not found", &stompy.APIError{StatusCode: 404, Message: "topic not found"}, exitNotFound, true},
+		{"wrapped unauthorized", fmt.Errorf("listing: %w", &stompy.APIError{StatusCode: 401}), exitUnauthorized, true},
+		{"no credentials", fmt.Errorf("%w: nothing configured", stompy.ErrUnauthorized), exitUnauthorized, true},
+		{"server", &stompy.APIError{StatusCode: 502}, exitServer, true},
+		{"rate limited", &stompy.APIError{StatusCode: 429}, exitRateLimited, true},
+		{"mcp method", &stompy.RPCError{Code: -32601}, exitNotFound, true},
+		{"network", &stompy.NetworkError{Op: "executing request", Err: errors.New("connection refused")}, exitNetwork, true},
+		{"request timeout", fmt.Errorf("calling tool: %w", context.DeadlineExceeded), exitNetwork, true},
+		{"plain", errors.New("--status is required"), exitError, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, hint := classifyError(tt.err)
+			if code != tt.wantCode {
+				t.Errorf("code = %d, want %d", code, tt.wantCode)
+			}
+			if (hint != "") != tt.wantHint {
+				t.Errorf("hint = %q, wantHint %v", hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestCommandTimedOut(t *testing.T) {
+	t.Cleanup(func() { flagTimeout, timeoutCtx = 0, nil })
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	// A request deadline without --timeout is not the command's timeout
+	flagTimeout, timeoutCtx = 0, nil
+	if commandTimedOut() {
+		t.Error("commandTimedOut() = true without --timeout")
+	}
+
+	flagTimeout, timeoutCtx = time.Minute, context.Background()
+	if commandTimedOut() {
+		t.Error("commandTimedOut() = true before the --timeout deadline")
+	}
+
+	flagTimeout, timeoutCtx = time.Minute, expired
+	if !commandTimedOut() {
+		t.Error("commandTimedOut() = false after the --timeout deadline")
+	}
+}
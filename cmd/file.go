@@ -1,10 +1,22 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/banton/stompy-cli/internal/config"
+	"github.com/banton/stompy-cli/internal/filesync"
 	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +28,24 @@ var fileCmd = &cobra.Command{
 var fileUploadCmd = &cobra.Command{
 	Use:   "upload <path>",
 	Short: "Upload a document",
-	Args:  cobra.ExactArgs(1),
+	Long: `Upload a document. The file is streamed rather than read into memory, and
+files larger than one chunk are sent in resumable chunks when the server
+supports it: if an upload fails, running the same command again continues
+from where it stopped.
+
+Given a directory, every file in it (and, with --recursive, in its
+subdirectories) is uploaded in parallel under its relative path. Hidden files
+are skipped. Uploaded content is recorded in a local manifest, so files
+whose SHA-256 has not changed are skipped on the next run and changed files
+are uploaded again. With --sync, remote copies of files removed locally and
+previous versions of changed files are listed for deletion; add --confirm
+to delete them.
+
+  stompy file upload design.fig --label "Q3 design export"
+  stompy file upload notes.pdf --meta source=scanner --meta pages=12
+  stompy file upload ./docs --recursive --include '*.pdf' --concurrency 4
+  stompy file upload ./docs --recursive --sync --confirm`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		project, err := getProject()
 		if err != nil {
@@ -24,23 +53,175 @@ var fileUploadCmd = &cobra.Command{
 		}
 
 		label, _ := cmd.Flags().GetString("label")
+		metaFlags, _ := cmd.Flags().GetStringArray("meta")
+		chunkMB, _ := cmd.Flags().GetInt("chunk-size")
+
+		metadata, err := parseKeyValues("--meta", metaFlags)
+		if err != nil {
+			return err
+		}
 
-		resp, err := apiClient.UploadFile(project, args[0], label)
+		info, err := os.Stat(args[0])
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return uploadTree(cmd, project, args[0], label, metadata, int64(chunkMB)<<20)
+		}
+		for _, name := range []string{"recursive", "include", "exclude", "sync", "confirm", "dry-run"} {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("%w: --%s only applies when uploading a directory", stompy.ErrValidation, name)
+			}
+		}
+
+		stateFile, err := uploadStateFile(project, args[0])
+		if err != nil {
+			return err
+		}
+
+		progress := output.NewProgress(os.Stderr, filepath.Base(args[0]))
+		resp, err := apiClient.UploadFile(cmd.Context(), project, args[0], stompy.UploadOptions{
+			Label:     label,
+			Metadata:  metadata,
+			Progress:  progress.Update,
+			ChunkSize: int64(chunkMB) << 20,
+			StateFile: stateFile,
+		})
+		progress.Done()
 		if err != nil {
 			return err
 		}
 
 		f := getFormatter()
-		fmt.Print(f.FormatSingle([]output.KeyValue{
+		fields := []output.KeyValue{
 			{Key: "ID", Value: fmt.Sprintf("%d", resp.ID)},
 			{Key: "Filename", Value: resp.Filename},
-			{Key: "Size", Value: formatBytes(resp.SizeBytes)},
-			{Key: "Created", Value: resp.CreatedAt.Local().Format("2006-01-02 15:04:05")},
-		}))
+		}
+		if resp.Label != "" {
+			fields = append(fields, output.KeyValue{Key: "Label", Value: resp.Label})
+		}
+		fields = append(fields,
+			output.KeyValue{Key: "Size", Value: formatBytes(resp.SizeBytes)},
+			output.KeyValue{Key: "Created", Value: resp.CreatedAt.Local().Format("2006-01-02 15:04:05")},
+		)
+		fields = append(fields, metadataFields(resp.Metadata)...)
+		fmt.Print(f.FormatSingle(fields))
 		return nil
 	},
 }
 
+// uploadTree uploads the files under root, skipping those already uploaded
+// with the same content.
+func uploadTree(cmd *cobra.Command, project, root, label string, metadata map[string]string, chunkSize int64) error {
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	include, _ := cmd.Flags().GetStringArray("include")
+	exclude, _ := cmd.Flags().GetStringArray("exclude")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	syncRemote, _ := cmd.Flags().GetBool("sync")
+	confirm, _ := cmd.Flags().GetBool("confirm")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if err := filesync.ValidatePatterns(append(slices.Clone(include), exclude...)); err != nil {
+		return err
+	}
+	if confirm && !syncRemote {
+		return fmt.Errorf("%w: --confirm only applies with --sync", stompy.ErrValidation)
+	}
+	if concurrency < 1 {
+		return fmt.Errorf("%w: --concurrency must be at least 1", stompy.ErrValidation)
+	}
+
+	manifestPath, err := manifestFile(project, root)
+	if err != nil {
+		return err
+	}
+	manifest, err := filesync.LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	opts := filesync.Options{
+		Recursive:   recursive,
+		Include:     include,
+		Exclude:     exclude,
+		Label:       label,
+		Metadata:    metadata,
+		Concurrency: concurrency,
+		ChunkSize:   chunkSize,
+		Sync:        syncRemote,
+		Delete:      confirm,
+		DryRun:      dryRun,
+		StateFile: func(p string) string {
+			f, _ := uploadStateFile(project, p)
+			return f
+		},
+	}
+
+	var (
+		mu      sync.Mutex
+		results []filesync.Result
+	)
+	report := func(r filesync.Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, r)
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", output.Error("✗"), r.Path, r.Err)
+		}
+	}
+	runErr := filesync.Run(cmd.Context(), apiClient, project, root, manifest, opts, report)
+	if !dryRun {
+		// Save even after a failure so completed uploads are not repeated
+		if err := manifest.Save(manifestPath); err != nil && runErr == nil {
+			runErr = err
+		}
+	}
+	if runErr != nil {
+		return runErr
+	}
+
+	slices.SortStableFunc(results, func(a, b filesync.Result) int { return strings.Compare(a.Path, b.Path) })
+	counts := map[filesync.Action]int{}
+	planned := 0
+	var rows [][]string
+	for _, r := range results {
+		action := string(r.Action)
+		if r.DryRun {
+			action = "would " + action
+			planned++
+		} else {
+			counts[r.Action]++
+		}
+		id := ""
+		if r.FileID != 0 {
+			id = strconv.Itoa(r.FileID)
+		}
+		path := r.Path
+		if r.Previous {
+			path += " (previous version)"
+		}
+		rows = append(rows, []string{path, action, id, formatBytes(int(r.Size))})
+	}
+
+	fmt.Print(getFormatter().FormatTable([]string{"PATH", "ACTION", "FILE ID", "SIZE"}, rows))
+	if isTableOutput() {
+		fmt.Fprintf(os.Stderr, "%d uploaded, %d replaced, %d unchanged, %d deleted, %d failed",
+			counts[filesync.ActionUpload], counts[filesync.ActionReplace], counts[filesync.ActionSkip],
+			counts[filesync.ActionDelete], counts[filesync.ActionFailed])
+		if planned > 0 {
+			fmt.Fprintf(os.Stderr, ", %d planned", planned)
+		}
+		fmt.Fprintln(os.Stderr)
+		if syncRemote && !confirm && !dryRun && planned > 0 {
+			fmt.Fprintln(os.Stderr, output.Dim("Pass --confirm to delete the files listed above."))
+		}
+	}
+	if n := counts[filesync.ActionFailed]; n > 0 {
+		return fmt.Errorf("%d file(s) failed to sync", n)
+	}
+	return nil
+}
+
 var fileListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List uploaded documents",
@@ -51,10 +232,14 @@ var fileListCmd = &cobra.Command{
 		}
 
 		search, _ := cmd.Flags().GetString("search")
-		limit, _ := cmd.Flags().GetInt("limit")
-		offset, _ := cmd.Flags().GetInt("offset")
 
-		resp, err := apiClient.ListFiles(project, search, limit, offset)
+		files, total, err := listItems(cmd, func(limit, offset int) ([]stompy.FileResponse, int, error) {
+			resp, err := apiClient.ListFiles(cmd.Context(), project, search, limit, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			return resp.Files, resp.Total, nil
+		})
 		if err != nil {
 			return err
 		}
@@ -62,7 +247,7 @@ var fileListCmd = &cobra.Command{
 		f := getFormatter()
 		headers := []string{"ID", "FILENAME", "LABEL", "SIZE", "CREATED"}
 		var rows [][]string
-		for _, file := range resp.Files {
+		for _, file := range files {
 			rows = append(rows, []string{
 				fmt.Sprintf("%d", file.ID),
 				file.Filename,
@@ -74,7 +259,7 @@ var fileListCmd = &cobra.Command{
 
 		fmt.Print(f.FormatTable(headers, rows))
 		if isTableOutput() {
-			fmt.Printf("\nTotal: %d files\n", resp.Total)
+			fmt.Printf("\nTotal: %d files\n", total)
 		}
 		return nil
 	},
@@ -95,7 +280,7 @@ var fileGetCmd = &cobra.Command{
 			return fmt.Errorf("invalid file ID: %s", args[0])
 		}
 
-		resp, err := apiClient.GetFile(project, id)
+		resp, err := apiClient.GetFile(cmd.Context(), project, id)
 		if err != nil {
 			return err
 		}
@@ -109,6 +294,7 @@ var fileGetCmd = &cobra.Command{
 			{Key: "Size", Value: formatBytes(resp.SizeBytes)},
 			{Key: "Created", Value: resp.CreatedAt.Local().Format("2006-01-02 15:04:05")},
 		}
+		fields = append(fields, metadataFields(resp.Metadata)...)
 
 		fmt.Print(f.FormatSingle(fields))
 		return nil
@@ -135,7 +321,7 @@ var fileDeleteCmd = &cobra.Command{
 			return fmt.Errorf("invalid file ID: %s", args[0])
 		}
 
-		if err := apiClient.DeleteFile(project, id); err != nil {
+		if err := apiClient.DeleteFile(cmd.Context(), project, id); err != nil {
 			return err
 		}
 
@@ -144,18 +330,225 @@ var fileDeleteCmd = &cobra.Command{
 	},
 }
 
+var fileDownloadCmd = &cobra.Command{
+	Use:   "download <id>",
+	Short: "Download a file's contents",
+	Long: `Download a file's contents, saved under its original filename in the
+current directory unless -O names another path or directory. Use -O - to
+write to stdout. Content is verified against the server's checksum when one
+is provided, and nothing is written to disk unless the download completes.
+
+  stompy file download 42
+  stompy file download 42 -O exports/
+  stompy file download 42 -O - | tar x`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := getProject()
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid file ID: %s", args[0])
+		}
+		out, _ := cmd.Flags().GetString("out")
+		force, _ := cmd.Flags().GetBool("force")
+
+		if out == "-" {
+			_, err := apiClient.DownloadFile(cmd.Context(), project, id, os.Stdout)
+			return err
+		}
+
+		info, err := apiClient.GetFile(cmd.Context(), project, id)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(info.Filename)
+		if name == "." || name == string(filepath.Separator) {
+			name = fmt.Sprintf("file-%d", id)
+		}
+		if out == "" {
+			out = name
+		} else if st, err := os.Stat(out); err == nil && st.IsDir() {
+			out = filepath.Join(out, name)
+		}
+		if _, err := os.Stat(out); err == nil && !force {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", out)
+		}
+
+		// Download next to the destination and rename, so a failed or
+		// corrupted download never leaves a partial file behind.
+		tmp, err := os.CreateTemp(filepath.Dir(out), ".stompy-download-*")
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", out, err)
+		}
+		defer os.Remove(tmp.Name())
+
+		progress := output.NewProgress(os.Stderr, name)
+		n, err := apiClient.DownloadFile(cmd.Context(), project, id, &progressWriter{w: tmp, total: int64(info.SizeBytes), fn: progress.Update})
+		progress.Done()
+		if closeErr := tmp.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("writing %s: %w", out, closeErr)
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", out, err)
+		}
+		if err := os.Rename(tmp.Name(), out); err != nil {
+			return fmt.Errorf("writing %s: %w", out, err)
+		}
+
+		fmt.Printf("%s Downloaded %s (%s)\n", output.Success("✓"), output.Teal(out), formatBytes(int(n)))
+		return nil
+	},
+}
+
+var fileCatCmd = &cobra.Command{
+	Use:   "cat <id>",
+	Short: "Print a file's contents to stdout",
+	Long: `Print a file's contents to stdout, for piping text files into other tools.
+Binary files are not written to a terminal unless --force is given.
+
+  stompy file cat 42 | grep TODO`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := getProject()
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid file ID: %s", args[0])
+		}
+		force, _ := cmd.Flags().GetBool("force")
+
+		if stat, _ := os.Stdout.Stat(); !force && stat != nil && stat.Mode()&os.ModeCharDevice != 0 {
+			info, err := apiClient.GetFile(cmd.Context(), project, id)
+			if err != nil {
+				return err
+			}
+			if !isTextMIME(info.MimeType) {
+				return fmt.Errorf("file %d is %s, not text; use 'stompy file download %d' or pass --force", id, info.MimeType, id)
+			}
+		}
+
+		_, err = apiClient.DownloadFile(cmd.Context(), project, id, os.Stdout)
+		return err
+	},
+}
+
+// isTextMIME reports whether a MIME type is safe to print to a terminal.
+func isTextMIME(mimeType string) bool {
+	mt, _, _ := strings.Cut(mimeType, ";")
+	mt = strings.TrimSpace(mt)
+	switch {
+	case mt == "", strings.HasPrefix(mt, "text/"):
+		return true
+	case strings.HasSuffix(mt, "+json"), strings.HasSuffix(mt, "+xml"):
+		return true
+	}
+	switch mt {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml", "application/javascript", "application/x-sh":
+		return true
+	}
+	return false
+}
+
+// progressWriter passes writes through to w and reports the running total.
+type progressWriter struct {
+	w     io.Writer
+	n     int64
+	total int64
+	fn    func(written, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.n += int64(n)
+	p.fn(p.n, p.total)
+	return n, err
+}
+
+// parseKeyValues parses repeated key=value flag values into a map.
+func parseKeyValues(flag string, values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	m := make(map[string]string, len(values))
+	for _, kv := range values {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: %s %q must be key=value", stompy.ErrValidation, flag, kv)
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// metadataFields renders file metadata as "Meta: key" fields, sorted by key.
+func metadataFields(metadata map[string]string) []output.KeyValue {
+	var fields []output.KeyValue
+	for _, k := range slices.Sorted(maps.Keys(metadata)) {
+		fields = append(fields, output.KeyValue{Key: "Meta: " + k, Value: metadata[k]})
+	}
+	return fields
+}
+
+// uploadStateFile returns where the resumable upload session for path in
+// project is recorded, so a rerun after a failure can continue it.
+func uploadStateFile(project, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(project + "\x00" + abs))
+	return filepath.Join(config.GetConfigDir(), "uploads", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// manifestFile returns where the manifest of files uploaded from root to
+// project is kept.
+func manifestFile(project, root string) (string, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(project + "\x00" + abs))
+	return filepath.Join(config.GetConfigDir(), "manifests", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
 func init() {
 	fileUploadCmd.Flags().String("label", "", "Label/description for the file")
+	fileUploadCmd.Flags().StringArray("meta", nil, "Extra metadata as key=value (repeatable)")
+	fileUploadCmd.Flags().Int("chunk-size", 0, "Resumable upload chunk size in MiB (default: server's choice)")
+	fileUploadCmd.Flags().BoolP("recursive", "r", false, "Upload subdirectories too (directory uploads)")
+	fileUploadCmd.Flags().StringArray("include", nil, "Only upload files matching this glob (repeatable)")
+	fileUploadCmd.Flags().StringArray("exclude", nil, "Skip files matching this glob (repeatable)")
+	fileUploadCmd.Flags().Int("concurrency", 4, "Parallel uploads (directory uploads)")
+	fileUploadCmd.Flags().Bool("sync", false, "List remote files removed locally and previous versions of changed files for deletion")
+	fileUploadCmd.Flags().Bool("confirm", false, "Delete the files found by --sync")
+	fileUploadCmd.Flags().Bool("dry-run", false, "Show what would be uploaded without uploading")
 
 	fileListCmd.Flags().String("search", "", "Search files by name")
 	fileListCmd.Flags().Int("limit", 0, "Limit results")
 	fileListCmd.Flags().Int("offset", 0, "Offset for pagination")
+	addPaginationFlags(fileListCmd)
+
+	fileDownloadCmd.Flags().StringP("out", "O", "", "Destination file or directory, or - for stdout (default: original filename)")
+	fileDownloadCmd.Flags().Bool("force", false, "Overwrite an existing file")
+
+	fileCatCmd.Flags().Bool("force", false, "Write binary content to a terminal")
 
 	fileDeleteCmd.Flags().Bool("confirm", false, "Confirm deletion (required)")
 
 	fileCmd.AddCommand(fileUploadCmd)
 	fileCmd.AddCommand(fileListCmd)
 	fileCmd.AddCommand(fileGetCmd)
+	fileCmd.AddCommand(fileDownloadCmd)
+	fileCmd.AddCommand(fileCatCmd)
 	fileCmd.AddCommand(fileDeleteCmd)
 	rootCmd.AddCommand(fileCmd)
 }
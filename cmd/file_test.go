@@ -0,0 +1,20 @@
+package cmd
+
+import "testing"
+
+func TestIsTextMIME(t *testing.T) {
+	cases := map[string]bool{
+		"text/plain; charset=utf-8": true,
+		"application/json":          true,
+		"application/ld+json":       true,
+		"":                          true,
+		"application/pdf":           false,
+		"image/png":                 false,
+		"application/octet-stream":  false,
+	}
+	for mt, want := range cases {
+		if got := isTextMIME(mt); got != want {
+			t.Errorf("isTextMIME(%q) = %v, want %v", mt, got, want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/banton/stompy-cli/internal/mcpbridge"
+	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "List and call any tool on the Stompy MCP server",
+}
+
+var mcpToolsCmd = &cobra.Command{
+	Use:   "tools [tool]",
+	Short: "List MCP tools, or describe one tool's arguments",
+	Long: `List the tools the MCP server advertises. With a tool name, show that
+tool's arguments. Use -o json to get the full input schemas.
+
+  stompy mcp tools
+  stompy mcp tools context_explore
+  stompy mcp tools -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			tool, err := mcpClient.Tool(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return printToolDetail(tool)
+		}
+
+		tools, err := mcpClient.ListTools(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		f := getFormatter()
+		if !isTableOutput() {
+			items := make([]map[string]any, 0, len(tools))
+			for _, t := range tools {
+				items = append(items, toolOutput(&t))
+			}
+			fmt.Println(f.FormatRaw(items))
+			return nil
+		}
+
+		headers := []string{"NAME", "DESCRIPTION", "ARGUMENTS"}
+		var rows [][]string
+		for _, t := range tools {
+			schema, err := t.Schema()
+			if err != nil {
+				return err
+			}
+			desc := firstLine(t.Description)
+			if len(desc) > 60 {
+				desc = desc[:57] + "..."
+			}
+			rows = append(rows, []string{t.Name, desc, summarizeArgs(schema)})
+		}
+
+		fmt.Print(f.FormatTable(headers, rows))
+		fmt.Printf("\nTotal: %d tools\n", len(tools))
+		return nil
+	},
+}
+
+var mcpCallCmd = &cobra.Command{
+	Use:   "call <tool>",
+	Short: "Call an MCP tool",
+	Long: `Call any MCP tool by name. Arguments come from --json (an object, inline,
+@file or - for stdin) and then --arg key=value, which overrides --json.
+--arg values are converted to the types the tool's input schema declares:
+numbers, booleans, comma-separated or JSON arrays, and JSON objects. Tools
+that take a "project" argument default it to the active project.
+
+  stompy mcp call context_explore --arg project=myproj --arg verbose=true
+  stompy mcp call recall_batch --arg project=myproj --arg topics=a,b
+  stompy mcp call project_brief --json @args.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		jsonFlag, _ := cmd.Flags().GetString("json")
+		argFlags, _ := cmd.Flags().GetStringArray("arg")
+		noValidate, _ := cmd.Flags().GetBool("no-validate")
+
+		tool, err := mcpClient.Tool(cmd.Context(), name)
+		if err != nil {
+			return err
+		}
+		schema, err := tool.Schema()
+		if err != nil {
+			return err
+		}
+
+		mcpArgs, err := buildToolArgs(schema, jsonFlag, argFlags)
+		if err != nil {
+			return err
+		}
+		if _, ok := schema.Properties["project"]; ok && mcpArgs["project"] == nil {
+			if project, err := getProject(); err == nil {
+				mcpArgs["project"] = project
+			}
+		}
+		if !noValidate {
+			if err := schema.Validate(mcpArgs); err != nil {
+				return err
+			}
+		}
+
+		text, err := mcpClient.CallTool(cmd.Context(), name, mcpArgs)
+		if err != nil {
+			return err
+		}
+
+		// Structured output re-encodes JSON results; anything else is printed as-is
+		var decoded any
+		if !isTableOutput() && json.Unmarshal([]byte(text), &decoded) == nil {
+			fmt.Println(getFormatter().FormatRaw(decoded))
+			return nil
+		}
+		fmt.Println(text)
+		return nil
+	},
+}
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve MCP over stdio, forwarding to the Stompy MCP server",
+	Long: `Speak MCP over stdin/stdout and forward every request to the remote Stompy
+MCP endpoint using the CLI's credentials, refreshing OAuth tokens as needed.
+Point any stdio-only MCP client at it instead of configuring OAuth there:
+
+  {"mcpServers": {"stompy": {"command": "stompy", "args": ["mcp", "serve"]}}}
+
+Tool calls that take a "project" argument default to --project (or the
+configured default project) when the client leaves it out. Logs go to stderr.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bridge := &mcpbridge.Bridge{Upstream: mcpClient, Verbose: flagVerbose}
+		if project, err := getProject(); err == nil {
+			bridge.DefaultProject = project
+		}
+		return bridge.Serve(cmd.Context(), os.Stdin, os.Stdout)
+	},
+}
+
+// buildToolArgs merges the --json object with --arg key=value pairs, coercing
+// each --arg value to the type declared by schema.
+func buildToolArgs(schema *stompy.ToolSchema, jsonFlag string, argFlags []string) (map[string]any, error) {
+	mcpArgs := map[string]any{}
+	if jsonFlag != "" {
+		data, err := readJSONFlag(jsonFlag)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &mcpArgs); err != nil {
+			return nil, fmt.Errorf("%w: --json must be a JSON object: %w", stompy.ErrValidation, err)
+		}
+		if mcpArgs == nil {
+			mcpArgs = map[string]any{}
+		}
+	}
+
+	for _, kv := range argFlags {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: --arg %q must be key=value", stompy.ErrValidation, kv)
+		}
+		v, err := schema.Coerce(key, value)
+		if err != nil {
+			return nil, err
+		}
+		mcpArgs[key] = v
+	}
+	return mcpArgs, nil
+}
+
+// readJSONFlag resolves a --json value: inline JSON, @file, or - for stdin.
+func readJSONFlag(value string) ([]byte, error) {
+	switch {
+	case value == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+		return data, nil
+	case strings.HasPrefix(value, "@"):
+		filePath := strings.TrimPrefix(value, "@")
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading file %q: %w", filePath, err)
+		}
+		return data, nil
+	}
+	return []byte(value), nil
+}
+
+// printToolDetail shows one tool's description and argument table.
+func printToolDetail(tool *stompy.MCPTool) error {
+	schema, err := tool.Schema()
+	if err != nil {
+		return err
+	}
+
+	f := getFormatter()
+	if !isTableOutput() {
+		fmt.Println(f.FormatRaw(toolOutput(tool)))
+		return nil
+	}
+
+	fields := []output.KeyValue{{Key: "Name", Value: tool.Name}}
+	if tool.Title != "" {
+		fields = append(fields, output.KeyValue{Key: "Title", Value: tool.Title})
+	}
+	fields = append(fields, output.KeyValue{Key: "Description", Value: tool.Description})
+	fmt.Print(f.FormatSingle(fields))
+
+	headers := []string{"ARGUMENT", "TYPE", "REQUIRED", "DESCRIPTION"}
+	var rows [][]string
+	for _, name := range schema.PropertyNames() {
+		prop := schema.Properties[name]
+		typ := prop.Type.String()
+		if prop.Items != nil {
+			typ += "<" + prop.Items.Type.String() + ">"
+		}
+		if len(prop.Enum) > 0 {
+			typ += fmt.Sprintf(" %v", prop.Enum)
+		}
+		required := ""
+		if slices.Contains(schema.Required, name) {
+			required = "yes"
+		}
+		rows = append(rows, []string{name, typ, required, prop.Description})
+	}
+	fmt.Println()
+	fmt.Print(f.FormatTable(headers, rows))
+	return nil
+}
+
+// toolOutput renders a tool for -o json/yaml with its schema decoded, so
+// YAML output does not show the raw schema bytes.
+func toolOutput(t *stompy.MCPTool) map[string]any {
+	item := map[string]any{"name": t.Name, "description": t.Description}
+	if t.Title != "" {
+		item["title"] = t.Title
+	}
+	var schema any
+	if len(t.InputSchema) > 0 && json.Unmarshal(t.InputSchema, &schema) == nil {
+		item["inputSchema"] = schema
+	}
+	return item
+}
+
+// summarizeArgs lists a schema's arguments for the tools table, marking
+// required ones with "*", e.g. "project*, grep, verbose".
+func summarizeArgs(schema *stompy.ToolSchema) string {
+	var parts []string
+	for _, name := range schema.PropertyNames() {
+		if slices.Contains(schema.Required, name) {
+			name += "*"
+		}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func init() {
+	mcpCallCmd.Flags().StringArray("arg", nil, "Tool argument as key=value (repeatable)")
+	mcpCallCmd.Flags().String("json", "", "Tool arguments as a JSON object (inline, @file, or - for stdin)")
+	mcpCallCmd.Flags().Bool("no-validate", false, "Skip client-side schema validation")
+
+	mcpCmd.AddCommand(mcpToolsCmd)
+	mcpCmd.AddCommand(mcpCallCmd)
+	mcpCmd.AddCommand(mcpServeCmd)
+	rootCmd.AddCommand(mcpCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+func TestBuildToolArgs(t *testing.T) {
+	tool := stompy.MCPTool{Name: "t", InputSchema: json.RawMessage(`{
+		"type": "object",
+		"properties": {"project": {"type": "string"}, "limit": {"type": "integer"}}
+	}`)}
+	schema, err := tool.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "args.json")
+	if err := os.WriteFile(path, []byte(`{"project": "from-file", "limit": 1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := buildToolArgs(schema, "@"+path, []string{"limit=5", "extra=a=b"})
+	if err != nil {
+		t.Fatalf("buildToolArgs failed: %v", err)
+	}
+	want := map[string]any{"project": "from-file", "limit": int64(5), "extra": "a=b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %#v, want %#v", got, want)
+	}
+
+	if _, err := buildToolArgs(schema, "", []string{"novalue"}); !errors.Is(err, stompy.ErrValidation) {
+		t.Errorf("missing '=' error = %v, want ErrValidation", err)
+	}
+	if _, err := buildToolArgs(schema, "[1]", nil); !errors.Is(err, stompy.ErrValidation) {
+		t.Errorf("non-object --json error = %v, want ErrValidation", err)
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/banton/stompy-cli/internal/auth"
+	"github.com/banton/stompy-cli/internal/config"
+	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named profiles for accounts and environments",
+	Long: `Profiles keep separate API URLs, credentials, default projects and output
+formats, e.g. for a personal account, a work account and a staging server.
+The top-level settings form the "default" profile.
+
+Select a profile with --profile, STOMPY_PROFILE, or 'stompy profile use'.
+login, logout and whoami act on the selected profile.`,
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create a profile",
+	Long: `Create a profile. The global --api-url, --api-key, --project and --output
+flags set the profile's values; --api-url defaults to the top-level api_url.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := config.ValidateProfileName(name); err != nil {
+			return fmt.Errorf("%w: %w", stompy.ErrValidation, err)
+		}
+		if config.ProfileExists(name) {
+			return fmt.Errorf("%w: profile %q already exists", stompy.ErrConflict, name)
+		}
+
+		use, _ := cmd.Flags().GetBool("use")
+
+		err := config.AddProfile(name, config.Profile{
+			APIURL:         flagAPIURL,
+			APIKey:         flagAPIKey,
+			DefaultProject: flagProject,
+			OutputFormat:   flagOutput,
+		})
+		if err != nil {
+			return fmt.Errorf("saving profile: %w", err)
+		}
+		fmt.Printf("Profile %q created.\n", name)
+
+		if use {
+			if err := config.UseProfile(name); err != nil {
+				return fmt.Errorf("saving current profile: %w", err)
+			}
+			fmt.Printf("Switched to profile %q.\n", name)
+		}
+		if flagAPIKey == "" {
+			fmt.Printf("Log in with: stompy login --profile %s\n", name)
+		}
+		return nil
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		active := config.ActiveProfile()
+
+		var rows [][]string
+		for _, name := range config.ListProfiles() {
+			p := config.GetProfile(name)
+			current := ""
+			if name == active {
+				current = "*"
+			}
+			rows = append(rows, []string{current, name, p.APIURL, p.DefaultProject, p.OutputFormat})
+		}
+		fmt.Print(getFormatter().FormatTable([]string{"CURRENT", "NAME", "API URL", "PROJECT", "OUTPUT"}, rows))
+		return nil
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set the profile used when none is given",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !config.ProfileExists(args[0]) {
+			return fmt.Errorf("%w: profile %q does not exist", stompy.ErrNotFound, args[0])
+		}
+		if err := config.UseProfile(args[0]); err != nil {
+			return fmt.Errorf("saving current profile: %w", err)
+		}
+		fmt.Printf("Switched to profile %q.\n", args[0])
+		if env := os.Getenv("STOMPY_PROFILE"); env != "" && env != args[0] {
+			fmt.Fprintln(os.Stderr, output.Dim(fmt.Sprintf("Note: STOMPY_PROFILE=%s overrides this in the current shell.", env)))
+		}
+		return nil
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Delete a profile and its stored credentials",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if name == config.DefaultProfile {
+			return fmt.Errorf("%w: the default profile cannot be removed", stompy.ErrValidation)
+		}
+		if !config.ProfileExists(name) {
+			return fmt.Errorf("%w: profile %q does not exist", stompy.ErrNotFound, name)
+		}
+
+		// Tokens outside config.yaml (encrypted file, helper) go with the profile
+		if err := config.SetProfile(name); err == nil {
+			configureCredentialStore()
+			if _, ok := auth.CurrentStore().(auth.ConfigStore); !ok {
+				if err := auth.ClearCredentials(); err != nil {
+					fmt.Fprintln(os.Stderr, output.Dim("Warning: could not remove stored credentials: "+err.Error()))
+				}
+			}
+		}
+
+		if err := config.DeleteProfile(name); err != nil {
+			return fmt.Errorf("removing profile: %w", err)
+		}
+		fmt.Printf("Profile %q removed.\n", name)
+		return nil
+	},
+}
+
+func init() {
+	profileAddCmd.Flags().Bool("use", false, "Switch to the new profile")
+
+	profileCmd.AddCommand(profileAddCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+	rootCmd.AddCommand(profileCmd)
+}
@@ -3,9 +3,9 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/banton/stompy-cli/internal/api"
 	"github.com/banton/stompy-cli/internal/config"
 	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
@@ -20,12 +20,12 @@ var projectCreateCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		desc, _ := cmd.Flags().GetString("description")
-		req := api.ProjectCreate{Name: args[0]}
+		req := stompy.ProjectCreate{Name: args[0]}
 		if desc != "" {
 			req.Description = &desc
 		}
 
-		resp, err := apiClient.CreateProject(req)
+		resp, err := apiClient.CreateProject(cmd.Context(), req)
 		if err != nil {
 			return err
 		}
@@ -46,7 +46,7 @@ var projectListCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		withStats, _ := cmd.Flags().GetBool("stats")
 
-		resp, err := apiClient.ListProjects(withStats)
+		resp, err := apiClient.ListProjects(cmd.Context(), withStats)
 		if err != nil {
 			return err
 		}
@@ -90,7 +90,7 @@ var projectInfoCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		withStats, _ := cmd.Flags().GetBool("stats")
 
-		resp, err := apiClient.GetProject(args[0], withStats)
+		resp, err := apiClient.GetProject(cmd.Context(), args[0], withStats)
 		if err != nil {
 			return err
 		}
@@ -134,7 +134,7 @@ var projectDeleteCmd = &cobra.Command{
 			return fmt.Errorf("must pass --confirm to delete project %q", args[0])
 		}
 
-		if err := apiClient.DeleteProject(args[0]); err != nil {
+		if err := apiClient.DeleteProject(cmd.Context(), args[0]); err != nil {
 			return err
 		}
 
@@ -192,7 +192,7 @@ var projectBriefCmd = &cobra.Command{
 		}
 
 		var resp ProjectBriefResponse
-		if err := mcpClient.CallToolTyped("project_brief", mcpArgs, &resp); err != nil {
+		if err := mcpClient.CallToolTyped(cmd.Context(), "project_brief", mcpArgs, &resp); err != nil {
 			return err
 		}
 
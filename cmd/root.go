@@ -1,30 +1,62 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 
-	"github.com/banton/stompy-cli/internal/api"
 	"github.com/banton/stompy-cli/internal/auth"
+	"github.com/banton/stompy-cli/internal/cassette"
 	"github.com/banton/stompy-cli/internal/config"
+	"github.com/banton/stompy-cli/internal/har"
+	"github.com/banton/stompy-cli/internal/httpclient"
 	"github.com/banton/stompy-cli/internal/output"
 	"github.com/banton/stompy-cli/internal/update"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
 var (
 	flagAPIURL     string
 	flagAPIKey     string
+	flagProfile    string
 	flagProject    string
 	flagOutput     string
 	flagVerbose    bool
 	flagUseStaging bool
+	flagTimeout    time.Duration
+	flagIdemKey    string
+	flagRecord     string
+	flagReplay     string
 
-	apiClient        *api.Client
-	mcpClient        *api.MCPClient
+	flagProxy         string
+	flagNoProxy       string
+	flagCABundle      string
+	flagClientCert    string
+	flagClientKey     string
+	flagTLSMinVersion string
+	flagTrace         string
+
+	apiClient        *stompy.Client
+	mcpClient        *stompy.MCPClient
+	traceRecorder    *har.Recorder
+	cassetteRecorder *cassette.Recorder
 	updateAvailable  = make(chan string, 1)
+
+	// timeoutCtx carries the --timeout deadline attached in PersistentPreRunE,
+	// and cancelTimeout releases it.
+	timeoutCtx    context.Context
+	cancelTimeout context.CancelFunc = func() {}
+
+	// oauthSession is set when apiClient authenticates with the OAuth login
+	// rather than an API key.
+	oauthSession bool
 )
 
 var rootCmd = &cobra.Command{
@@ -34,6 +66,29 @@ var rootCmd = &cobra.Command{
 	SilenceErrors: true,
 	SilenceUsage:  true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Bound the whole command (including retries) by --timeout
+		if flagTimeout > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), flagTimeout)
+			cmd.SetContext(ctx)
+			timeoutCtx, cancelTimeout = ctx, cancel
+		}
+		if flagIdemKey != "" {
+			cmd.SetContext(stompy.WithIdempotencyKey(cmd.Context(), flagIdemKey))
+		}
+
+		// doctor reports a broken config file instead of refusing to run
+		if err := config.Load(); err != nil && cmd.CommandPath() != "stompy doctor" {
+			return err
+		}
+		// An unknown profile can still be created or removed
+		if err := config.SetProfile(config.ResolveProfile(flagProfile)); err != nil && !strings.HasPrefix(cmd.CommandPath(), "stompy profile") {
+			return fmt.Errorf("%w: %w", stompy.ErrNotFound, err)
+		}
+		// Commands that never touch the network still run with a broken proxy
+		// or TLS setting, so it can be fixed with "stompy config set".
+		httpErr := configureHTTP()
+		configureCredentialStore()
+
 		// Fire off async version check (non-blocking, result printed in PostRun)
 		go func() {
 			if latest := update.CheckForUpdate(Version, config.GetConfigDir()); latest != "" {
@@ -47,42 +102,66 @@ var rootCmd = &cobra.Command{
 		// (e.g. "stompy update" vs "stompy context update").
 		cmdPath := cmd.CommandPath()
 		switch cmdPath {
-		case "stompy login", "stompy logout", "stompy version", "stompy update":
-			return config.Load()
+		case "stompy login", "stompy logout", "stompy update":
+			return httpErr
+		case "stompy version", "stompy dev fake-server", "stompy doctor":
+			return nil
 		}
 		switch cmd.Name() {
 		case "completion", "bash", "zsh", "fish", "powershell":
-			return config.Load()
+			return nil
 		}
-		// Config subcommands don't need API auth
-		if strings.Contains(cmdPath, "config ") {
-			return config.Load()
+		// Config and profile subcommands don't need API auth
+		if strings.Contains(cmdPath, "config ") || strings.HasPrefix(cmdPath, "stompy profile ") {
+			return nil
 		}
 		// Also skip for parent commands (just groupings)
 		if !cmd.HasParent() || (cmd.HasSubCommands() && len(args) == 0) {
-			return config.Load()
+			return nil
+		}
+		if httpErr != nil {
+			return httpErr
 		}
 
-		if err := config.Load(); err != nil {
+		transport, replaying, err := cassetteTransport()
+		if err != nil {
 			return err
 		}
 
-		token, err := resolveAuthToken()
-		if err != nil {
+		// Replays never reach the server, so missing credentials are fine
+		token, err := resolveAuthToken(cmd.Context())
+		if err != nil && !replaying {
 			return err
 		}
 
-		apiURL := flagAPIURL
-		if apiURL == "" {
-			if flagUseStaging {
-				apiURL = config.GetStagingAPIURL()
-			} else {
-				apiURL = config.GetAPIURL()
+		apiURL := resolveAPIURL()
+		opts := []stompy.Option{
+			stompy.WithToken(token),
+			stompy.WithVersion(Version),
+			stompy.WithVerbose(flagVerbose),
+			stompy.WithWarningHandler(printWarning),
+		}
+		// OAuth sessions can be refreshed mid-command when the server rejects
+		// the token; API keys are sent as-is.
+		oauthSession = token != "" && token == auth.AccessToken()
+		if oauthSession {
+			opts = append(opts, stompy.WithTokenSource(&auth.ConfigTokenSource{APIURL: apiURL}))
+		}
+		// One limiter shared by both clients so the quota covers REST and MCP together
+		if rl := config.GetRateLimit(); rl != "" {
+			limiter, err := stompy.ParseRateLimit(rl)
+			if err != nil {
+				return err
 			}
+			opts = append(opts, stompy.WithRateLimiter(limiter))
 		}
 
-		apiClient = api.NewClient(apiURL, token, Version, flagVerbose)
-		mcpClient = api.NewMCPClient(api.MCPBaseURL(apiURL), token, Version, flagVerbose)
+		apiClient = stompy.NewClient(apiURL, opts...)
+		mcpClient = stompy.NewMCPClient(stompy.MCPBaseURL(apiURL), opts...)
+		if transport != nil {
+			apiClient.HTTPClient.Transport = transport
+			mcpClient.HTTPClient.Transport = transport
+		}
 		return nil
 	},
 }
@@ -90,16 +169,54 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.PersistentFlags().StringVar(&flagAPIURL, "api-url", "", "Override API base URL")
 	rootCmd.PersistentFlags().StringVar(&flagAPIKey, "api-key", "", "Override API key")
+	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "Profile to use (default: STOMPY_PROFILE or 'stompy profile use')")
 	rootCmd.PersistentFlags().StringVarP(&flagProject, "project", "p", "", "Override default project")
 	rootCmd.PersistentFlags().StringVarP(&flagOutput, "output", "o", "", "Output format: table, json, yaml")
 	rootCmd.PersistentFlags().BoolVar(&flagVerbose, "verbose", false, "Debug HTTP logging")
+	rootCmd.PersistentFlags().DurationVar(&flagTimeout, "timeout", 0, "Abort the command after this long, e.g. 30s or 2m (0 = no limit)")
+	rootCmd.PersistentFlags().StringVar(&flagIdemKey, "idempotency-key", "", "Idempotency-Key to send with mutating requests (default: random per operation)")
+	rootCmd.PersistentFlags().StringVar(&flagRecord, "record", "", "Record API traffic to a cassette file")
+	rootCmd.PersistentFlags().StringVar(&flagReplay, "replay", "", "Serve API responses from a cassette file instead of the network")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.PersistentFlags().StringVar(&flagProxy, "proxy", "", "Proxy URL for all requests (default: https_proxy config or HTTPS_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&flagNoProxy, "no-proxy", "", "Comma-separated hosts, domains or CIDRs to reach without the proxy")
+	rootCmd.PersistentFlags().StringVar(&flagCABundle, "ca-bundle", "", "PEM file of CA certificates to trust in addition to the system's")
+	rootCmd.PersistentFlags().StringVar(&flagClientCert, "client-cert", "", "PEM client certificate for mutual TLS")
+	rootCmd.PersistentFlags().StringVar(&flagClientKey, "client-key", "", "PEM private key for --client-cert")
+	rootCmd.PersistentFlags().StringVar(&flagTLSMinVersion, "tls-min-version", "", "Minimum TLS version: 1.2 or 1.3 (default 1.2)")
+	rootCmd.PersistentFlags().StringVar(&flagTrace, "trace", "", "Write every HTTP request to a HAR file and print a latency summary")
 	rootCmd.PersistentFlags().BoolVar(&flagUseStaging, "use-staging", false, "")
 	rootCmd.PersistentFlags().MarkHidden("use-staging")
 }
 
 // Execute is the main entry point for the CLI.
 func Execute() {
-	err := rootCmd.Execute()
+	// Ctrl-C cancels the in-flight request and any retry backoff
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := rootCmd.ExecuteContext(ctx)
+	interrupted := ctx.Err() != nil
+	timedOut := commandTimedOut()
+	stop()
+	cancelTimeout()
+
+	// End the MCP session, if the command opened one
+	if mcpClient != nil {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		mcpClient.Close(closeCtx) //nolint:errcheck
+		cancel()
+	}
+
+	if cassetteRecorder != nil {
+		if recErr := cassetteRecorder.Close(); recErr != nil && err == nil {
+			err = recErr
+		}
+	}
+
+	if traceRecorder != nil {
+		if traceErr := writeTrace(traceRecorder, flagTrace); traceErr != nil && err == nil {
+			err = traceErr
+		}
+	}
 
 	// Print update notice (if available) after command output
 	select {
@@ -115,14 +232,138 @@ func Execute() {
 	}
 
 	if err != nil {
+		switch {
+		case interrupted:
+			fmt.Fprintln(os.Stderr, output.Error("Interrupted."))
+			os.Exit(exitInterrupted)
+		case timedOut:
+			fmt.Fprintln(os.Stderr, output.Error("Error:")+fmt.Sprintf("\n  command timed out after %s (--timeout)", flagTimeout))
+			os.Exit(exitTimeout)
+		}
+		code, hint := classifyError(err)
 		fmt.Fprintln(os.Stderr, output.Error("Error:")+"\n  "+err.Error())
-		os.Exit(1)
+		if hint != "" {
+			fmt.Fprintln(os.Stderr, output.Dim("  Hint: "+hint))
+		}
+		os.Exit(code)
+	}
+}
+
+// commandTimedOut reports whether the command ran out of --timeout. Other
+// deadlines, such as the HTTP client's per-request timeout, are network
+// failures rather than the command's own.
+func commandTimedOut() bool {
+	return flagTimeout > 0 && timeoutCtx != nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded)
+}
+
+// cassetteTransport returns the RoundTripper selected by --record, --replay or
+// STOMPY_CASSETTE, or nil if none is set. STOMPY_CASSETTE replays the file when
+// it exists and records to it otherwise.
+func cassetteTransport() (rt http.RoundTripper, replaying bool, err error) {
+	record, replay := flagRecord, flagReplay
+	if record == "" && replay == "" {
+		if env := os.Getenv("STOMPY_CASSETTE"); env != "" {
+			if _, statErr := os.Stat(env); statErr == nil {
+				replay = env
+			} else {
+				record = env
+			}
+		}
+	}
+
+	switch {
+	case replay != "":
+		r, err := cassette.NewReplayer(replay)
+		if err != nil {
+			return nil, false, err
+		}
+		return r, true, nil
+	case record != "":
+		cassetteRecorder = cassette.NewRecorder(record, httpclient.Transport())
+		return cassetteRecorder, false, nil
+	}
+	return nil, false, nil
+}
+
+// configureHTTP applies the proxy and TLS settings (flags over config) to the
+// transport shared by all outgoing requests.
+func configureHTTP() error {
+	if err := httpclient.Configure(httpOptions()); err != nil {
+		return fmt.Errorf("%w: %w", stompy.ErrValidation, err)
 	}
+	if flagTrace != "" {
+		httpclient.Wrap(func(next http.RoundTripper) http.RoundTripper {
+			traceRecorder = har.NewRecorder(next)
+			return traceRecorder
+		})
+	}
+	return nil
 }
 
+// httpOptions returns the proxy and TLS settings, flags over config.
+func httpOptions() httpclient.Options {
+	pick := func(flag, cfg string) string {
+		if flag != "" {
+			return flag
+		}
+		return cfg
+	}
+	return httpclient.Options{
+		Proxy:         pick(flagProxy, config.GetHTTPSProxy()),
+		NoProxy:       pick(flagNoProxy, config.GetNoProxy()),
+		CAFile:        pick(flagCABundle, config.GetCABundle()),
+		CertFile:      pick(flagClientCert, config.GetClientCert()),
+		KeyFile:       pick(flagClientKey, config.GetClientKey()),
+		MinTLSVersion: pick(flagTLSMinVersion, config.GetTLSMinVersion()),
+	}
+}
+
+// configureCredentialStore selects the credential store named by the
+// credential_store setting, which takes over tokens still in config.yaml the
+// first time credentials are used. A store that cannot be opened fails only
+// the commands that need it, so API keys keep working.
+func configureCredentialStore() {
+	host := ""
+	if u, err := url.Parse(resolveAPIURL()); err == nil {
+		host = u.Host
+	}
+	profile := config.ActiveProfile()
+	if profile == config.DefaultProfile {
+		profile = ""
+	}
+	s, err := auth.NewStore(config.GetCredentialStore(), auth.StoreOptions{
+		File:       config.GetCredentialFile(),
+		Passphrase: os.Getenv("STOMPY_CREDENTIAL_PASSPHRASE"),
+		Key:        os.Getenv("STOMPY_CREDENTIAL_KEY"),
+		Helper:     config.GetCredentialHelper(),
+		Host:       host,
+		Profile:    profile,
+	})
+	if err != nil {
+		auth.UseStore(failedStore{err})
+		return
+	}
+	auth.UseStore(auth.MigrateOnUse(s, func(moved bool, err error) {
+		switch {
+		case err != nil:
+			fmt.Fprintln(os.Stderr, output.Dim("Warning: "+err.Error()))
+		case moved:
+			fmt.Fprintf(os.Stderr, "Moved OAuth tokens from %s to the %s.\n", config.GetConfigPath(), s.Name())
+		}
+	}))
+}
+
+// failedStore stands in for a credential store that could not be opened.
+type failedStore struct{ err error }
+
+func (s failedStore) Load() (*auth.Credentials, error) { return nil, s.err }
+func (s failedStore) Save(*auth.Credentials) error     { return s.err }
+func (s failedStore) Delete() error                    { return s.err }
+func (s failedStore) Name() string                     { return "credential store" }
+
 // resolveAuthToken determines the auth token using precedence:
 // --api-key flag > STOMPY_API_KEY env > OAuth token (with auto-refresh) > api_key from config > error
-func resolveAuthToken() (string, error) {
+func resolveAuthToken(ctx context.Context) (string, error) {
 	// 1. --api-key flag
 	if flagAPIKey != "" {
 		return flagAPIKey, nil
@@ -133,33 +374,16 @@ func resolveAuthToken() (string, error) {
 		return envKey, nil
 	}
 
-	// 3. OAuth token from config (with auto-refresh)
-	accessToken := config.GetAccessToken()
-	if accessToken != "" {
-		expiry := config.GetTokenExpiry()
-		if !auth.IsExpired(expiry) {
-			return accessToken, nil
-		}
-
-		// Try to refresh
-		refreshToken := config.GetRefreshToken()
-		if refreshToken != "" {
-			apiURL := flagAPIURL
-			if apiURL == "" {
-				if flagUseStaging {
-					apiURL = config.GetStagingAPIURL()
-				} else {
-					apiURL = config.GetAPIURL()
-				}
-			}
-			tokenResp, err := auth.RefreshToken(apiURL, refreshToken)
-			if err == nil {
-				newExpiry := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-				_ = config.SaveTokens(tokenResp.AccessToken, tokenResp.RefreshToken, newExpiry, config.GetEmail(), "")
-				return tokenResp.AccessToken, nil
-			}
-			// Refresh failed — fall through
+	// 3. OAuth token from the credential store (with auto-refresh)
+	creds, err := auth.LoadCredentials()
+	if err != nil {
+		return "", fmt.Errorf("%w: reading credentials: %w", stompy.ErrUnauthorized, err)
+	}
+	if creds != nil {
+		if token, err := auth.GetValidToken(ctx, resolveAPIURL()); err == nil {
+			return token, nil
 		}
+		// Refresh failed — fall through
 	}
 
 	// 4. Static api_key from config
@@ -168,7 +392,48 @@ func resolveAuthToken() (string, error) {
 	}
 
 	// 5. No auth available
-	return "", fmt.Errorf("not authenticated. Run 'stompy login' or set STOMPY_API_KEY")
+	return "", fmt.Errorf("%w: no OAuth session or API key configured", stompy.ErrUnauthorized)
+}
+
+// resolveAPIURL returns the API base URL from --api-url, --use-staging or config.
+func resolveAPIURL() string {
+	if flagAPIURL != "" {
+		return flagAPIURL
+	}
+	if flagUseStaging {
+		return config.GetStagingAPIURL()
+	}
+	return config.GetAPIURL()
+}
+
+// addPaginationFlags registers --all and --page-size on a list command that
+// already defines --limit and --offset.
+func addPaginationFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("all", false, "Fetch every page of results")
+	cmd.Flags().Int("page-size", stompy.DefaultPageSize, "Items per request when using --all")
+	cmd.MarkFlagsMutuallyExclusive("all", "limit")
+	cmd.MarkFlagsMutuallyExclusive("all", "offset")
+}
+
+// listItems fetches the page selected by --limit and --offset, or every page
+// with --all, and returns the items and the total count. fetch lists one page.
+func listItems[T any](cmd *cobra.Command, fetch func(limit, offset int) ([]T, int, error)) ([]T, int, error) {
+	if all, _ := cmd.Flags().GetBool("all"); all {
+		pageSize, _ := cmd.Flags().GetInt("page-size")
+		var items []T
+		for item, err := range stompy.Paginate(cmd.Context(), pageSize, func(_ context.Context, limit, offset int) ([]T, int, error) {
+			return fetch(limit, offset)
+		}) {
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+		}
+		return items, len(items), nil
+	}
+	limit, _ := cmd.Flags().GetInt("limit")
+	offset, _ := cmd.Flags().GetInt("offset")
+	return fetch(limit, offset)
 }
 
 // getProject resolves the active project name.
@@ -194,3 +459,8 @@ func isTableOutput() bool {
 	f := getOutputFormat()
 	return f == "" || f == "table"
 }
+
+// printWarning shows a warning from the API client, such as an outdated CLI.
+func printWarning(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+}
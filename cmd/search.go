@@ -20,7 +20,7 @@ var searchCmd = &cobra.Command{
 
 		limit, _ := cmd.Flags().GetInt("limit")
 
-		resp, err := apiClient.Search(project, args[0], limit)
+		resp, err := apiClient.Search(cmd.Context(), project, args[0], limit)
 		if err != nil {
 			return err
 		}
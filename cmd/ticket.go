@@ -6,8 +6,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/banton/stompy-cli/internal/api"
 	"github.com/banton/stompy-cli/internal/output"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
@@ -36,7 +36,7 @@ var ticketCreateCmd = &cobra.Command{
 		assignee, _ := cmd.Flags().GetString("assignee")
 		tagsStr, _ := cmd.Flags().GetString("tags")
 
-		req := api.TicketCreate{
+		req := stompy.TicketCreate{
 			Title:    title,
 			Type:     ticketType,
 			Priority: priority,
@@ -54,7 +54,7 @@ var ticketCreateCmd = &cobra.Command{
 			}
 		}
 
-		resp, err := apiClient.CreateTicket(project, req)
+		resp, err := apiClient.CreateTicket(cmd.Context(), project, req)
 		if err != nil {
 			return err
 		}
@@ -79,7 +79,7 @@ var ticketGetCmd = &cobra.Command{
 			return fmt.Errorf("invalid ticket ID: %s", args[0])
 		}
 
-		resp, err := apiClient.GetTicket(project, id)
+		resp, err := apiClient.GetTicket(cmd.Context(), project, id)
 		if err != nil {
 			return err
 		}
@@ -134,7 +134,7 @@ var ticketUpdateCmd = &cobra.Command{
 			return fmt.Errorf("invalid ticket ID: %s", args[0])
 		}
 
-		req := api.TicketUpdate{}
+		req := stompy.TicketUpdate{}
 		if cmd.Flags().Changed("title") {
 			v, _ := cmd.Flags().GetString("title")
 			req.Title = &v
@@ -160,7 +160,7 @@ var ticketUpdateCmd = &cobra.Command{
 			req.Tags = tags
 		}
 
-		resp, err := apiClient.UpdateTicket(project, id, req)
+		resp, err := apiClient.UpdateTicket(cmd.Context(), project, id, req)
 		if err != nil {
 			return err
 		}
@@ -190,7 +190,7 @@ var ticketMoveCmd = &cobra.Command{
 			return fmt.Errorf("--status is required")
 		}
 
-		resp, err := apiClient.TransitionTicket(project, id, status)
+		resp, err := apiClient.TransitionTicket(cmd.Context(), project, id, status)
 		if err != nil {
 			return err
 		}
@@ -224,7 +224,7 @@ var ticketCloseCmd = &cobra.Command{
 		}
 
 		// Fetch ticket to determine type
-		ticket, err := apiClient.GetTicket(project, id)
+		ticket, err := apiClient.GetTicket(cmd.Context(), project, id)
 		if err != nil {
 			return err
 		}
@@ -234,7 +234,7 @@ var ticketCloseCmd = &cobra.Command{
 			status = "done" // fallback
 		}
 
-		resp, err := apiClient.TransitionTicket(project, id, status)
+		resp, err := apiClient.TransitionTicket(cmd.Context(), project, id, status)
 		if err != nil {
 			return err
 		}
@@ -256,10 +256,14 @@ var ticketListCmd = &cobra.Command{
 		status, _ := cmd.Flags().GetString("status")
 		ticketType, _ := cmd.Flags().GetString("type")
 		priority, _ := cmd.Flags().GetString("priority")
-		limit, _ := cmd.Flags().GetInt("limit")
-		offset, _ := cmd.Flags().GetInt("offset")
 
-		resp, err := apiClient.ListTickets(project, status, ticketType, priority, limit, offset)
+		tickets, total, err := listItems(cmd, func(limit, offset int) ([]stompy.TicketResponse, int, error) {
+			resp, err := apiClient.ListTickets(cmd.Context(), project, status, ticketType, priority, limit, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			return resp.Tickets, resp.Total, nil
+		})
 		if err != nil {
 			return err
 		}
@@ -268,7 +272,7 @@ var ticketListCmd = &cobra.Command{
 		colorize := isTableOutput()
 		headers := []string{"ID", "TYPE", "STATUS", "PRIORITY", "TITLE", "ASSIGNEE"}
 		var rows [][]string
-		for _, t := range resp.Tickets {
+		for _, t := range tickets {
 			assignee := ""
 			if t.Assignee != nil {
 				assignee = *t.Assignee
@@ -291,7 +295,7 @@ var ticketListCmd = &cobra.Command{
 
 		fmt.Print(f.FormatTable(headers, rows))
 		if isTableOutput() {
-			fmt.Printf("\nTotal: %d tickets\n", resp.Total)
+			fmt.Printf("\nTotal: %d tickets\n", total)
 		}
 		return nil
 	},
@@ -310,7 +314,7 @@ var ticketBoardCmd = &cobra.Command{
 		ticketType, _ := cmd.Flags().GetString("type")
 		status, _ := cmd.Flags().GetString("status")
 
-		resp, err := apiClient.GetBoard(project, view, ticketType, status)
+		resp, err := apiClient.GetBoard(cmd.Context(), project, view, ticketType, status)
 		if err != nil {
 			return err
 		}
@@ -351,7 +355,7 @@ var ticketSearchCmd = &cobra.Command{
 		status, _ := cmd.Flags().GetString("status")
 		limit, _ := cmd.Flags().GetInt("limit")
 
-		resp, err := apiClient.SearchTickets(project, args[0], ticketType, status, limit)
+		resp, err := apiClient.SearchTickets(cmd.Context(), project, args[0], ticketType, status, limit)
 		if err != nil {
 			return err
 		}
@@ -407,6 +411,7 @@ func init() {
 	ticketListCmd.Flags().String("priority", "", "Filter by priority")
 	ticketListCmd.Flags().Int("limit", 0, "Limit results")
 	ticketListCmd.Flags().Int("offset", 0, "Offset for pagination")
+	addPaginationFlags(ticketListCmd)
 
 	ticketBoardCmd.Flags().String("view", "summary", "Board view: kanban, summary")
 	ticketBoardCmd.Flags().String("type", "", "Filter by type")
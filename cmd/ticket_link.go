@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/banton/stompy-cli/internal/api"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
@@ -37,12 +37,12 @@ var ticketLinkAddCmd = &cobra.Command{
 			return fmt.Errorf("--type is required (blocks, parent, related, duplicate)")
 		}
 
-		req := api.LinkCreate{
+		req := stompy.LinkCreate{
 			TargetID: target,
 			LinkType: linkType,
 		}
 
-		resp, err := apiClient.AddLink(project, id, req)
+		resp, err := apiClient.AddLink(cmd.Context(), project, id, req)
 		if err != nil {
 			return err
 		}
@@ -67,7 +67,7 @@ var ticketLinkListCmd = &cobra.Command{
 			return fmt.Errorf("invalid ticket ID: %s", args[0])
 		}
 
-		links, err := apiClient.ListLinks(project, id)
+		links, err := apiClient.ListLinks(cmd.Context(), project, id)
 		if err != nil {
 			return err
 		}
@@ -114,7 +114,7 @@ var ticketLinkRemoveCmd = &cobra.Command{
 			return fmt.Errorf("invalid link ID: %s", args[1])
 		}
 
-		if err := apiClient.RemoveLink(project, ticketID, linkID); err != nil {
+		if err := apiClient.RemoveLink(cmd.Context(), project, ticketID, linkID); err != nil {
 			return err
 		}
 
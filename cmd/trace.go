@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/banton/stompy-cli/internal/har"
+	"github.com/banton/stompy-cli/internal/output"
+)
+
+// writeTrace saves the --trace HAR file and prints a per-request latency
+// summary to stderr.
+func writeTrace(r *har.Recorder, path string) error {
+	entries := r.Entries()
+	if err := r.Save(path, Version); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "%s No requests made; wrote empty trace to %s\n", output.Dim("→"), path)
+		return nil
+	}
+
+	headers := []string{"#", "METHOD", "URL", "STATUS", "DNS", "CONNECT", "TLS", "TTFB", "TOTAL"}
+	var rows [][]string
+	var total float64
+	for i, e := range entries {
+		status := strconv.Itoa(e.Response.Status)
+		if e.Error != "" {
+			status = "error"
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			e.Request.Method,
+			traceURL(e.Request.URL),
+			status,
+			formatMillis(e.Timings.DNS),
+			formatMillis(e.Timings.Connect),
+			formatMillis(e.Timings.SSL),
+			formatMillis(e.Timings.TTFB()),
+			formatMillis(e.Time),
+		})
+		total += e.Time
+	}
+	fmt.Fprint(os.Stderr, "\n"+output.NewFormatter("table").FormatTable(headers, rows))
+	fmt.Fprintf(os.Stderr, "%s %d requests, %s in total. Trace written to %s\n",
+		output.Dim("→"), len(entries), formatMillis(total), path)
+	return nil
+}
+
+// traceURL shortens a request URL to its path for the summary table; the
+// HAR file has the full URL.
+func traceURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	s := u.Path
+	if len(s) > 48 {
+		s = "..." + s[len(s)-45:]
+	}
+	return s
+}
+
+// formatMillis renders a HAR duration, where -1 means the phase didn't happen.
+func formatMillis(ms float64) string {
+	switch {
+	case ms < 0:
+		return "-"
+	case ms < 10:
+		return fmt.Sprintf("%.1fms", ms)
+	case ms < 1000:
+		return fmt.Sprintf("%.0fms", ms)
+	}
+	return fmt.Sprintf("%.2fs", ms/1000)
+}
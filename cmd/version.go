@@ -5,10 +5,10 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/banton/stompy-cli/internal/api"
 	"github.com/banton/stompy-cli/internal/config"
 	"github.com/banton/stompy-cli/internal/output"
 	"github.com/banton/stompy-cli/internal/update"
+	"github.com/banton/stompy-cli/pkg/stompy"
 	"github.com/spf13/cobra"
 )
 
@@ -22,20 +22,13 @@ var versionCmd = &cobra.Command{
 		fmt.Printf("stompy-cli %s\n", Version)
 
 		// Try to fetch API version from server
-		apiURL := flagAPIURL
-		if apiURL == "" {
-			if flagUseStaging {
-				apiURL = config.GetStagingAPIURL()
-			} else {
-				apiURL = config.GetAPIURL()
-			}
-		}
+		apiURL := resolveAPIURL()
 		if apiURL != "" {
-			c := api.NewClient(apiURL, "", Version, false)
+			c := stompy.NewClient(apiURL, stompy.WithVersion(Version), stompy.WithWarningHandler(printWarning))
 			// Ping health endpoint to get version headers
-			_, _, err := c.Do(http.MethodGet, "/health", nil, url.Values{})
-			if err == nil && c.APIVersion != "" {
-				fmt.Printf("API: %s (server %s)\n", apiURL, c.APIVersion)
+			_, _, err := c.Do(cmd.Context(), http.MethodGet, "/health", nil, url.Values{})
+			if err == nil && c.APIVersion() != "" {
+				fmt.Printf("API: %s (server %s)\n", apiURL, c.APIVersion())
 			} else {
 				fmt.Printf("API: %s\n", apiURL)
 			}
@@ -1,16 +0,0 @@
-package api
-
-import "fmt"
-
-type APIError struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"message"`
-	Detail     string `json:"detail,omitempty"`
-}
-
-func (e *APIError) Error() string {
-	if e.Detail != "" {
-		return fmt.Sprintf("API error %d: %s — %s", e.StatusCode, e.Message, e.Detail)
-	}
-	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
-}
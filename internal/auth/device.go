@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/httpclient"
+)
+
+const (
+	deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	loginScope      = "openid profile email"
+
+	// defaultPollInterval applies when the server does not send one (RFC 8628 §3.2).
+	defaultPollInterval = 5
+	// slowDownStep is added to the interval on each slow_down (RFC 8628 §3.5).
+	slowDownStep = 5
+)
+
+// pollUnit scales the server's poll interval, which is given in seconds.
+var pollUnit = time.Second
+
+// ErrDeviceFlowUnsupported means the server has no device authorization endpoint.
+var ErrDeviceFlowUnsupported = errors.New("server does not support device login")
+
+var errDeviceCodeExpired = errors.New("the device code expired before it was approved — please try again")
+
+// DeviceAuthorization is the server's answer to a device login request
+// (RFC 8628 §3.2).
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// oauthError is an OAuth 2.0 error response (RFC 6749 §5.2).
+type oauthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *oauthError) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+	return e.Code
+}
+
+// RequestDeviceCode starts a device login via POST /oauth/device_authorization.
+func RequestDeviceCode(ctx context.Context, apiURL string) (*DeviceAuthorization, error) {
+	data := url.Values{
+		"client_id": {CLIClientID},
+		"scope":     {loginScope},
+	}
+	resp, err := postForm(ctx, oauthURL(apiURL, "/oauth/device_authorization"), data)
+	if err != nil {
+		return nil, fmt.Errorf("requesting device code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, ErrDeviceFlowUnsupported
+	default:
+		return nil, fmt.Errorf("device code request failed: %w", decodeOAuthError(resp))
+	}
+
+	var da DeviceAuthorization
+	if err := json.NewDecoder(resp.Body).Decode(&da); err != nil {
+		return nil, fmt.Errorf("decoding device code response: %w", err)
+	}
+	if da.DeviceCode == "" || da.UserCode == "" || da.VerificationURI == "" {
+		return nil, errors.New("device code response is missing required fields")
+	}
+	return &da, nil
+}
+
+// PollDeviceToken polls the token endpoint until the user approves or denies
+// the device login, the code expires, or ctx is done. Transient failures
+// only slow polling down.
+func PollDeviceToken(ctx context.Context, apiURL string, da *DeviceAuthorization) (*TokenResponse, error) {
+	interval := da.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	// pollCtx ends when the device code expires; ctx is the caller's
+	pollCtx := ctx
+	if da.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		pollCtx, cancel = context.WithTimeout(ctx, time.Duration(da.ExpiresIn)*pollUnit)
+		defer cancel()
+	}
+
+	data := url.Values{
+		"grant_type":  {deviceGrantType},
+		"device_code": {da.DeviceCode},
+		"client_id":   {CLIClientID},
+	}
+	for {
+		if err := sleep(pollCtx, time.Duration(interval)*pollUnit); err != nil {
+			if ctx.Err() == nil {
+				return nil, errDeviceCodeExpired
+			}
+			return nil, err
+		}
+
+		// Network errors and server failures are treated like slow_down:
+		// back off and keep polling until the code expires.
+		resp, err := postForm(pollCtx, oauthURL(apiURL, "/oauth/token"), data)
+		if err != nil {
+			if pollCtx.Err() != nil {
+				if ctx.Err() == nil {
+					return nil, errDeviceCodeExpired
+				}
+				return nil, fmt.Errorf("polling for token: %w", err)
+			}
+			interval += slowDownStep
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			resp.Body.Close()
+			interval += slowDownStep
+			continue
+		}
+		if resp.StatusCode == http.StatusOK {
+			var tokenResp TokenResponse
+			err := json.NewDecoder(resp.Body).Decode(&tokenResp)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("decoding token response: %w", err)
+			}
+			return &tokenResp, nil
+		}
+
+		oerr := decodeOAuthError(resp)
+		resp.Body.Close()
+		switch oerr.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += slowDownStep
+		case "access_denied":
+			return nil, errors.New("login was denied")
+		case "expired_token":
+			return nil, errDeviceCodeExpired
+		default:
+			return nil, fmt.Errorf("device login failed: %w", oerr)
+		}
+	}
+}
+
+// LoginDevice performs the device authorization flow: show the user a code
+// to enter on another device, then wait for approval.
+func LoginDevice(ctx context.Context, apiURL string) (*TokenResponse, error) {
+	da, err := RequestDeviceCode(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("To sign in, open %s on any device and enter the code:\n\n    %s\n\n", da.VerificationURI, da.UserCode)
+	if da.VerificationURIComplete != "" {
+		fmt.Printf("Or open this link, which includes the code:\n  %s\n\n", da.VerificationURIComplete)
+	}
+
+	fmt.Print("Waiting for authorization...")
+	tokenResp, err := PollDeviceToken(ctx, apiURL, da)
+	if err != nil {
+		fmt.Println(" Failed.")
+		return nil, err
+	}
+	fmt.Println(" Done!")
+	return tokenResp, nil
+}
+
+// HasDisplay reports whether a browser can likely be opened on this machine:
+// not over SSH, and on Linux and BSD only with an X11 or Wayland display.
+func HasDisplay() bool {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return false
+	}
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return true
+	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// oauthURL turns a REST base URL into the URL of an OAuth endpoint at path.
+func oauthURL(apiURL, path string) string {
+	return strings.TrimSuffix(apiURL, "/api/v1") + path
+}
+
+// postForm POSTs data to u with the shared transport.
+func postForm(ctx context.Context, u string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	return httpclient.New(tokenTimeout).Do(req)
+}
+
+// decodeOAuthError reads an OAuth error body, falling back to the HTTP status.
+func decodeOAuthError(resp *http.Response) *oauthError {
+	var oerr oauthError
+	if err := json.NewDecoder(resp.Body).Decode(&oerr); err != nil || oerr.Code == "" {
+		return &oauthError{Code: fmt.Sprintf("status %d", resp.StatusCode)}
+	}
+	return &oerr
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeviceLogin(t *testing.T) {
+	defer func(u time.Duration) { pollUnit = u }(pollUnit)
+	pollUnit = time.Millisecond
+
+	polls := 0
+	var pollTimes []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		switch r.URL.Path {
+		case "/oauth/device_authorization":
+			if got := r.FormValue("client_id"); got != CLIClientID {
+				t.Errorf("client_id = %q", got)
+			}
+			json.NewEncoder(w).Encode(DeviceAuthorization{
+				DeviceCode: "dev-code", UserCode: "ABCD-EFGH", VerificationURI: "https://example.com/device",
+				ExpiresIn: 60_000, Interval: 1,
+			})
+		case "/oauth/token":
+			if got := r.FormValue("grant_type"); got != deviceGrantType {
+				t.Errorf("grant_type = %q", got)
+			}
+			if got := r.FormValue("device_code"); got != "dev-code" {
+				t.Errorf("device_code = %q", got)
+			}
+			polls++
+			pollTimes = append(pollTimes, time.Now())
+			w.Header().Set("Content-Type", "application/json")
+			switch polls {
+			case 1:
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"authorization_pending"}`))
+			case 2:
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"slow_down"}`))
+			default:
+				json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 3600})
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	apiURL := server.URL + "/api/v1"
+	da, err := RequestDeviceCode(context.Background(), apiURL)
+	if err != nil {
+		t.Fatalf("RequestDeviceCode() error: %v", err)
+	}
+	if da.UserCode != "ABCD-EFGH" {
+		t.Errorf("UserCode = %q", da.UserCode)
+	}
+
+	tok, err := PollDeviceToken(context.Background(), apiURL, da)
+	if err != nil {
+		t.Fatalf("PollDeviceToken() error: %v", err)
+	}
+	if tok.AccessToken != "access" || polls != 3 {
+		t.Errorf("token = %q after %d polls, want access after 3", tok.AccessToken, polls)
+	}
+	// slow_down raises the interval from 1 to 6 units
+	if gap := pollTimes[2].Sub(pollTimes[1]); gap < 6*pollUnit {
+		t.Errorf("poll after slow_down came %s later, want >= %s", gap, 6*pollUnit)
+	}
+}
+
+func TestPollDeviceToken_Errors(t *testing.T) {
+	defer func(u time.Duration) { pollUnit = u }(pollUnit)
+	pollUnit = time.Millisecond
+
+	for code, want := range map[string]string{
+		"access_denied":  "denied",
+		"expired_token":  "expired",
+		"invalid_client": "invalid_client",
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(oauthError{Code: code})
+		}))
+		_, err := PollDeviceToken(context.Background(), server.URL, &DeviceAuthorization{DeviceCode: "d", Interval: 1})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %v, want it to mention %q", code, err, want)
+		}
+		server.Close()
+	}
+
+	// The code expiring ends polling even while authorization is pending
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"authorization_pending"}`))
+	}))
+	defer server.Close()
+	_, err := PollDeviceToken(context.Background(), server.URL, &DeviceAuthorization{DeviceCode: "d", Interval: 1, ExpiresIn: 20})
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expiry error = %v", err)
+	}
+}
+
+func TestPollDeviceToken_SurvivesTransientFailures(t *testing.T) {
+	defer func(u time.Duration) { pollUnit = u }(pollUnit)
+	pollUnit = time.Millisecond
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		switch polls {
+		case 1:
+			hj, ok := w.(http.Hijacker)
+			if !ok {
+				t.Fatal("server does not support hijacking")
+			}
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access"})
+		}
+	}))
+	defer server.Close()
+
+	tok, err := PollDeviceToken(context.Background(), server.URL, &DeviceAuthorization{DeviceCode: "d", Interval: 1, ExpiresIn: 60_000})
+	if err != nil {
+		t.Fatalf("PollDeviceToken() error: %v", err)
+	}
+	if tok.AccessToken != "access" || polls != 3 {
+		t.Errorf("token = %q after %d polls, want access after 3", tok.AccessToken, polls)
+	}
+}
+
+func TestRequestDeviceCode_Unsupported(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if _, err := RequestDeviceCode(context.Background(), server.URL+"/api/v1"); !errors.Is(err, ErrDeviceFlowUnsupported) {
+		t.Errorf("error = %v, want ErrDeviceFlowUnsupported", err)
+	}
+}
+
+func TestParseRedirect(t *testing.T) {
+	cases := []struct {
+		input, code string
+		wantErr     bool
+	}{
+		{"http://localhost:4321/callback?code=abc&state=s1", "abc", false},
+		{"  code=abc&state=s1\n", "abc", false},
+		{"abc", "abc", false},
+		{"http://localhost:4321/callback?code=abc&state=other", "", true},
+		{"http://localhost:4321/callback?error=access_denied&state=s1", "", true},
+		{"http://localhost:4321/callback?state=s1", "", true},
+		{"", "", true},
+	}
+	for _, tc := range cases {
+		code, err := ParseRedirect(tc.input, "s1")
+		if (err != nil) != tc.wantErr || code != tc.code {
+			t.Errorf("ParseRedirect(%q) = %q, %v; want %q, error %v", tc.input, code, err, tc.code, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims are the JWT claims whoami shows. They are decoded locally for
+// display only: the signature is not verified, so never base a security
+// decision on them.
+type Claims struct {
+	Subject   string
+	Email     string
+	Org       string
+	Scopes    []string
+	Issuer    string
+	Audience  []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// errNotJWT means a token is opaque rather than a JWT, e.g. an API key.
+var errNotJWT = errors.New("token is not a JWT")
+
+// DecodeClaims reads the payload of a JWT without verifying it.
+func DecodeClaims(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errNotJWT
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("decoding JWT payload: %w", err)
+	}
+
+	var raw struct {
+		Sub   string          `json:"sub"`
+		Email string          `json:"email"`
+		Org   string          `json:"org"`
+		OrgID string          `json:"org_id"`
+		Scope string          `json:"scope"` // RFC 8693: space-separated
+		Scp   []string        `json:"scp"`
+		Iss   string          `json:"iss"`
+		Aud   json.RawMessage `json:"aud"` // a string or an array
+		Iat   float64         `json:"iat"`
+		Exp   float64         `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("parsing JWT claims: %w", err)
+	}
+
+	c := &Claims{
+		Subject: raw.Sub,
+		Email:   raw.Email,
+		Org:     raw.Org,
+		Scopes:  raw.Scp,
+		Issuer:  raw.Iss,
+	}
+	if c.Org == "" {
+		c.Org = raw.OrgID
+	}
+	if raw.Scope != "" {
+		c.Scopes = strings.Fields(raw.Scope)
+	}
+	if len(raw.Aud) > 0 {
+		var one string
+		if json.Unmarshal(raw.Aud, &one) == nil {
+			c.Audience = []string{one}
+		} else {
+			_ = json.Unmarshal(raw.Aud, &c.Audience)
+		}
+	}
+	if raw.Iat > 0 {
+		c.IssuedAt = time.Unix(int64(raw.Iat), 0)
+	}
+	if raw.Exp > 0 {
+		c.ExpiresAt = time.Unix(int64(raw.Exp), 0)
+	}
+	return c, nil
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"encoding/base64"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDecodeClaims(t *testing.T) {
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+
+	c, err := DecodeClaims(jwt(`{"sub":"u1","email":"a@b.c","org_id":"o1","scope":"openid tickets:write","iss":"https://issuer","aud":"stompy","iat":1767225600,"exp":1767229200}`))
+	if err != nil {
+		t.Fatalf("DecodeClaims() error: %v", err)
+	}
+	if c.Subject != "u1" || c.Email != "a@b.c" || c.Org != "o1" || c.Issuer != "https://issuer" {
+		t.Errorf("claims = %+v", c)
+	}
+	if !slices.Equal(c.Scopes, []string{"openid", "tickets:write"}) || !slices.Equal(c.Audience, []string{"stompy"}) {
+		t.Errorf("scopes = %v, audience = %v", c.Scopes, c.Audience)
+	}
+	if !c.IssuedAt.Equal(time.Unix(1767225600, 0)) || c.ExpiresAt.Sub(c.IssuedAt) != time.Hour {
+		t.Errorf("iat = %v, exp = %v", c.IssuedAt, c.ExpiresAt)
+	}
+
+	c, err = DecodeClaims(jwt(`{"scp":["a","b"],"aud":["x","y"]}`))
+	if err != nil {
+		t.Fatalf("DecodeClaims() error: %v", err)
+	}
+	if !slices.Equal(c.Scopes, []string{"a", "b"}) || !slices.Equal(c.Audience, []string{"x", "y"}) {
+		t.Errorf("scopes = %v, audience = %v", c.Scopes, c.Audience)
+	}
+
+	for _, bad := range []string{"sk_live_opaque", "a.!!!.c", jwt("not json")} {
+		if _, err := DecodeClaims(bad); err == nil {
+			t.Errorf("DecodeClaims(%q) succeeded, want error", bad)
+		}
+	}
+}
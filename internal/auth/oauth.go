@@ -1,12 +1,15 @@
 package auth
 
 import (
+	"bufio"
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -115,7 +118,7 @@ func OpenBrowser(rawURL string) error {
 }
 
 // ExchangeCode exchanges an authorization code for tokens via POST /oauth/token.
-func ExchangeCode(apiURL, code, verifier, redirectURI string) (*TokenResponse, error) {
+func ExchangeCode(ctx context.Context, apiURL, code, verifier, redirectURI string) (*TokenResponse, error) {
 	data := url.Values{
 		"grant_type":    {"authorization_code"},
 		"code":          {code},
@@ -124,8 +127,7 @@ func ExchangeCode(apiURL, code, verifier, redirectURI string) (*TokenResponse, e
 		"client_id":     {CLIClientID},
 	}
 
-	tokenURL := strings.TrimSuffix(apiURL, "/api/v1") + "/oauth/token"
-	resp, err := http.PostForm(tokenURL, data)
+	resp, err := postForm(ctx, oauthURL(apiURL, "/oauth/token"), data)
 	if err != nil {
 		return nil, fmt.Errorf("exchanging code: %w", err)
 	}
@@ -143,9 +145,19 @@ func ExchangeCode(apiURL, code, verifier, redirectURI string) (*TokenResponse, e
 	return &tokenResp, nil
 }
 
+// LoginOptions adjusts the browser login flow.
+type LoginOptions struct {
+	// NoBrowser prints the authorization URL instead of opening it, and
+	// accepts the redirect URL pasted from a browser on another machine.
+	NoBrowser bool
+	// In is where the pasted redirect is read from when NoBrowser is set.
+	In io.Reader
+}
+
 // Login performs the full OAuth PKCE login flow:
 // generate PKCE pair, start callback server, open browser, wait for code, exchange.
-func Login(apiURL string) (*TokenResponse, error) {
+// Waiting ends early when ctx is done.
+func Login(ctx context.Context, apiURL string, opts LoginOptions) (*TokenResponse, error) {
 	verifier, challenge, err := GeneratePKCE()
 	if err != nil {
 		return nil, err
@@ -169,24 +181,87 @@ func Login(apiURL string) (*TokenResponse, error) {
 		url.QueryEscape(CLIClientID),
 		url.QueryEscape(redirectURI),
 		url.QueryEscape(challenge),
-		url.QueryEscape("openid profile email"),
+		url.QueryEscape(loginScope),
 		url.QueryEscape(state),
 	)
 
-	fmt.Println("Opening browser to authenticate...")
-	fmt.Printf("If the browser doesn't open, visit:\n  %s\n\n", authURL)
+	if opts.NoBrowser {
+		fmt.Printf("Open this URL in a browser on any machine:\n  %s\n\n", authURL)
+		fmt.Println("After you approve, the browser is sent to a localhost address that may fail to load.")
+		fmt.Println("Copy that address from the address bar and paste it here.")
+		go readPastedCode(opts.In, state, codeCh)
+		fmt.Print("\nRedirect URL: ")
+	} else {
+		fmt.Println("Opening browser to authenticate...")
+		fmt.Printf("If the browser doesn't open, visit:\n  %s\n\n", authURL)
 
-	if err := OpenBrowser(authURL); err != nil {
-		fmt.Printf("Could not open browser: %v\n", err)
-	}
+		if err := OpenBrowser(authURL); err != nil {
+			fmt.Printf("Could not open browser: %v\n", err)
+		}
 
-	fmt.Print("Waiting for authentication...")
+		fmt.Print("Waiting for authentication...")
+	}
 	select {
 	case code := <-codeCh:
 		fmt.Println(" Done!")
-		return ExchangeCode(apiURL, code, verifier, redirectURI)
+		return ExchangeCode(ctx, apiURL, code, verifier, redirectURI)
+	case <-ctx.Done():
+		fmt.Println(" Canceled.")
+		return nil, ctx.Err()
 	case <-time.After(LoginTimeout):
 		fmt.Println(" Timed out.")
 		return nil, fmt.Errorf("login timed out after %v — please try again", LoginTimeout)
 	}
 }
+
+// readPastedCode reads lines from in until one holds an authorization code
+// for expectedState, and sends the code on codeCh.
+func readPastedCode(in io.Reader, expectedState string, codeCh chan<- string) {
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		code, err := ParseRedirect(sc.Text(), expectedState)
+		if err == nil {
+			select {
+			case codeCh <- code:
+			default: // the callback server got there first
+			}
+			return
+		}
+		if strings.TrimSpace(sc.Text()) != "" {
+			fmt.Printf("%v\nRedirect URL: ", err)
+		}
+	}
+}
+
+// ParseRedirect extracts the authorization code from a pasted redirect URL
+// ("http://localhost:1234/callback?code=...&state=..."), its query string,
+// or a bare code.
+func ParseRedirect(input, expectedState string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", errors.New("nothing pasted")
+	}
+	if !strings.Contains(input, "=") {
+		return input, nil
+	}
+
+	query := input
+	if i := strings.Index(input, "?"); i >= 0 {
+		query = input[i+1:]
+	}
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("could not read the pasted URL: %w", err)
+	}
+	if e := q.Get("error"); e != "" {
+		return "", fmt.Errorf("authentication failed: %s — %s", e, q.Get("error_description"))
+	}
+	if q.Get("state") != expectedState {
+		return "", errors.New("the pasted URL is from a different login attempt (state mismatch)")
+	}
+	code := q.Get("code")
+	if code == "" {
+		return "", errors.New("the pasted URL has no code parameter")
+	}
+	return code, nil
+}
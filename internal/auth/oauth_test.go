@@ -1,13 +1,17 @@
 package auth
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGeneratePKCE(t *testing.T) {
@@ -175,7 +179,7 @@ func TestExchangeCode(t *testing.T) {
 
 	// ExchangeCode strips /api/v1 and appends /oauth/token
 	apiURL := server.URL + "/api/v1"
-	got, err := ExchangeCode(apiURL, "test-code", "test-verifier", "http://localhost:9999/callback")
+	got, err := ExchangeCode(context.Background(), apiURL, "test-code", "test-verifier", "http://localhost:9999/callback")
 	if err != nil {
 		t.Fatalf("ExchangeCode() error: %v", err)
 	}
@@ -200,7 +204,7 @@ func TestExchangeCode_ServerError(t *testing.T) {
 	}))
 	defer server.Close()
 
-	_, err := ExchangeCode(server.URL+"/api/v1", "code", "verifier", "http://localhost:9999/callback")
+	_, err := ExchangeCode(context.Background(), server.URL+"/api/v1", "code", "verifier", "http://localhost:9999/callback")
 	if err == nil {
 		t.Error("ExchangeCode() expected error for 500 response, got nil")
 	}
@@ -228,3 +232,15 @@ func TestGenerateState_Uniqueness(t *testing.T) {
 		t.Error("two GenerateState calls produced identical values")
 	}
 }
+
+func TestLogin_Canceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	_, err := Login(ctx, "http://127.0.0.1:1/api/v1", LoginOptions{NoBrowser: true, In: pr})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Token type hints for RevokeToken (RFC 7009 §2.1).
+const (
+	HintAccessToken  = "access_token"
+	HintRefreshToken = "refresh_token"
+)
+
+// ErrRevocationUnsupported means the server has no revocation endpoint.
+var ErrRevocationUnsupported = errors.New("server does not support token revocation")
+
+// RevokeToken invalidates token on the server via POST /oauth/revoke
+// (RFC 7009). Revoking a token the server no longer knows is not an error.
+func RevokeToken(ctx context.Context, apiURL, token, hint string) error {
+	data := url.Values{
+		"token":           {token},
+		"token_type_hint": {hint},
+		"client_id":       {CLIClientID},
+	}
+	resp, err := postForm(ctx, oauthURL(apiURL, "/oauth/revoke"), data)
+	if err != nil {
+		return fmt.Errorf("revoking %s: %w", hint, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return ErrRevocationUnsupported
+	}
+	oerr := decodeOAuthError(resp)
+	// A server that only revokes one token type rejects the other (§2.2.1)
+	if oerr.Code == "unsupported_token_type" {
+		return nil
+	}
+	return fmt.Errorf("revoking %s: %w", hint, oerr)
+}
+
+// RevokeCredentials revokes the refresh token, then the access token, of c.
+// The refresh token goes first: servers commonly revoke the access tokens
+// issued from it as well, and it is the long-lived one worth stopping.
+func RevokeCredentials(ctx context.Context, apiURL string, c *Credentials) error {
+	var errs []error
+	for _, t := range []struct{ token, hint string }{
+		{c.RefreshToken, HintRefreshToken},
+		{c.AccessToken, HintAccessToken},
+	} {
+		if t.token == "" {
+			continue
+		}
+		if err := RevokeToken(ctx, apiURL, t.token, t.hint); err != nil {
+			if errors.Is(err, ErrRevocationUnsupported) {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
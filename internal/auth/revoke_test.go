@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRevokeCredentials(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/revoke" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r.FormValue("token_type_hint")+"="+r.FormValue("token"))
+		// This server only revokes refresh tokens
+		if r.FormValue("token_type_hint") == HintAccessToken {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(oauthError{Code: "unsupported_token_type"})
+		}
+	}))
+	defer server.Close()
+
+	err := RevokeCredentials(context.Background(), server.URL+"/api/v1", &Credentials{AccessToken: "a", RefreshToken: "r"})
+	if err != nil {
+		t.Fatalf("RevokeCredentials() error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "refresh_token=r" || got[1] != "access_token=a" {
+		t.Errorf("revoked %v, want refresh token then access token", got)
+	}
+}
+
+func TestRevokeToken_Errors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	if err := RevokeToken(context.Background(), server.URL, "t", HintRefreshToken); !errors.Is(err, ErrRevocationUnsupported) {
+		t.Errorf("error = %v, want ErrRevocationUnsupported", err)
+	}
+	server.Close()
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(oauthError{Code: "invalid_client"})
+	}))
+	defer server.Close()
+	err := RevokeCredentials(context.Background(), server.URL, &Credentials{AccessToken: "a", RefreshToken: "r"})
+	if err == nil || errors.Is(err, ErrRevocationUnsupported) {
+		t.Errorf("error = %v, want invalid_client failure", err)
+	}
+}
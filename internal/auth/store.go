@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/config"
+)
+
+// Credential store names accepted by the credential_store setting.
+const (
+	StoreConfig = "config" // plaintext in config.yaml (default)
+	StoreFile   = "file"   // encrypted file
+	StoreHelper = "helper" // external credential helper
+)
+
+// Credentials are the OAuth tokens and identity obtained by stompy login.
+type Credentials struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+	Email        string    `json:"email,omitempty"`
+	UserID       string    `json:"user_id,omitempty"`
+}
+
+// Store persists Credentials between commands.
+type Store interface {
+	// Load returns the stored credentials, or nil if there are none.
+	Load() (*Credentials, error)
+	Save(c *Credentials) error
+	Delete() error
+	// Name describes the backend for messages, e.g. "encrypted file".
+	Name() string
+}
+
+// reloader is implemented by stores that keep credentials in memory between
+// calls. reload drops that copy so the next Load sees what other stompy
+// processes saved.
+type reloader interface {
+	reload() error
+}
+
+// StoreOptions configures the backend returned by NewStore.
+type StoreOptions struct {
+	// File is the encrypted credentials file (StoreFile).
+	File string
+	// Passphrase or Key (32 bytes, base64) unlocks the encrypted file.
+	Passphrase string
+	Key        string
+
+	// Helper is the credential helper command line (StoreHelper).
+	Helper string
+	// Host is the API host and Profile the config profile, passed to the
+	// helper so it can keep separate credentials per server and account.
+	Host    string
+	Profile string
+}
+
+// NewStore returns the credential store called kind, or the config store
+// when kind is empty.
+func NewStore(kind string, opts StoreOptions) (Store, error) {
+	switch kind {
+	case "", StoreConfig:
+		return ConfigStore{}, nil
+	case StoreFile:
+		return newFileStore(opts)
+	case StoreHelper:
+		return newHelperStore(opts)
+	}
+	return nil, fmt.Errorf("unknown credential_store %q (want %s, %s or %s)", kind, StoreConfig, StoreFile, StoreHelper)
+}
+
+var (
+	storeMu sync.RWMutex
+	current Store = ConfigStore{}
+)
+
+// UseStore makes s the store used by LoadCredentials, SaveCredentials and
+// ClearCredentials.
+func UseStore(s Store) {
+	storeMu.Lock()
+	current = s
+	storeMu.Unlock()
+}
+
+// CurrentStore returns the store set by UseStore: the config store until
+// UseStore is called.
+func CurrentStore() Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return current
+}
+
+// LoadCredentials returns the credentials in the current store, or nil if
+// the user is not logged in.
+func LoadCredentials() (*Credentials, error) {
+	return CurrentStore().Load()
+}
+
+// SaveCredentials persists c to the current store.
+func SaveCredentials(c *Credentials) error {
+	return CurrentStore().Save(c)
+}
+
+// ClearCredentials removes the credentials from the current store.
+func ClearCredentials() error {
+	return CurrentStore().Delete()
+}
+
+// reloadCredentials makes the current store forget any credentials it holds
+// in memory.
+func reloadCredentials() error {
+	if r, ok := CurrentStore().(reloader); ok {
+		return r.reload()
+	}
+	return nil
+}
+
+// AccessToken returns the stored access token, or "" if there is none or
+// the store cannot be read.
+func AccessToken() string {
+	c, err := LoadCredentials()
+	if err != nil || c == nil {
+		return ""
+	}
+	return c.AccessToken
+}
+
+// MigrateFromConfig moves tokens left in config.yaml into s and removes
+// them from the config file. It reports whether anything was moved.
+func MigrateFromConfig(s Store) (bool, error) {
+	if _, ok := s.(ConfigStore); ok {
+		return false, nil
+	}
+	legacy, _ := ConfigStore{}.Load()
+	if legacy == nil {
+		return false, nil
+	}
+	if err := s.Save(legacy); err != nil {
+		return false, fmt.Errorf("moving tokens to %s: %w", s.Name(), err)
+	}
+	if err := config.ClearTokens(); err != nil {
+		return false, fmt.Errorf("removing tokens from config: %w", err)
+	}
+	return true, nil
+}
+
+// MigrateOnUse wraps s so MigrateFromConfig runs the first time credentials
+// are loaded, saved or deleted instead of on every command, since unlocking
+// some stores is slow. done, if set, is told the outcome.
+func MigrateOnUse(s Store, done func(moved bool, err error)) Store {
+	if _, ok := s.(ConfigStore); ok {
+		return s
+	}
+	return &migratingStore{Store: s, done: done}
+}
+
+type migratingStore struct {
+	Store
+	done func(moved bool, err error)
+	once sync.Once
+}
+
+func (s *migratingStore) migrate() {
+	s.once.Do(func() {
+		moved, err := MigrateFromConfig(s.Store)
+		if s.done != nil {
+			s.done(moved, err)
+		}
+	})
+}
+
+func (s *migratingStore) Load() (*Credentials, error) {
+	s.migrate()
+	return s.Store.Load()
+}
+
+func (s *migratingStore) Save(c *Credentials) error {
+	s.migrate()
+	return s.Store.Save(c)
+}
+
+func (s *migratingStore) Delete() error {
+	s.migrate()
+	return s.Store.Delete()
+}
+
+func (s *migratingStore) reload() error {
+	if r, ok := s.Store.(reloader); ok {
+		return r.reload()
+	}
+	return nil
+}
+
+// ConfigStore keeps credentials in plaintext in config.yaml, as stompy has
+// always done.
+type ConfigStore struct{}
+
+// Load implements Store.
+func (ConfigStore) Load() (*Credentials, error) {
+	tok := config.GetAccessToken()
+	if tok == "" {
+		return nil, nil
+	}
+	return &Credentials{
+		AccessToken:  tok,
+		RefreshToken: config.GetRefreshToken(),
+		Expiry:       config.GetTokenExpiry(),
+		Email:        config.GetEmail(),
+		UserID:       config.GetUserID(),
+	}, nil
+}
+
+// Save implements Store.
+func (ConfigStore) Save(c *Credentials) error {
+	return config.SaveTokens(c.AccessToken, c.RefreshToken, c.Expiry, c.Email, c.UserID)
+}
+
+// Delete implements Store.
+func (ConfigStore) Delete() error {
+	return config.ClearTokens()
+}
+
+// reload implements reloader.
+func (ConfigStore) reload() error {
+	return config.ReloadTokens()
+}
+
+// Name implements Store.
+func (ConfigStore) Name() string { return "config file " + config.GetConfigPath() }
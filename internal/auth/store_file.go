@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/banton/stompy-cli/internal/config"
+)
+
+const (
+	kdfPBKDF2 = "pbkdf2-sha256"
+	kdfNone   = "none" // raw key
+
+	fileStoreVersion = 1
+	keyLength        = 32 // AES-256
+)
+
+// pbkdf2Iterations follows OWASP's recommendation for PBKDF2-HMAC-SHA256.
+var pbkdf2Iterations = 600_000
+
+// fileAAD binds the ciphertext to this file format.
+var fileAAD = []byte("stompy-credentials-v1")
+
+// encryptedFile is the on-disk format of the encrypted store.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// fileStore keeps credentials AES-256-GCM encrypted in a file, with the key
+// either given directly or derived from a passphrase.
+type fileStore struct {
+	path       string
+	passphrase string
+	key        []byte
+
+	mu      sync.Mutex
+	derived map[string][]byte // passphrase keys by salt; derivation is slow
+}
+
+func newFileStore(opts StoreOptions) (*fileStore, error) {
+	if opts.File == "" {
+		return nil, errors.New("credential file path is not set")
+	}
+	s := &fileStore{path: opts.File, passphrase: opts.Passphrase, derived: map[string][]byte{}}
+	switch {
+	case opts.Key != "":
+		key, err := base64.StdEncoding.DecodeString(opts.Key)
+		if err != nil || len(key) != keyLength {
+			return nil, fmt.Errorf("STOMPY_CREDENTIAL_KEY must be %d base64-encoded bytes (e.g. from 'openssl rand -base64 32')", keyLength)
+		}
+		s.key = key
+	case opts.Passphrase == "":
+		return nil, errors.New("the encrypted credential store needs STOMPY_CREDENTIAL_PASSPHRASE or STOMPY_CREDENTIAL_KEY")
+	}
+	return s, nil
+}
+
+// Load implements Store.
+func (s *fileStore) Load() (*Credentials, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading credentials: %w", err)
+	}
+
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("reading credentials %s: %w", s.path, err)
+	}
+	if f.Version != fileStoreVersion {
+		return nil, fmt.Errorf("reading credentials %s: unsupported version %d", s.path, f.Version)
+	}
+	key, err := s.keyFor(f)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Ciphertext, fileAAD)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting credentials %s: wrong passphrase or key, or the file is corrupted", s.path)
+	}
+
+	var c Credentials
+	if err := json.Unmarshal(plain, &c); err != nil {
+		return nil, fmt.Errorf("decoding credentials: %w", err)
+	}
+	return &c, nil
+}
+
+// Save implements Store.
+func (s *fileStore) Save(c *Credentials) error {
+	plain, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	f := encryptedFile{Version: fileStoreVersion, KDF: kdfNone}
+	if s.key == nil {
+		f.KDF, f.Iterations = kdfPBKDF2, pbkdf2Iterations
+		f.Salt = s.salt()
+	}
+	key, err := s.keyFor(f)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, plain, fileAAD)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := config.WriteFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("saving credentials: %w", err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *fileStore) Delete() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing credentials: %w", err)
+	}
+	return nil
+}
+
+// Name implements Store.
+func (s *fileStore) Name() string { return "encrypted file " + s.path }
+
+// keyFor returns the key that encrypts f.
+func (s *fileStore) keyFor(f encryptedFile) ([]byte, error) {
+	switch f.KDF {
+	case kdfNone:
+		if s.key == nil {
+			return nil, fmt.Errorf("credentials %s are encrypted with a key; set STOMPY_CREDENTIAL_KEY", s.path)
+		}
+		return s.key, nil
+	case kdfPBKDF2:
+		if s.passphrase == "" {
+			return nil, fmt.Errorf("credentials %s are encrypted with a passphrase; set STOMPY_CREDENTIAL_PASSPHRASE", s.path)
+		}
+	default:
+		return nil, fmt.Errorf("credentials %s use unknown key derivation %q", s.path, f.KDF)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.derived[string(f.Salt)]; ok {
+		return key, nil
+	}
+	key, err := pbkdf2.Key(sha256.New, s.passphrase, f.Salt, f.Iterations, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+	s.derived[string(f.Salt)] = key
+	return key, nil
+}
+
+// salt returns a salt whose key is already derived, so saving after a load
+// does not pay for a second derivation, or a fresh one.
+func (s *fileStore) salt() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for salt := range s.derived {
+		return []byte(salt)
+	}
+	salt := make([]byte, 16)
+	rand.Read(salt) //nolint:errcheck // crypto/rand.Read never fails
+	return salt
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
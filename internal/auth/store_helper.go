@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// helperStore delegates to an external program, in the style of
+// git-credential helpers. The helper is run as "<command> get|store|erase"
+// with key=value lines on stdin, ended by a blank line:
+//
+//	host=api.stompy.ai
+//	profile=work          (only with a named profile)
+//	access_token=...      (store only)
+//	refresh_token=...     (store only)
+//	expiry=2026-01-02T15:04:05Z
+//	email=...
+//	user_id=...
+//
+// For get, the helper prints the same keys on stdout, or nothing when it
+// holds no credentials for host. A non-zero exit is an error.
+type helperStore struct {
+	command []string
+	host    string
+	profile string
+
+	// The helper is asked once per command; every request reads the token.
+	mu     sync.Mutex
+	cached *Credentials
+	loaded bool
+}
+
+func newHelperStore(opts StoreOptions) (*helperStore, error) {
+	command := strings.Fields(opts.Helper)
+	if len(command) == 0 {
+		return nil, errors.New("credential_store is helper but credential_helper is not set")
+	}
+	return &helperStore{command: command, host: opts.Host, profile: opts.Profile}, nil
+}
+
+// Load implements Store.
+func (s *helperStore) Load() (*Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded {
+		return s.cached, nil
+	}
+
+	out, err := s.run("get", map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+	fields := parseHelperOutput(out)
+	if fields["access_token"] == "" {
+		s.cached, s.loaded = nil, true
+		return nil, nil
+	}
+	c := &Credentials{
+		AccessToken:  fields["access_token"],
+		RefreshToken: fields["refresh_token"],
+		Email:        fields["email"],
+		UserID:       fields["user_id"],
+	}
+	if exp := fields["expiry"]; exp != "" {
+		if c.Expiry, err = time.Parse(time.RFC3339, exp); err != nil {
+			return nil, fmt.Errorf("credential helper returned invalid expiry %q", exp)
+		}
+	}
+	s.cached, s.loaded = c, true
+	return c, nil
+}
+
+// Save implements Store.
+func (s *helperStore) Save(c *Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fields := map[string]string{
+		"access_token":  c.AccessToken,
+		"refresh_token": c.RefreshToken,
+		"email":         c.Email,
+		"user_id":       c.UserID,
+	}
+	if !c.Expiry.IsZero() {
+		fields["expiry"] = c.Expiry.UTC().Format(time.RFC3339)
+	}
+	if _, err := s.run("store", fields); err != nil {
+		return err
+	}
+	saved := *c
+	s.cached, s.loaded = &saved, true
+	return nil
+}
+
+// Delete implements Store.
+func (s *helperStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.run("erase", map[string]string{}); err != nil {
+		return err
+	}
+	s.cached, s.loaded = nil, true
+	return nil
+}
+
+// reload implements reloader.
+func (s *helperStore) reload() error {
+	s.mu.Lock()
+	s.cached, s.loaded = nil, false
+	s.mu.Unlock()
+	return nil
+}
+
+// Name implements Store.
+func (s *helperStore) Name() string { return "credential helper " + s.command[0] }
+
+// run invokes the helper with action and fields on stdin and returns its stdout.
+func (s *helperStore) run(action string, fields map[string]string) ([]byte, error) {
+	var in bytes.Buffer
+	fmt.Fprintf(&in, "host=%s\n", s.host)
+	if s.profile != "" {
+		fmt.Fprintf(&in, "profile=%s\n", s.profile)
+	}
+	for _, k := range []string{"access_token", "refresh_token", "expiry", "email", "user_id"} {
+		if v := fields[k]; v != "" {
+			fmt.Fprintf(&in, "%s=%s\n", k, v)
+		}
+	}
+	in.WriteString("\n")
+
+	args := append(append([]string{}, s.command[1:]...), action)
+	cmd := exec.Command(s.command[0], args...)
+	cmd.Stdin = &in
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %s %s: %w", s.command[0], action, err)
+	}
+	return out, nil
+}
+
+// parseHelperOutput reads key=value lines up to the first blank line.
+func parseHelperOutput(out []byte) map[string]string {
+	fields := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" {
+			break
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			fields[k] = v
+		}
+	}
+	return fields
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/config"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	// Keep the passphrase tests fast
+	pbkdf2Iterations = 1000
+}
+
+var testCreds = Credentials{
+	AccessToken:  "access-tok",
+	RefreshToken: "refresh-tok",
+	Expiry:       time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	Email:        "user@test.com",
+	UserID:       "user-123",
+}
+
+func assertRoundTrip(t *testing.T, s Store) {
+	t.Helper()
+	if got, err := s.Load(); err != nil || got != nil {
+		t.Fatalf("empty Load() = %v, %v; want nil, nil", got, err)
+	}
+	if err := s.Save(&testCreds); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got == nil || *got != testCreds {
+		t.Errorf("Load() = %+v, want %+v", got, testCreds)
+	}
+	if err := s.Delete(); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if got, err := s.Load(); err != nil || got != nil {
+		t.Errorf("Load() after Delete = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestFileStore_Passphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	s, err := NewStore(StoreFile, StoreOptions{File: path, Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRoundTrip(t, s)
+
+	if err := s.Save(&testCreds); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "refresh-tok") {
+		t.Error("credentials file contains the plaintext refresh token")
+	}
+	if info, _ := os.Stat(path); runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %04o, want 0600", info.Mode().Perm())
+	}
+
+	wrong, _ := NewStore(StoreFile, StoreOptions{File: path, Passphrase: "battery staple"})
+	if _, err := wrong.Load(); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Load() with wrong passphrase error = %v", err)
+	}
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	keyed, _ := NewStore(StoreFile, StoreOptions{File: path, Key: key})
+	if _, err := keyed.Load(); err == nil || !strings.Contains(err.Error(), "STOMPY_CREDENTIAL_PASSPHRASE") {
+		t.Errorf("Load() with a key for a passphrase file error = %v", err)
+	}
+}
+
+func TestFileStore_Key(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	s, err := NewStore(StoreFile, StoreOptions{File: filepath.Join(t.TempDir(), "c.enc"), Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRoundTrip(t, s)
+
+	if _, err := NewStore(StoreFile, StoreOptions{File: "c.enc", Key: "c2hvcnQ="}); err == nil {
+		t.Error("NewStore() accepted a 5-byte key")
+	}
+	if _, err := NewStore(StoreFile, StoreOptions{File: "c.enc"}); err == nil {
+		t.Error("NewStore() accepted neither passphrase nor key")
+	}
+}
+
+func TestHelperStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper script needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "helper.sh")
+	// Keeps the last stdin of "store" and replays it, minus host, on "get"
+	script := `#!/bin/sh
+state="` + dir + `/state"
+case "$1" in
+get) [ -f "$state" ] && grep -v '^host=' "$state" ;;
+store) cat > "$state"; grep -q '^host=api.example.com$' "$state" || exit 3 ;;
+erase) rm -f "$state" ;;
+esac
+exit 0
+`
+	if err := os.WriteFile(helper, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStore(StoreHelper, StoreOptions{Helper: helper, Host: "api.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRoundTrip(t, s)
+
+	// A fresh store asks the helper instead of its cache
+	if err := s.Save(&testCreds); err != nil {
+		t.Fatal(err)
+	}
+	fresh, _ := NewStore(StoreHelper, StoreOptions{Helper: helper, Host: "api.example.com"})
+	if got, err := fresh.Load(); err != nil || got == nil || *got != testCreds {
+		t.Errorf("fresh Load() = %+v, %v; want %+v", got, err, testCreds)
+	}
+
+	failing, _ := NewStore(StoreHelper, StoreOptions{Helper: helper, Host: "other.example.com"})
+	if err := failing.Save(&testCreds); err == nil {
+		t.Error("Save() succeeded although the helper exited non-zero")
+	}
+}
+
+func TestMigrateFromConfig(t *testing.T) {
+	resetViper()
+	t.Setenv("HOME", t.TempDir())
+	if err := (ConfigStore{}).Save(&testCreds); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStore(StoreFile, StoreOptions{File: filepath.Join(t.TempDir(), "c.enc"), Passphrase: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, err := MigrateFromConfig(s)
+	if err != nil || !moved {
+		t.Fatalf("MigrateFromConfig() = %v, %v; want true, nil", moved, err)
+	}
+	if got := viper.GetString("auth.refresh_token"); got != "" {
+		t.Errorf("config still holds refresh token %q", got)
+	}
+	if got, err := s.Load(); err != nil || got == nil || *got != testCreds {
+		t.Errorf("migrated credentials = %+v, %v; want %+v", got, err, testCreds)
+	}
+
+	if moved, err := MigrateFromConfig(s); err != nil || moved {
+		t.Errorf("second MigrateFromConfig() = %v, %v; want false, nil", moved, err)
+	}
+}
+
+func TestMigrateOnUse(t *testing.T) {
+	resetViper()
+	t.Setenv("HOME", t.TempDir())
+	if err := (ConfigStore{}).Save(&testCreds); err != nil {
+		t.Fatal(err)
+	}
+
+	inner, err := NewStore(StoreFile, StoreOptions{File: filepath.Join(t.TempDir(), "c.enc"), Passphrase: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	s := MigrateOnUse(inner, func(moved bool, err error) {
+		calls++
+		if err != nil || !moved {
+			t.Errorf("migration = %v, %v; want true, nil", moved, err)
+		}
+	})
+	if calls != 0 || viper.GetString("auth.refresh_token") == "" {
+		t.Fatal("tokens migrated before the store was used")
+	}
+
+	for range 2 {
+		if got, err := s.Load(); err != nil || got == nil || *got != testCreds {
+			t.Errorf("Load() = %+v, %v; want %+v", got, err, testCreds)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("migration ran %d times, want 1", calls)
+	}
+	if got := viper.GetString("auth.refresh_token"); got != "" {
+		t.Errorf("config still holds refresh token %q", got)
+	}
+
+	if _, ok := MigrateOnUse(ConfigStore{}, nil).(ConfigStore); !ok {
+		t.Error("MigrateOnUse wrapped the config store")
+	}
+}
+
+func TestFileStore_PerProfile(t *testing.T) {
+	resetViper()
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { config.SetProfile("") })
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	// One explicit credential_file shared by the default and two named profiles
+	viper.Set("credential_file", filepath.Join(t.TempDir(), "creds.enc"))
+	for _, name := range []string{"work", "home"} {
+		if err := config.AddProfile(name, config.Profile{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stores := map[string]Store{}
+	for _, name := range []string{"", "work", "home"} {
+		if err := config.SetProfile(name); err != nil {
+			t.Fatal(err)
+		}
+		s, err := NewStore(StoreFile, StoreOptions{File: config.GetCredentialFile(), Passphrase: "pw", Profile: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		creds := testCreds
+		creds.AccessToken = "access-" + name
+		if err := s.Save(&creds); err != nil {
+			t.Fatal(err)
+		}
+		stores[name] = s
+	}
+
+	// Removing one profile's credentials leaves the others alone
+	if err := stores["work"].Delete(); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"": "access-", "home": "access-home"} {
+		got, err := stores[name].Load()
+		if err != nil || got == nil || got.AccessToken != want {
+			t.Errorf("profile %q Load() = %+v, %v; want access token %q", name, got, err, want)
+		}
+	}
+	if got, _ := stores["work"].Load(); got != nil {
+		t.Errorf("work Load() after Delete = %+v, want nil", got)
+	}
+}
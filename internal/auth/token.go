@@ -1,11 +1,12 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/banton/stompy-cli/internal/config"
@@ -13,21 +14,28 @@ import (
 
 const TokenExpiryBuffer = 5 * time.Minute
 
+// tokenTimeout bounds each call to the OAuth token endpoint.
+const tokenTimeout = 30 * time.Second
+
+// lockTimeout bounds the wait for another process's refresh, which is one
+// call to the token endpoint.
+const lockTimeout = tokenTimeout + 15*time.Second
+
 // IsExpired checks if a token expiry time has passed (with 5-minute safety buffer).
 func IsExpired(expiry time.Time) bool {
 	return time.Now().After(expiry.Add(-TokenExpiryBuffer))
 }
 
 // RefreshToken uses a refresh token to obtain a new access token.
-func RefreshToken(apiURL, refreshToken string) (*TokenResponse, error) {
+// Cancelling ctx aborts the request.
+func RefreshToken(ctx context.Context, apiURL, refreshToken string) (*TokenResponse, error) {
 	data := url.Values{
 		"grant_type":    {"refresh_token"},
 		"refresh_token": {refreshToken},
 		"client_id":     {CLIClientID},
 	}
 
-	tokenURL := strings.TrimSuffix(apiURL, "/api/v1") + "/oauth/token"
-	resp, err := http.PostForm(tokenURL, data)
+	resp, err := postForm(ctx, oauthURL(apiURL, "/oauth/token"), data)
 	if err != nil {
 		return nil, fmt.Errorf("refreshing token: %w", err)
 	}
@@ -48,32 +56,116 @@ func RefreshToken(apiURL, refreshToken string) (*TokenResponse, error) {
 // GetValidToken returns a valid access token. It checks the stored token's
 // expiry, refreshes if needed, persists updated tokens, and returns the
 // access token string. Returns an error if no token is stored or refresh fails.
-func GetValidToken(apiURL string) (string, error) {
-	accessToken := config.GetAccessToken()
-	if accessToken == "" {
+func GetValidToken(ctx context.Context, apiURL string) (string, error) {
+	creds, err := LoadCredentials()
+	if err != nil {
+		return "", err
+	}
+	if creds == nil {
 		return "", fmt.Errorf("not logged in — please run 'stompy login'")
 	}
 
-	expiry := config.GetTokenExpiry()
-	if !IsExpired(expiry) {
-		return accessToken, nil
+	if !IsExpired(creds.Expiry) {
+		return creds.AccessToken, nil
 	}
 
 	// Token expired or within buffer — try refresh
-	rt := config.GetRefreshToken()
-	if rt == "" {
+	if creds.RefreshToken == "" {
 		return "", fmt.Errorf("token expired and no refresh token available — please run 'stompy login'")
 	}
 
-	tokenResp, err := RefreshToken(apiURL, rt)
+	return refreshLocked(ctx, apiURL, func(c *Credentials) bool {
+		return !IsExpired(c.Expiry)
+	})
+}
+
+// refreshLocked refreshes the stored tokens while holding the config lock.
+// Servers may rotate refresh tokens on use, so parallel stompy processes
+// must not each refresh the same one: after taking the lock the tokens are
+// re-read, and if another process already replaced them with ones usable
+// accepts, those are returned instead.
+func refreshLocked(ctx context.Context, apiURL string, usable func(*Credentials) bool) (string, error) {
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+	unlock, err := config.Lock(lockCtx)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	if err := reloadCredentials(); err != nil {
+		return "", err
+	}
+	creds, err := LoadCredentials()
+	if err != nil {
+		return "", err
+	}
+	if creds == nil {
+		return "", fmt.Errorf("not logged in — please run 'stompy login'")
+	}
+	if creds.AccessToken != "" && usable(creds) {
+		return creds.AccessToken, nil
+	}
+	if creds.RefreshToken == "" {
+		return "", fmt.Errorf("no refresh token available — please run 'stompy login'")
+	}
+	return refreshAndSave(ctx, apiURL, creds)
+}
+
+// refreshAndSave exchanges the refresh token in creds for new tokens and
+// persists them.
+func refreshAndSave(ctx context.Context, apiURL string, creds *Credentials) (string, error) {
+	tokenResp, err := RefreshToken(ctx, apiURL, creds.RefreshToken)
 	if err != nil {
 		return "", err
 	}
 
-	newExpiry := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-	if err := config.SaveTokens(tokenResp.AccessToken, tokenResp.RefreshToken, newExpiry, config.GetEmail(), ""); err != nil {
+	refreshed := *creds
+	refreshed.AccessToken = tokenResp.AccessToken
+	if tokenResp.RefreshToken != "" {
+		refreshed.RefreshToken = tokenResp.RefreshToken
+	}
+	refreshed.Expiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	if err := SaveCredentials(&refreshed); err != nil {
 		return "", fmt.Errorf("saving refreshed tokens: %w", err)
 	}
 
 	return tokenResp.AccessToken, nil
 }
+
+// ConfigTokenSource serves the OAuth access token from the credential store
+// and refreshes it when the API rejects it mid-command. It implements
+// stompy.TokenSource and is safe for concurrent use.
+type ConfigTokenSource struct {
+	APIURL string
+
+	mu sync.Mutex
+}
+
+// Token returns the stored access token.
+func (s *ConfigTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	creds, err := LoadCredentials()
+	if err != nil {
+		return "", err
+	}
+	if creds != nil && creds.AccessToken != "" {
+		return creds.AccessToken, nil
+	}
+	return "", fmt.Errorf("not logged in — please run 'stompy login'")
+}
+
+// Refresh obtains a new access token after rejected was refused. If another
+// request, or another stompy process, already refreshed past rejected, the
+// current token is returned without a second round trip.
+func (s *ConfigTokenSource) Refresh(ctx context.Context, rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return refreshLocked(ctx, s.APIURL, func(c *Credentials) bool {
+		return c.AccessToken != rejected
+	})
+}
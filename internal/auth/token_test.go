@@ -1,12 +1,17 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/banton/stompy-cli/internal/config"
 	"github.com/spf13/viper"
 )
 
@@ -82,7 +87,7 @@ func TestRefreshToken(t *testing.T) {
 	defer server.Close()
 
 	apiURL := server.URL + "/api/v1"
-	got, err := RefreshToken(apiURL, "old-refresh-token")
+	got, err := RefreshToken(context.Background(), apiURL, "old-refresh-token")
 	if err != nil {
 		t.Fatalf("RefreshToken() error: %v", err)
 	}
@@ -104,12 +109,32 @@ func TestRefreshToken_Failure(t *testing.T) {
 	}))
 	defer server.Close()
 
-	_, err := RefreshToken(server.URL+"/api/v1", "expired-refresh-token")
+	_, err := RefreshToken(context.Background(), server.URL+"/api/v1", "expired-refresh-token")
 	if err == nil {
 		t.Error("RefreshToken() expected error for 401 response, got nil")
 	}
 }
 
+func TestRefreshToken_Canceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := RefreshToken(ctx, server.URL+"/api/v1", "refresh-token")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RefreshToken() error = %v, want context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("RefreshToken() returned after %s, want it to stop with ctx", d)
+	}
+}
+
 // resetViper clears all viper state for test isolation.
 func resetViper() {
 	viper.Reset()
@@ -118,7 +143,7 @@ func resetViper() {
 func TestGetValidToken_NotLoggedIn(t *testing.T) {
 	resetViper()
 
-	_, err := GetValidToken("https://api.stompy.ai/api/v1")
+	_, err := GetValidToken(context.Background(), "https://api.stompy.ai/api/v1")
 	if err == nil {
 		t.Error("GetValidToken() expected error when not logged in, got nil")
 	}
@@ -129,7 +154,7 @@ func TestGetValidToken_ValidToken(t *testing.T) {
 	viper.Set("auth.access_token", "valid-access-token")
 	viper.Set("auth.token_expiry", time.Now().Add(1*time.Hour).Format(time.RFC3339))
 
-	token, err := GetValidToken("https://api.stompy.ai/api/v1")
+	token, err := GetValidToken(context.Background(), "https://api.stompy.ai/api/v1")
 	if err != nil {
 		t.Fatalf("GetValidToken() error: %v", err)
 	}
@@ -144,7 +169,7 @@ func TestGetValidToken_ExpiredNoRefreshToken(t *testing.T) {
 	viper.Set("auth.token_expiry", time.Now().Add(-1*time.Hour).Format(time.RFC3339))
 	// No refresh token set
 
-	_, err := GetValidToken("https://api.stompy.ai/api/v1")
+	_, err := GetValidToken(context.Background(), "https://api.stompy.ai/api/v1")
 	if err == nil {
 		t.Error("GetValidToken() expected error when expired with no refresh token, got nil")
 	}
@@ -175,7 +200,7 @@ func TestGetValidToken_RefreshesExpiredToken(t *testing.T) {
 	tmpDir := t.TempDir()
 	viper.SetConfigFile(tmpDir + "/config.yaml")
 
-	token, err := GetValidToken(server.URL + "/api/v1")
+	token, err := GetValidToken(context.Background(), server.URL+"/api/v1")
 	if err != nil {
 		t.Fatalf("GetValidToken() error: %v", err)
 	}
@@ -188,3 +213,83 @@ func TestGetValidToken_RefreshesExpiredToken(t *testing.T) {
 		t.Errorf("persisted access_token = %q, want %q", got, "refreshed-access-token")
 	}
 }
+
+func TestConfigTokenSource_Refresh(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if got := r.FormValue("refresh_token"); got != "old-refresh-token" {
+			t.Errorf("refresh_token = %q", got)
+		}
+		json.NewEncoder(w).Encode(TokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 3600})
+	}))
+	defer server.Close()
+
+	resetViper()
+	t.Setenv("HOME", t.TempDir())
+	viper.SetConfigFile(t.TempDir() + "/config.yaml")
+	viper.Set("auth.access_token", "revoked-access")
+	viper.Set("auth.refresh_token", "old-refresh-token")
+	viper.Set("auth.token_expiry", time.Now().Add(time.Hour).Format(time.RFC3339))
+
+	src := &ConfigTokenSource{APIURL: server.URL + "/api/v1"}
+	tok, err := src.Refresh(context.Background(), "revoked-access")
+	if err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if tok != "new-access" {
+		t.Errorf("token = %q, want new-access", tok)
+	}
+	if got := viper.GetString("auth.refresh_token"); got != "new-refresh" {
+		t.Errorf("persisted refresh_token = %q, want new-refresh", got)
+	}
+
+	// A second caller that was rejected with the old token reuses the new one.
+	tok, err = src.Refresh(context.Background(), "revoked-access")
+	if err != nil || tok != "new-access" {
+		t.Errorf("second Refresh() = %q, %v", tok, err)
+	}
+	if calls != 1 {
+		t.Errorf("token endpoint called %d times, want 1", calls)
+	}
+}
+
+func TestGetValidToken_UsesTokensRefreshedByAnotherProcess(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	resetViper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("auth.access_token", "expired-token")
+	viper.Set("auth.refresh_token", "rotated-away")
+	viper.Set("auth.token_expiry", time.Now().Add(-time.Hour).Format(time.RFC3339))
+
+	// Another process refreshed meanwhile, rotating the refresh token
+	other := fmt.Sprintf("auth:\n  access_token: fresh-token\n  refresh_token: new-refresh\n  token_expiry: %q\n",
+		time.Now().Add(time.Hour).Format(time.RFC3339))
+	if err := os.MkdirAll(config.GetConfigDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.GetConfigPath(), []byte(other), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := GetValidToken(context.Background(), server.URL+"/api/v1")
+	if err != nil {
+		t.Fatalf("GetValidToken() error: %v", err)
+	}
+	if token != "fresh-token" || calls != 0 {
+		t.Errorf("token = %q after %d refresh calls, want fresh-token without refreshing", token, calls)
+	}
+	if got := viper.GetString("auth.refresh_token"); got != "new-refresh" {
+		t.Errorf("refresh_token = %q, want the other process's new-refresh", got)
+	}
+}
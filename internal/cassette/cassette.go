@@ -0,0 +1,260 @@
+// Package cassette records HTTP exchanges to a file and replays them later
+// without network access, for reproducible tests and bug reports. Credentials
+// are redacted from headers and bodies before anything is written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/banton/stompy-cli/internal/har"
+)
+
+const formatVersion = 1
+
+// Cassette is the on-disk list of recorded exchanges.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request/response pair.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded form of an outgoing request.
+type Request struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" for non-UTF-8 bodies
+	BodySize     int64       `json:"body_size,omitempty"`     // full size, when Body is truncated
+}
+
+// Response is the recorded form of a server response.
+type Response struct {
+	StatusCode   int         `json:"status_code"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+	BodySize     int64       `json:"body_size,omitempty"`
+}
+
+// Load reads a cassette file.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path with owner-only permissions.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+// Recorder is an http.RoundTripper that forwards requests to Next and keeps
+// every exchange for the cassette file, written by Close. Bodies are passed
+// through as they stream, keeping at most har.MaxBodyBytes of each (replay
+// serves the kept prefix), and credentials are redacted as in --trace files.
+type Recorder struct {
+	path    string
+	next    http.RoundTripper
+	mu      sync.Mutex
+	entries []*exchange
+}
+
+// exchange is an interaction being recorded. Its fields are guarded by
+// Recorder.mu.
+type exchange struct {
+	in       Interaction
+	done     bool
+	reqBody  *har.Capture
+	respBody *har.Capture
+}
+
+// NewRecorder returns a Recorder writing to path. If next is nil,
+// http.DefaultTransport is used.
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{path: path, next: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	x := &exchange{in: Interaction{Request: Request{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Headers: har.RedactHeaders(req.Header),
+	}}}
+	out := req
+	if req.Body != nil && req.Body != http.NoBody {
+		x.reqBody = &har.Capture{ReadCloser: req.Body}
+		out = req.Clone(req.Context())
+		out.Body = x.reqBody
+	}
+
+	resp, err := r.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	x.in.Response = Response{StatusCode: resp.StatusCode, Headers: har.RedactHeaders(resp.Header)}
+	x.respBody = &har.Capture{ReadCloser: resp.Body, OnDone: func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		x.finishLocked()
+	}}
+	resp.Body = x.respBody
+
+	r.mu.Lock()
+	r.entries = append(r.entries, x)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// finishLocked fills in the recorded bodies. Callers hold Recorder.mu.
+func (x *exchange) finishLocked() {
+	if x.done {
+		return
+	}
+	x.done = true
+	if x.reqBody != nil {
+		req := &x.in.Request
+		req.Body, req.BodyEncoding, req.BodySize = recordBody(x.reqBody, req.Headers.Get("Content-Type"))
+	}
+	resp := &x.in.Response
+	resp.Body, resp.BodyEncoding, resp.BodySize = recordBody(x.respBody, resp.Headers.Get("Content-Type"))
+}
+
+// Close writes the recorded exchanges to the cassette file. Responses whose
+// bodies were not read to the end are recorded with what was read.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	c := Cassette{Version: formatVersion, Interactions: make([]Interaction, 0, len(r.entries))}
+	for _, x := range r.entries {
+		x.finishLocked()
+		c.Interactions = append(c.Interactions, x.in)
+	}
+	r.mu.Unlock()
+	return c.Save(r.path)
+}
+
+// recordBody returns a captured body as stored in the cassette, and the full
+// size when only a prefix was kept.
+func recordBody(c *har.Capture, contentType string) (body, encoding string, size int64) {
+	b, n, truncated := c.Snapshot()
+	if truncated {
+		size = n
+	}
+	body, encoding = encodeBody(contentType, b, truncated)
+	return body, encoding, size
+}
+
+// Replayer is an http.RoundTripper that serves responses from a cassette.
+// Requests are matched on method, path and query, each interaction is used at
+// most once, and matches are taken in recorded order.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayer loads the cassette at path for replay.
+func NewReplayer(path string) (*Replayer, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{cassette: c, used: make([]bool, len(c.Interactions))}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := requestKey(req.Method, req.URL.RequestURI())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] {
+			continue
+		}
+		u, err := req.URL.Parse(in.Request.URL)
+		if err != nil || requestKey(in.Request.Method, u.RequestURI()) != key {
+			continue
+		}
+		r.used[i] = true
+
+		body, err := decodeBody(in.Response.Body, in.Response.BodyEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: decoding recorded body for %s: %w", key, err)
+		}
+		header := in.Response.Headers.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette: no recorded response for %s", key)
+}
+
+func requestKey(method, requestURI string) string {
+	return strings.ToUpper(method) + " " + requestURI
+}
+
+// encodeBody stores text bodies as is, minus secrets, and binary ones in
+// base64.
+func encodeBody(contentType string, b []byte, truncated bool) (body, encoding string) {
+	if len(b) == 0 {
+		return "", ""
+	}
+	if utf8.Valid(b) {
+		return har.RedactBody(contentType, b, truncated), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), "base64"
+}
+
+func decodeBody(body, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
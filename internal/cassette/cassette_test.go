@@ -0,0 +1,174 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/banton/stompy-cli/internal/har"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Stompy-API-Version", "6.0.0")
+		io.WriteString(w, `{"path":"`+r.URL.Path+`"}`)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "traffic.json")
+	recorder := NewRecorder(path, nil)
+	rec := &http.Client{Transport: recorder}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/projects?stats=true", strings.NewReader(`{"name":"p"}`))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := rec.Do(req)
+	if err != nil {
+		t.Fatalf("recording request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"path":"/projects"}` {
+		t.Errorf("recorded body passed through = %q", body)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "secret-token") {
+		t.Error("cassette contains the Authorization token")
+	}
+
+	rp, err := NewReplayer(path)
+	if err != nil {
+		t.Fatalf("NewReplayer() error: %v", err)
+	}
+	srv.Close() // replay must not touch the network
+
+	replay := &http.Client{Transport: rp}
+	resp, err = replay.Post("http://other-host/projects?stats=true", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("replaying request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ = io.ReadAll(resp.Body)
+	if string(body) != `{"path":"/projects"}` {
+		t.Errorf("replayed body = %q", body)
+	}
+	if resp.Header.Get("X-Stompy-API-Version") != "6.0.0" {
+		t.Errorf("replayed header = %q, want 6.0.0", resp.Header.Get("X-Stompy-API-Version"))
+	}
+}
+
+func TestRecorder_RedactsAndCapsBodies(t *testing.T) {
+	large := strings.Repeat("x", har.MaxBodyBytes+10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		switch r.URL.Path {
+		case "/api-keys":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"id":"key_1","secret":"sk_live_abc"}`)
+		case "/download":
+			io.WriteString(w, large)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "traffic.json")
+	recorder := NewRecorder(path, nil)
+	client := &http.Client{Transport: recorder}
+
+	resp, err := client.PostForm(srv.URL+"/oauth/token", url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"rt_secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	resp, err = client.Post(srv.URL+"/api-keys", "application/json", strings.NewReader(`{"name":"ci"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "sk_live_abc") {
+		t.Errorf("caller got a redacted body: %s", body)
+	}
+	resp, err = client.Get(srv.URL + "/download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := io.Copy(io.Discard, resp.Body); n != int64(len(large)) {
+		t.Errorf("caller got %d bytes, want %d", n, len(large))
+	}
+	resp.Body.Close()
+
+	if _, err := os.Stat(path); err == nil {
+		t.Error("cassette written before Close")
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	for _, secret := range []string{"rt_secret", "sk_live_abc"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl := c.Interactions[2].Response
+	if len(dl.Body) != har.MaxBodyBytes || dl.BodySize != int64(len(large)) {
+		t.Errorf("download body kept %d bytes with body_size %d, want %d and %d", len(dl.Body), dl.BodySize, har.MaxBodyBytes, len(large))
+	}
+}
+
+func TestReplayer_ConsumesInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.json")
+	c := &Cassette{Version: formatVersion, Interactions: []Interaction{
+		{Request: Request{Method: "GET", URL: "http://x/a"}, Response: Response{StatusCode: 503}},
+		{Request: Request{Method: "GET", URL: "http://x/a"}, Response: Response{StatusCode: 200, Body: "ok"}},
+	}}
+	if err := c.Save(path); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	rp, err := NewReplayer(path)
+	if err != nil {
+		t.Fatalf("NewReplayer() error: %v", err)
+	}
+	client := &http.Client{Transport: rp}
+
+	for _, want := range []int{503, 200} {
+		resp, err := client.Get("http://x/a")
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("status = %d, want %d", resp.StatusCode, want)
+		}
+	}
+
+	if _, err := client.Get("http://x/a"); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("third Get() error = %v, want no recorded response", err)
+	}
+}
+
+func TestEncodeBody_Binary(t *testing.T) {
+	bin := []byte{0xff, 0xfe, 0x00, 0x01}
+	s, enc := encodeBody("application/octet-stream", bin, false)
+	if enc != "base64" {
+		t.Fatalf("encoding = %q, want base64", enc)
+	}
+	got, err := decodeBody(s, enc)
+	if err != nil || string(got) != string(bin) {
+		t.Errorf("round trip = %v, %v; want %v", got, err, bin)
+	}
+}
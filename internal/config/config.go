@@ -1,12 +1,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -57,16 +61,81 @@ func Load() error {
 // Save writes the current Viper config to the config file,
 // creating the directory if needed.
 func Save() error {
-	dir := GetConfigDir()
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("creating config dir: %w", err)
+	data, err := yaml.Marshal(viper.AllSettings())
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	return writeConfig(data)
+}
+
+// writeConfig replaces the config file with data, so a crash or a parallel
+// stompy process never sees a half-written config.
+func writeConfig(data []byte) error {
+	if err := WriteFileAtomic(GetConfigPath(), data, 0o600); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// WriteFileAtomic replaces path with data and perm, creating its directory
+// readable only by the user. The data goes to a temp file that is renamed
+// into place, so readers see either the old or the new file, never a
+// truncated one.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
 	}
-	return viper.WriteConfigAs(GetConfigPath())
+	return os.Rename(tmp.Name(), path)
+}
+
+// tokenKeys are the config keys SaveTokens writes.
+var tokenKeys = []string{"auth.access_token", "auth.refresh_token", "auth.token_expiry", "auth.email", "auth.user_id"}
+
+// ReloadTokens re-reads the config file, picking up OAuth tokens another
+// stompy process saved since Load. Tokens saved earlier by this process
+// give way to the file's.
+func ReloadTokens() error {
+	file := viper.New()
+	file.SetConfigFile(GetConfigPath())
+	if err := file.ReadInConfig(); err != nil {
+		// Nothing saved yet, so nothing newer than what is in memory
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("reading config: %w", err)
+	}
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("reading config: %w", err)
+		}
+	}
+	// Values set with viper.Set shadow the file, so copy the file's over them
+	for _, key := range tokenKeys {
+		viper.Set(profileKey(key), file.GetString(profileKey(key)))
+	}
+	return nil
 }
 
 // GetAPIURL returns the configured API URL.
 func GetAPIURL() string {
-	return viper.GetString("api_url")
+	return scoped("api_url", true)
 }
 
 // GetStagingAPIURL returns the staging API URL.
@@ -76,27 +145,94 @@ func GetStagingAPIURL() string {
 
 // GetAPIKey returns the configured API key.
 func GetAPIKey() string {
-	return viper.GetString("api_key")
+	return scoped("api_key", false)
 }
 
 // GetDefaultProject returns the configured default project.
 func GetDefaultProject() string {
-	return viper.GetString("default_project")
+	return scoped("default_project", false)
 }
 
 // GetOutputFormat returns the configured output format.
 func GetOutputFormat() string {
-	return viper.GetString("output_format")
+	return scoped("output_format", true)
 }
 
-// SetValue sets a config key to the given value and saves.
+// GetRateLimit returns the configured client-side rate limit (e.g. "10/s"), or "" if unlimited.
+func GetRateLimit() string {
+	return viper.GetString("rate_limit")
+}
+
+// GetHTTPSProxy returns the configured proxy URL, or "" to use the environment's.
+func GetHTTPSProxy() string {
+	return viper.GetString("https_proxy")
+}
+
+// GetNoProxy returns the configured comma-separated proxy bypass list.
+func GetNoProxy() string {
+	return viper.GetString("no_proxy")
+}
+
+// GetCABundle returns the path of an extra PEM CA bundle to trust.
+func GetCABundle() string {
+	return viper.GetString("ca_bundle")
+}
+
+// GetClientCert returns the path of the PEM client certificate for mutual TLS.
+func GetClientCert() string {
+	return viper.GetString("client_cert")
+}
+
+// GetClientKey returns the path of the PEM key for the client certificate.
+func GetClientKey() string {
+	return viper.GetString("client_key")
+}
+
+// GetTLSMinVersion returns the minimum TLS version (e.g. "1.2"), or "" for the default.
+func GetTLSMinVersion() string {
+	return viper.GetString("tls_min_version")
+}
+
+// GetCredentialStore returns where OAuth tokens are kept: "config"
+// (default), "file" or "helper".
+func GetCredentialStore() string {
+	return viper.GetString("credential_store")
+}
+
+// GetCredentialFile returns the path of the encrypted credentials file. A
+// named profile gets its own file beside it, with the profile name appended
+// (credentials-work.enc), so profiles never share or clear each other's
+// tokens.
+func GetCredentialFile() string {
+	p := viper.GetString("credential_file")
+	if p == "" {
+		p = filepath.Join(GetConfigDir(), "credentials.enc")
+	}
+	if activeProfile == "" {
+		return p
+	}
+	ext := filepath.Ext(p)
+	return strings.TrimSuffix(p, ext) + "-" + activeProfile + ext
+}
+
+// GetCredentialHelper returns the credential helper command line.
+func GetCredentialHelper() string {
+	return viper.GetString("credential_helper")
+}
+
+// SetValue sets a config key to the given value and saves. Per-profile keys
+// are set in the active profile.
 func SetValue(key, value string) error {
-	viper.Set(key, value)
+	viper.Set(profileKey(key), value)
 	return Save()
 }
 
-// GetValue returns the string value for a config key.
+// GetValue returns the string value for a config key, from the active
+// profile for per-profile keys.
 func GetValue(key string) string {
+	if isProfileKey(key) {
+		return scoped(key, inherits(key))
+	}
 	return viper.GetString(key)
 }
 
@@ -105,29 +241,30 @@ func GetAllSettings() map[string]any {
 	return viper.AllSettings()
 }
 
-// SaveTokens persists auth tokens and user info to the config file.
+// SaveTokens persists auth tokens and user info to the config file, in the
+// active profile.
 func SaveTokens(accessToken, refreshToken string, expiry time.Time, email, userID string) error {
-	viper.Set("auth.access_token", accessToken)
-	viper.Set("auth.refresh_token", refreshToken)
-	viper.Set("auth.token_expiry", expiry.Format(time.RFC3339))
-	viper.Set("auth.email", email)
-	viper.Set("auth.user_id", userID)
+	viper.Set(profileKey("auth.access_token"), accessToken)
+	viper.Set(profileKey("auth.refresh_token"), refreshToken)
+	viper.Set(profileKey("auth.token_expiry"), expiry.Format(time.RFC3339))
+	viper.Set(profileKey("auth.email"), email)
+	viper.Set(profileKey("auth.user_id"), userID)
 	return Save()
 }
 
 // GetAccessToken returns the stored access token.
 func GetAccessToken() string {
-	return viper.GetString("auth.access_token")
+	return scoped("auth.access_token", false)
 }
 
 // GetRefreshToken returns the stored refresh token.
 func GetRefreshToken() string {
-	return viper.GetString("auth.refresh_token")
+	return scoped("auth.refresh_token", false)
 }
 
 // GetTokenExpiry returns the stored token expiry time.
 func GetTokenExpiry() time.Time {
-	s := viper.GetString("auth.token_expiry")
+	s := scoped("auth.token_expiry", false)
 	if s == "" {
 		return time.Time{}
 	}
@@ -140,16 +277,21 @@ func GetTokenExpiry() time.Time {
 
 // GetEmail returns the stored user email.
 func GetEmail() string {
-	return viper.GetString("auth.email")
+	return scoped("auth.email", false)
+}
+
+// GetUserID returns the stored user ID.
+func GetUserID() string {
+	return scoped("auth.user_id", false)
 }
 
 // ClearTokens removes all auth tokens from the config and saves.
 func ClearTokens() error {
-	viper.Set("auth.access_token", "")
-	viper.Set("auth.refresh_token", "")
-	viper.Set("auth.token_expiry", "")
-	viper.Set("auth.email", "")
-	viper.Set("auth.user_id", "")
+	viper.Set(profileKey("auth.access_token"), "")
+	viper.Set(profileKey("auth.refresh_token"), "")
+	viper.Set(profileKey("auth.token_expiry"), "")
+	viper.Set(profileKey("auth.email"), "")
+	viper.Set(profileKey("auth.user_id"), "")
 	return Save()
 }
 
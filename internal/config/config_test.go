@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -50,11 +51,18 @@ func TestSaveAndLoad(t *testing.T) {
 		t.Fatalf("Save() error: %v", err)
 	}
 
-	// Verify file was written
+	// Verify file was written, readable only by the user, with no temp files left
 	configPath := filepath.Join(tmpDir, configDirName, configFileName+"."+configFileType)
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	info, err := os.Stat(configPath)
+	if os.IsNotExist(err) {
 		t.Fatalf("config file not created at %s", configPath)
 	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("config file mode = %v, want 0600", info.Mode().Perm())
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(tmpDir, configDirName, ".config-*")); len(tmp) != 0 {
+		t.Errorf("temp files left behind: %v", tmp)
+	}
 
 	// Reset and re-load
 	viper.Reset()
@@ -232,3 +240,28 @@ func TestResolveProject_Precedence(t *testing.T) {
 		t.Errorf("ResolveProject() = %q, want %q (env should win over config)", got, "env-project")
 	}
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested")
+	path := filepath.Join(dir, "creds.json")
+	for _, data := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("WriteFileAtomic() error: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != data {
+			t.Errorf("file = %q, %v; want %q", got, err, data)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want no leftover temp files", len(entries))
+	}
+	if runtime.GOOS != "windows" {
+		info, _ := os.Stat(path)
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("perm = %o, want 600", perm)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockFileName is the file in the config directory that Lock locks.
+const lockFileName = "config.lock"
+
+// lockPollInterval is how often Lock retries while another process holds the lock.
+var lockPollInterval = 50 * time.Millisecond
+
+// Lock takes an exclusive advisory lock on the config directory, waiting
+// until it is free or ctx is done. Parallel stompy processes hold it while
+// they read, refresh and save OAuth tokens, so one process's refresh is
+// neither lost to nor repeated by another. The returned function releases
+// the lock; exiting releases it too.
+func Lock(ctx context.Context) (unlock func(), err error) {
+	if err := os.MkdirAll(GetConfigDir(), 0700); err != nil {
+		return nil, fmt.Errorf("creating config dir: %w", err)
+	}
+	path := filepath.Join(GetConfigDir(), lockFileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+		if locked {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+
+		t := time.NewTimer(lockPollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			f.Close()
+			return nil, fmt.Errorf("waiting for another stompy process to release %s: %w", path, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
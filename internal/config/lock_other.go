@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package config
+
+import "os"
+
+// tryLockFile always succeeds: this platform has no file locking, so
+// parallel processes are not serialized.
+func tryLockFile(*os.File) (bool, error) { return true, nil }
+
+func unlockFile(*os.File) {}
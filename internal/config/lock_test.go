@@ -0,0 +1,40 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	setupTestConfig(t)
+
+	unlock, err := Lock(context.Background())
+	if err != nil {
+		t.Fatalf("Lock() error: %v", err)
+	}
+
+	// A second holder, as another process would be, waits for the first
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := Lock(ctx); err == nil {
+		t.Fatal("second Lock() succeeded while the lock was held")
+	}
+
+	acquired := make(chan func())
+	go func() {
+		unlock2, err := Lock(context.Background())
+		if err != nil {
+			t.Errorf("Lock() after release error: %v", err)
+		}
+		acquired <- unlock2
+	}()
+	time.Sleep(2 * lockPollInterval)
+	unlock()
+	select {
+	case unlock2 := <-acquired:
+		unlock2()
+	case <-time.After(5 * time.Second):
+		t.Fatal("Lock() did not acquire the lock after it was released")
+	}
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile names the top-level settings, used when no profile is selected.
+const DefaultProfile = "default"
+
+const profilesKey = "profiles"
+
+// profileKeys are the settings each profile carries, and whether a profile
+// that leaves one unset falls back to the top-level value. Credentials and
+// the default project never fall back: they belong to one account.
+var profileKeys = map[string]bool{
+	"api_url":         true,
+	"output_format":   true,
+	"api_key":         false,
+	"default_project": false,
+}
+
+var profileNameRE = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// activeProfile is the profile the getters read; "" for the top level.
+var activeProfile string
+
+// Profile holds the settings a profile overrides.
+type Profile struct {
+	APIURL         string
+	APIKey         string
+	DefaultProject string
+	OutputFormat   string
+}
+
+// ResolveProfile determines the profile to use with this precedence:
+// 1. Explicit flag value
+// 2. STOMPY_PROFILE environment variable
+// 3. current_profile from config
+func ResolveProfile(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if env := os.Getenv("STOMPY_PROFILE"); env != "" {
+		return env
+	}
+	return viper.GetString("current_profile")
+}
+
+// SetProfile makes the getters read the named profile's settings. "" and
+// "default" select the top-level settings.
+func SetProfile(name string) error {
+	if name == DefaultProfile {
+		name = ""
+	}
+	if name != "" && !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist. Run 'stompy profile list' to see available profiles", name)
+	}
+	activeProfile = name
+	return nil
+}
+
+// ActiveProfile returns the name of the profile in use.
+func ActiveProfile() string {
+	if activeProfile == "" {
+		return DefaultProfile
+	}
+	return activeProfile
+}
+
+// CurrentProfile returns the profile selected with 'stompy profile use'.
+func CurrentProfile() string {
+	if p := viper.GetString("current_profile"); p != "" {
+		return p
+	}
+	return DefaultProfile
+}
+
+// UseProfile saves name as the profile used when none is given.
+func UseProfile(name string) error {
+	if name == DefaultProfile {
+		name = ""
+	}
+	viper.Set("current_profile", name)
+	return Save()
+}
+
+// ValidateProfileName checks that name can be used as a profile name.
+func ValidateProfileName(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("%q is reserved for the top-level settings", name)
+	}
+	if !profileNameRE.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// ProfileExists reports whether a profile called name is configured.
+func ProfileExists(name string) bool {
+	return name == DefaultProfile || viper.IsSet(profilesKey+"."+name)
+}
+
+// ListProfiles returns the configured profile names, "default" first.
+func ListProfiles() []string {
+	names := make([]string, 0)
+	for name := range viper.GetStringMap(profilesKey) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...)
+}
+
+// GetProfile returns the effective settings of the named profile.
+func GetProfile(name string) Profile {
+	saved := activeProfile
+	defer func() { activeProfile = saved }()
+	activeProfile = name
+	if name == DefaultProfile {
+		activeProfile = ""
+	}
+	return Profile{
+		APIURL:         GetAPIURL(),
+		APIKey:         GetAPIKey(),
+		DefaultProject: GetDefaultProject(),
+		OutputFormat:   GetOutputFormat(),
+	}
+}
+
+// AddProfile creates a profile and saves it. An empty APIURL records the
+// top-level API URL.
+func AddProfile(name string, p Profile) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if ProfileExists(name) {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	if p.APIURL == "" {
+		p.APIURL = viper.GetString("api_url")
+	}
+	prefix := profilesKey + "." + name + "."
+	viper.Set(prefix+"api_url", p.APIURL)
+	for key, value := range map[string]string{
+		"api_key":         p.APIKey,
+		"default_project": p.DefaultProject,
+		"output_format":   p.OutputFormat,
+	} {
+		if value != "" {
+			viper.Set(prefix+key, value)
+		}
+	}
+	return Save()
+}
+
+// DeleteProfile removes a profile, and its OAuth tokens, from the config
+// file. If it was the current profile, the top-level settings become current.
+func DeleteProfile(name string) error {
+	// Viper cannot unset a key, so the file is rewritten without it and reloaded
+	settings := viper.AllSettings()
+	if profiles, ok := settings[profilesKey].(map[string]any); ok {
+		delete(profiles, name)
+	}
+	if viper.GetString("current_profile") == name {
+		delete(settings, "current_profile")
+	}
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	if err := writeConfig(data); err != nil {
+		return err
+	}
+	if activeProfile == name {
+		activeProfile = ""
+	}
+	viper.Reset()
+	return Load()
+}
+
+// isProfileKey reports whether key is kept per profile.
+func isProfileKey(key string) bool {
+	_, ok := profileKeys[key]
+	return ok || strings.HasPrefix(key, "auth.")
+}
+
+// inherits reports whether a profile falls back to the top-level key.
+func inherits(key string) bool {
+	return profileKeys[key]
+}
+
+// profileKey returns where key is stored for the active profile.
+func profileKey(key string) string {
+	if activeProfile == "" || !isProfileKey(key) {
+		return key
+	}
+	return profilesKey + "." + activeProfile + "." + key
+}
+
+// scoped reads a per-profile key. STOMPY_* environment variables win, as
+// they do over the config file; then the active profile's value; then, if
+// inherit is set, the top-level value.
+func scoped(key string, inherit bool) string {
+	if activeProfile == "" {
+		return viper.GetString(key)
+	}
+	if !strings.Contains(key, ".") {
+		if env := os.Getenv("STOMPY_" + strings.ToUpper(key)); env != "" {
+			return env
+		}
+	}
+	if v := viper.GetString(profileKey(key)); v != "" {
+		return v
+	}
+	if inherit {
+		return viper.GetString(key)
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestProfiles(t *testing.T) {
+	setupTestConfig(t)
+	t.Cleanup(func() { activeProfile = "" })
+
+	if err := Load(); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	SetValue("api_url", "https://top.example.com/api/v1")
+	SetValue("api_key", "top-key")
+	SetValue("default_project", "top-project")
+	if err := Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	if err := AddProfile("work", Profile{DefaultProject: "work-project"}); err != nil {
+		t.Fatalf("AddProfile() error: %v", err)
+	}
+	if err := AddProfile("work", Profile{}); err == nil {
+		t.Error("AddProfile() with an existing name should fail")
+	}
+	if err := AddProfile(DefaultProfile, Profile{}); err == nil {
+		t.Error("AddProfile(default) should fail")
+	}
+	if err := SetProfile("missing"); err == nil {
+		t.Error("SetProfile(missing) should fail")
+	}
+
+	if err := SetProfile("work"); err != nil {
+		t.Fatalf("SetProfile() error: %v", err)
+	}
+	if got := ActiveProfile(); got != "work" {
+		t.Errorf("ActiveProfile() = %q, want work", got)
+	}
+	if got := GetAPIURL(); got != "https://top.example.com/api/v1" {
+		t.Errorf("GetAPIURL() = %q, want the top-level URL recorded by AddProfile", got)
+	}
+	if got := GetDefaultProject(); got != "work-project" {
+		t.Errorf("GetDefaultProject() = %q, want work-project", got)
+	}
+	if got := GetAPIKey(); got != "" {
+		t.Errorf("GetAPIKey() = %q, want no fallback to the top-level key", got)
+	}
+	if got := GetOutputFormat(); got != defaultOutputFormat {
+		t.Errorf("GetOutputFormat() = %q, want inherited %q", got, defaultOutputFormat)
+	}
+
+	t.Setenv("STOMPY_DEFAULT_PROJECT", "env-project")
+	if got := GetDefaultProject(); got != "env-project" {
+		t.Errorf("GetDefaultProject() = %q, want env override", got)
+	}
+
+	// Tokens land in the profile and leave the top level alone
+	if err := SaveTokens("work-access", "work-refresh", time.Now().Add(time.Hour), "me@work.com", "u1"); err != nil {
+		t.Fatalf("SaveTokens() error: %v", err)
+	}
+	if got := viper.GetString("profiles.work.auth.access_token"); got != "work-access" {
+		t.Errorf("profile access token = %q", got)
+	}
+	if got := viper.GetString("auth.access_token"); got != "" {
+		t.Errorf("top-level access token = %q, want empty", got)
+	}
+
+	if got := ListProfiles(); len(got) != 2 || got[0] != DefaultProfile || got[1] != "work" {
+		t.Errorf("ListProfiles() = %v", got)
+	}
+
+	if err := UseProfile("work"); err != nil {
+		t.Fatalf("UseProfile() error: %v", err)
+	}
+	if err := DeleteProfile("work"); err != nil {
+		t.Fatalf("DeleteProfile() error: %v", err)
+	}
+	if ProfileExists("work") {
+		t.Error("profile still exists after DeleteProfile()")
+	}
+	if got := ActiveProfile(); got != DefaultProfile {
+		t.Errorf("ActiveProfile() after delete = %q, want default", got)
+	}
+	if got := CurrentProfile(); got != DefaultProfile {
+		t.Errorf("CurrentProfile() after delete = %q, want default", got)
+	}
+	if got := GetAPIKey(); got != "top-key" {
+		t.Errorf("top-level api_key after delete = %q, want top-key", got)
+	}
+}
@@ -0,0 +1,187 @@
+package fakestompy
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// AddLoginSession seeds an OAuth login session and returns its ID. The fake
+// server issues no tokens, so sessions only exist when seeded; mark the one
+// standing in for the caller with Current.
+func (s *Server) AddLoginSession(ls stompy.SessionResponse) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ls.ID == "" {
+		ls.ID = "ses_" + strconv.Itoa(s.newID())
+	}
+	if ls.ClientID == "" {
+		ls.ClientID = "stompy-cli"
+	}
+	if ls.CreatedAt.IsZero() {
+		ls.CreatedAt = time.Now().UTC()
+	}
+	s.logins[ls.ID] = &ls
+	return ls.ID
+}
+
+// RevokedTokens returns the tokens passed to /oauth/revoke, in order.
+func (s *Server) RevokedTokens() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.revoked)
+}
+
+func (s *Server) handleListLoginSessions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]stompy.SessionResponse, 0, len(s.logins))
+	for _, ls := range s.logins {
+		list = append(list, *ls)
+	}
+	slices.SortFunc(list, func(a, b stompy.SessionResponse) int { return strings.Compare(a.ID, b.ID) })
+	writeJSON(w, http.StatusOK, stompy.SessionListResponse{Sessions: list, Total: len(list)})
+}
+
+func (s *Server) handleRevokeLoginSession(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	if _, ok := s.logins[id]; !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	delete(s.logins, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRevokeAllLoginSessions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.logins)
+	clear(s.logins)
+	writeJSON(w, http.StatusOK, stompy.SessionRevokeResponse{Revoked: n})
+}
+
+// handleRevokeToken implements RFC 7009: any token is accepted and the
+// answer is always 200.
+func (s *Server) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("token") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	s.mu.Lock()
+	s.revoked = append(s.revoked, r.PostForm.Get("token"))
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]stompy.APIKeyResponse, 0, len(s.apiKeys))
+	for _, k := range s.apiKeys {
+		list = append(list, *k)
+	}
+	slices.SortFunc(list, func(a, b stompy.APIKeyResponse) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+	writeJSON(w, http.StatusOK, stompy.APIKeyListResponse{APIKeys: list, Total: len(list)})
+}
+
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req stompy.APIKeyCreate
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.apiKeys {
+		if k.Name == req.Name {
+			writeError(w, http.StatusConflict, "an API key with this name already exists")
+			return
+		}
+	}
+	if req.Scopes == nil {
+		req.Scopes = []string{}
+	}
+	k := &stompy.APIKeyResponse{
+		ID:        "key_" + strconv.Itoa(s.newID()),
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: req.ExpiresAt,
+	}
+	secret := newSecret()
+	k.Prefix = secret[:10]
+	s.apiKeys[k.ID] = k
+	writeJSON(w, http.StatusCreated, stompy.APIKeySecretResponse{APIKeyResponse: *k, Secret: secret})
+}
+
+func (s *Server) handleRotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.apiKeys[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	secret := newSecret()
+	k.Prefix = secret[:10]
+	writeJSON(w, http.StatusOK, stompy.APIKeySecretResponse{APIKeyResponse: *k, Secret: secret})
+}
+
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.PathValue("id")
+	if _, ok := s.apiKeys[id]; !ok {
+		writeError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	delete(s.apiKeys, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newSecret returns a random API key secret.
+func newSecret() string {
+	var b [24]byte
+	_, _ = rand.Read(b[:])
+	return "sk_" + hex.EncodeToString(b[:])
+}
+
+// handleMe describes a fixed demo user. The fake server does not track who a
+// token belongs to, so only the auth method reflects the request: tokens
+// shaped like the secrets it mints are API keys.
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	me := stompy.MeResponse{
+		UserID:     "usr_demo",
+		Email:      "demo@example.com",
+		Name:       "Demo User",
+		Org:        "demo-org",
+		Scopes:     []string{"projects:read", "projects:write", "tickets:write"},
+		AuthMethod: "oauth",
+	}
+	if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); strings.HasPrefix(token, "sk_") {
+		s.mu.Lock()
+		for _, k := range s.apiKeys {
+			if strings.HasPrefix(token, k.Prefix) {
+				me.APIKeyID, me.Scopes, me.ExpiresAt = k.ID, k.Scopes, k.ExpiresAt
+			}
+		}
+		s.mu.Unlock()
+		me.AuthMethod = "api_key"
+	}
+	writeJSON(w, http.StatusOK, me)
+}
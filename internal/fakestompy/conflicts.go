@@ -0,0 +1,184 @@
+package fakestompy
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+type conflictEntry struct {
+	stompy.ConflictResponse
+}
+
+type bugEntry struct {
+	stompy.BugReportResponse
+}
+
+// AddConflict seeds a conflict into project (created if missing) and returns
+// its ID. The fake server never detects conflicts on its own.
+func (s *Server) AddConflict(projectName string, c stompy.ConflictResponse) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.addProjectLocked(projectName, nil)
+	c.ID = s.newID()
+	if c.Status == "" {
+		c.Status = "pending"
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+	p.conflicts[c.ID] = &conflictEntry{c}
+	return c.ID
+}
+
+// AddBugReport seeds a bug report into project (created if missing) and
+// returns its ID. Bug reports are read-only through the API.
+func (s *Server) AddBugReport(projectName string, b stompy.BugReportResponse) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.addProjectLocked(projectName, nil)
+	b.ID = s.newID()
+	if b.Status == "" {
+		b.Status = "open"
+	}
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = time.Now().UTC()
+	}
+	if b.UpdatedAt.IsZero() {
+		b.UpdatedAt = b.CreatedAt
+	}
+	p.bugs[b.ID] = &bugEntry{b}
+	return b.ID
+}
+
+func (s *Server) handleListConflicts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	matched := []stompy.ConflictResponse{}
+	for _, id := range sortedIDs(p.conflicts) {
+		if c := p.conflicts[id]; status == "" || c.Status == status {
+			matched = append(matched, c.ConflictResponse)
+		}
+	}
+	writeJSON(w, http.StatusOK, stompy.ConflictListResponse{Conflicts: page(r, matched), Total: len(matched)})
+}
+
+func (s *Server) lookupConflict(w http.ResponseWriter, r *http.Request, p *project) (*conflictEntry, bool) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+	c, ok := p.conflicts[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "conflict not found")
+	}
+	return c, ok
+}
+
+func (s *Server) handleGetConflict(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	c, ok := s.lookupConflict(w, r, p)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, c.ConflictResponse)
+}
+
+func (s *Server) handleDetectConflicts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	found := 0
+	for _, c := range p.conflicts {
+		if c.Status == "pending" {
+			found++
+		}
+	}
+	writeJSON(w, http.StatusOK, stompy.ConflictDetectResponse{ConflictsFound: found, Scanned: len(p.contexts)})
+}
+
+func (s *Server) handleResolveConflict(w http.ResponseWriter, r *http.Request) {
+	var req stompy.ConflictResolveRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	switch req.Resolution {
+	case "dismiss", "keep_a", "keep_b", "merge":
+	default:
+		writeError(w, http.StatusUnprocessableEntity, "invalid resolution: "+req.Resolution)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	c, ok := s.lookupConflict(w, r, p)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	c.Status = "resolved"
+	if req.Resolution == "dismiss" {
+		c.Status = "dismissed"
+	}
+	c.Resolution = &req.Resolution
+	c.ResolvedAt = &now
+	writeJSON(w, http.StatusOK, c.ConflictResponse)
+}
+
+func (s *Server) handleListBugReports(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	matched := []stompy.BugReportResponse{}
+	for _, id := range sortedIDs(p.bugs) {
+		if b := p.bugs[id]; status == "" || b.Status == status {
+			matched = append(matched, b.BugReportResponse)
+		}
+	}
+	writeJSON(w, http.StatusOK, stompy.BugReportListResponse{BugReports: page(r, matched), Total: len(matched)})
+}
+
+func (s *Server) handleGetBugReport(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	b, ok := p.bugs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "bug report not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, b.BugReportResponse)
+}
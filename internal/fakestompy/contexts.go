@@ -0,0 +1,279 @@
+package fakestompy
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+const previewLen = 120
+
+// contextEntry is a topic with its full version history (oldest first).
+type contextEntry struct {
+	id          int
+	topic       string
+	priority    string
+	tags        []string
+	accessCount int
+	versions    []contextVersion
+}
+
+type contextVersion struct {
+	version   string
+	content   string
+	createdAt time.Time
+}
+
+func (c *contextEntry) latest() contextVersion {
+	return c.versions[len(c.versions)-1]
+}
+
+// addVersion appends a new version numbered 1.0, 1.1, 1.2, ...
+func (c *contextEntry) addVersion(content string) string {
+	v := fmt.Sprintf("1.%d", len(c.versions))
+	c.versions = append(c.versions, contextVersion{version: v, content: content, createdAt: time.Now().UTC()})
+	return v
+}
+
+func (c *contextEntry) response() stompy.ContextResponse {
+	latest := c.latest()
+	pv := preview(latest.content)
+	return stompy.ContextResponse{
+		ID:          c.id,
+		Topic:       c.topic,
+		Version:     latest.version,
+		Priority:    c.priority,
+		Tags:        c.tags,
+		Preview:     &pv,
+		LockedAt:    &latest.createdAt,
+		AccessCount: c.accessCount,
+	}
+}
+
+func (p *project) sortedTopics() []string {
+	topics := make([]string, 0, len(p.contexts))
+	for t := range p.contexts {
+		topics = append(topics, t)
+	}
+	slices.Sort(topics)
+	return topics
+}
+
+func splitTags(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func preview(s string) string {
+	if len(s) <= previewLen {
+		return s
+	}
+	return s[:previewLen] + "..."
+}
+
+func (s *Server) handleListContexts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	priority, tags, search := q.Get("priority"), splitTags(q.Get("tags")), q.Get("search")
+	var matched []stompy.ContextResponse
+	for _, topic := range p.sortedTopics() {
+		c := p.contexts[topic]
+		if priority != "" && c.priority != priority {
+			continue
+		}
+		if len(tags) > 0 && !slices.ContainsFunc(tags, func(t string) bool { return slices.Contains(c.tags, t) }) {
+			continue
+		}
+		if search != "" && !containsFold(topic, search) && !containsFold(c.latest().content, search) {
+			continue
+		}
+		matched = append(matched, c.response())
+	}
+
+	resp := stompy.ContextListResponse{Contexts: page(r, matched), Total: len(matched)}
+	if resp.Contexts == nil {
+		resp.Contexts = []stompy.ContextResponse{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleLockContext(w http.ResponseWriter, r *http.Request) {
+	var req stompy.ContextCreateRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Topic == "" || req.Content == "" {
+		writeError(w, http.StatusUnprocessableEntity, "topic and content are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	c, exists := p.contexts[req.Topic]
+	if !exists {
+		c = &contextEntry{id: s.newID(), topic: req.Topic, priority: "reference"}
+		p.contexts[req.Topic] = c
+	}
+	if req.Priority != "" {
+		c.priority = req.Priority
+	}
+	if req.Tags != "" {
+		c.tags = splitTags(req.Tags)
+	}
+	version := c.addVersion(req.Content)
+
+	writeJSON(w, http.StatusCreated, stompy.ContextCreateResponse{Status: "locked", Topic: req.Topic, Version: version})
+}
+
+func (s *Server) handleGetContext(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	c, ok := p.contexts[r.PathValue("topic")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "context not found")
+		return
+	}
+
+	v := c.latest()
+	if want := r.URL.Query().Get("version"); want != "" && want != "latest" {
+		i := slices.IndexFunc(c.versions, func(cv contextVersion) bool { return cv.version == want })
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "version not found")
+			return
+		}
+		v = c.versions[i]
+	}
+	c.accessCount++
+
+	resp := stompy.ContextDetailResponse{ContextResponse: c.response(), Content: v.content}
+	resp.Version = v.version
+	for _, cv := range c.versions {
+		created := cv.createdAt
+		resp.Versions = append(resp.Versions, stompy.VersionSummary{Version: cv.version, CreatedAt: &created})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleUpdateContext(w http.ResponseWriter, r *http.Request) {
+	var req stompy.ContextUpdateRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	c, ok := p.contexts[r.PathValue("topic")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "context not found")
+		return
+	}
+
+	if req.Content != "" && req.Content != c.latest().content {
+		c.addVersion(req.Content)
+	}
+	if req.Priority != "" {
+		c.priority = req.Priority
+	}
+	if req.Tags != "" {
+		c.tags = splitTags(req.Tags)
+	}
+	writeJSON(w, http.StatusOK, c.response())
+}
+
+func (s *Server) handleUnlockContext(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	topic := r.PathValue("topic")
+	c, ok := p.contexts[topic]
+	if !ok {
+		writeError(w, http.StatusNotFound, "context not found")
+		return
+	}
+
+	switch version := r.URL.Query().Get("version"); version {
+	case "", "all":
+		delete(p.contexts, topic)
+	case "latest":
+		c.versions = c.versions[:len(c.versions)-1]
+	default:
+		i := slices.IndexFunc(c.versions, func(cv contextVersion) bool { return cv.version == version })
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "version not found")
+			return
+		}
+		c.versions = slices.Delete(c.versions, i, i+1)
+	}
+	if len(c.versions) == 0 {
+		delete(p.contexts, topic)
+	}
+
+	archived := r.URL.Query().Get("no_archive") != "true"
+	writeJSON(w, http.StatusOK, stompy.ContextDeleteResponse{Status: "unlocked", Topic: topic, Archived: archived})
+}
+
+func (s *Server) handleMoveContext(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TargetProject string `json:"target_project"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	topic := r.PathValue("topic")
+	c, ok := p.contexts[topic]
+	if !ok {
+		writeError(w, http.StatusNotFound, "context not found")
+		return
+	}
+	target, ok := s.projects[req.TargetProject]
+	if !ok {
+		writeError(w, http.StatusNotFound, "target project not found")
+		return
+	}
+	if _, clash := target.contexts[topic]; clash {
+		writeError(w, http.StatusConflict, "target project already has this topic")
+		return
+	}
+
+	delete(p.contexts, topic)
+	target.contexts[topic] = c
+	writeJSON(w, http.StatusOK, stompy.ContextMoveResponse{Status: "moved", Topic: topic, TargetProject: target.name})
+}
@@ -0,0 +1,421 @@
+package fakestompy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+func newClients(t *testing.T, s *Server, token string) (*stompy.Client, *stompy.MCPClient) {
+	t.Helper()
+	srv, baseURL := NewTestServer(s)
+	t.Cleanup(srv.Close)
+	return stompy.NewClient(baseURL, stompy.WithToken(token)), stompy.NewMCPClient(stompy.MCPBaseURL(baseURL), stompy.WithToken(token))
+}
+
+func TestAuthRequired(t *testing.T) {
+	s := New()
+	s.Token = "secret"
+	c, _ := newClients(t, s, "wrong")
+
+	_, err := c.ListProjects(context.Background(), false)
+	var apiErr *stompy.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Fatalf("err = %v, want 401 APIError", err)
+	}
+}
+
+func TestProjectsAndContexts(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClients(t, New(), "")
+
+	if _, err := c.CreateProject(ctx, stompy.ProjectCreate{Name: "p"}); err != nil {
+		t.Fatalf("CreateProject() error: %v", err)
+	}
+	if _, err := c.CreateProject(ctx, stompy.ProjectCreate{Name: "p"}); err == nil {
+		t.Fatal("expected conflict creating duplicate project")
+	}
+
+	if _, err := c.LockContext(ctx, "p", stompy.ContextCreateRequest{Topic: "t", Content: "v1", Priority: "important"}); err != nil {
+		t.Fatalf("LockContext() error: %v", err)
+	}
+	created, err := c.LockContext(ctx, "p", stompy.ContextCreateRequest{Topic: "t", Content: "v2"})
+	if err != nil {
+		t.Fatalf("LockContext() error: %v", err)
+	}
+	if created.Version != "1.1" {
+		t.Errorf("Version = %q, want 1.1", created.Version)
+	}
+
+	detail, err := c.GetContext(ctx, "p", "t", "1.0")
+	if err != nil {
+		t.Fatalf("GetContext() error: %v", err)
+	}
+	if detail.Content != "v1" || len(detail.Versions) != 2 || detail.Priority != "important" {
+		t.Errorf("unexpected detail: %+v", detail)
+	}
+
+	if _, err := c.UnlockContext(ctx, "p", "t", "", false, false); err != nil {
+		t.Fatalf("UnlockContext() error: %v", err)
+	}
+	list, err := c.ListContexts(ctx, "p", "", "", 0, 0)
+	if err != nil {
+		t.Fatalf("ListContexts() error: %v", err)
+	}
+	if list.Total != 0 {
+		t.Errorf("Total = %d, want 0", list.Total)
+	}
+}
+
+func TestTicketWorkflow(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	s.AddProject("p")
+	c, _ := newClients(t, s, "")
+
+	bug, err := c.CreateTicket(ctx, "p", stompy.TicketCreate{Title: "Crash", Type: "bug", Priority: "high"})
+	if err != nil {
+		t.Fatalf("CreateTicket() error: %v", err)
+	}
+	if bug.Status != "triage" {
+		t.Errorf("Status = %q, want triage", bug.Status)
+	}
+
+	if _, err := c.TransitionTicket(ctx, "p", bug.ID, "shipped"); err == nil {
+		t.Error("expected error moving a bug to a feature status")
+	}
+	moved, err := c.TransitionTicket(ctx, "p", bug.ID, "resolved")
+	if err != nil {
+		t.Fatalf("TransitionTicket() error: %v", err)
+	}
+	if moved.ClosedAt == nil {
+		t.Error("ClosedAt not set after reaching terminal status")
+	}
+	if len(moved.History) != 2 || *moved.History[0].NewValue != "triage" {
+		t.Errorf("unexpected history: %+v", moved.History)
+	}
+
+	board, err := c.GetBoard(ctx, "p", "", "bug", "")
+	if err != nil {
+		t.Fatalf("GetBoard() error: %v", err)
+	}
+	if len(board.Columns) != 4 || board.Columns[3].Status != "resolved" || board.Columns[3].Count != 1 {
+		t.Errorf("unexpected board: %+v", board)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	s.AddProject("p")
+	c, _ := newClients(t, s, "")
+
+	for i := range 7 {
+		if _, err := c.CreateTicket(ctx, "p", stompy.TicketCreate{Title: strings.Repeat("x", i+1)}); err != nil {
+			t.Fatalf("CreateTicket() error: %v", err)
+		}
+	}
+
+	n := 0
+	for _, err := range c.AllTickets(ctx, "p", "", "", "", 3) {
+		if err != nil {
+			t.Fatalf("AllTickets() error: %v", err)
+		}
+		n++
+	}
+	if n != 7 {
+		t.Errorf("got %d tickets, want 7", n)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	s.AddProject("p")
+	c, _ := newClients(t, s, "")
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.UploadFile(ctx, "p", path, stompy.UploadOptions{Label: "docs", Metadata: map[string]string{"source": "test"}})
+	if err != nil {
+		t.Fatalf("UploadFile() error: %v", err)
+	}
+	if f.Filename != "notes.txt" || f.Label != "docs" || f.SizeBytes != 5 || f.Metadata["source"] != "test" {
+		t.Errorf("unexpected file: %+v", f)
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.DownloadFile(ctx, "p", f.ID, &buf); err != nil {
+		t.Fatalf("DownloadFile() error: %v", err)
+	}
+	if buf.String() != "hello" {
+		t.Errorf("downloaded %q, want hello", buf.String())
+	}
+
+	if err := c.DeleteFile(ctx, "p", f.ID); err != nil {
+		t.Fatalf("DeleteFile() error: %v", err)
+	}
+	if _, err := c.GetFile(ctx, "p", f.ID); err == nil {
+		t.Error("expected 404 after delete")
+	}
+}
+
+func TestUploadRetried(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	s.AddProject("p")
+	s.FailUploads = 1
+	c, _ := newClients(t, s, "")
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.UploadFile(ctx, "p", path, stompy.UploadOptions{})
+	if err != nil {
+		t.Fatalf("UploadFile() error: %v", err)
+	}
+	if s.FailUploads != 0 {
+		t.Errorf("FailUploads = %d, want the injected failure retried", s.FailUploads)
+	}
+	if n := len(s.projects["p"].files); n != 1 {
+		t.Errorf("server has %d files, want 1", n)
+	}
+	if got := s.projects["p"].files[f.ID].data; string(got) != "hello" {
+		t.Errorf("uploaded %q, want hello", got)
+	}
+}
+
+func TestResumableUpload(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	s.AddProject("p")
+	c, _ := newClients(t, s, "")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.bin")
+	data := []byte(strings.Repeat("0123456789abcdef", 4096)) // 64 KiB
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stateFile := filepath.Join(dir, "state.json")
+
+	// The first chunk fails once and is retried. Cancel once the second
+	// chunk is under way; the first stays on the server.
+	s.FailChunks = 1
+	cctx, cancel := context.WithCancel(ctx)
+	opts := stompy.UploadOptions{
+		Label:     "design",
+		ChunkSize: 16 << 10,
+		StateFile: stateFile,
+		Progress: func(sent, total int64) {
+			if sent > 20<<10 {
+				cancel()
+			}
+		},
+	}
+	if _, err := c.UploadFile(cctx, "p", path, opts); err == nil {
+		t.Fatal("expected the canceled upload to fail")
+	}
+	if _, err := os.Stat(stateFile); err != nil {
+		t.Fatalf("state file not kept after failure: %v", err)
+	}
+
+	if s.FailChunks != 0 {
+		t.Errorf("FailChunks = %d, want the injected failure retried", s.FailChunks)
+	}
+
+	// A rerun resumes from the server's offset. Depending on timing, the
+	// canceled second chunk may or may not have been stored.
+	var first int64 = -1
+	opts.Progress = func(sent, total int64) {
+		if first < 0 {
+			first = sent
+		}
+	}
+	f, err := c.UploadFile(ctx, "p", path, opts)
+	if err != nil {
+		t.Fatalf("resumed UploadFile() error: %v", err)
+	}
+	if first != 16<<10 && first != 32<<10 {
+		t.Errorf("resumed at byte %d, want a chunk boundary after the first chunk", first)
+	}
+	if f.SizeBytes != len(data) || f.Label != "design" {
+		t.Errorf("unexpected file: %+v", f)
+	}
+	if got := s.projects["p"].files[f.ID].data; string(got) != string(data) {
+		t.Error("uploaded content does not match the file")
+	}
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		t.Errorf("state file not removed after completion: %v", err)
+	}
+}
+
+func TestUploadFallsBackToMultipart(t *testing.T) {
+	s := New()
+	s.AddProject("p")
+	s.NoResumableUploads = true
+	c, _ := newClients(t, s, "")
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, make([]byte, 40<<10), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.UploadFile(context.Background(), "p", path, stompy.UploadOptions{ChunkSize: 16 << 10})
+	if err != nil {
+		t.Fatalf("UploadFile() error: %v", err)
+	}
+	if f.SizeBytes != 40<<10 {
+		t.Errorf("SizeBytes = %d, want %d", f.SizeBytes, 40<<10)
+	}
+}
+
+func TestMCPTools(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	s.Seed()
+	_, m := newClients(t, s, "")
+
+	var brief struct {
+		Project      string `json:"project"`
+		ContextCount int    `json:"context_count"`
+	}
+	if err := m.CallToolTyped(ctx, "project_brief", map[string]any{"project": DemoProject}, &brief); err != nil {
+		t.Fatalf("project_brief error: %v", err)
+	}
+	if brief.Project != DemoProject || brief.ContextCount != 3 {
+		t.Errorf("unexpected brief: %+v", brief)
+	}
+
+	var batch struct {
+		Results []struct {
+			Topic string `json:"topic"`
+			Found bool   `json:"found"`
+		} `json:"results"`
+	}
+	args := map[string]any{"project": DemoProject, "topics": []string{"architecture", "missing"}}
+	if err := m.CallToolTyped(ctx, "recall_batch", args, &batch); err != nil {
+		t.Fatalf("recall_batch error: %v", err)
+	}
+	if len(batch.Results) != 2 || !batch.Results[0].Found || batch.Results[1].Found {
+		t.Errorf("unexpected batch: %+v", batch)
+	}
+
+	if _, err := m.CallTool(ctx, "project_brief", map[string]any{"project": "nope"}); err == nil {
+		t.Error("expected tool error for unknown project")
+	}
+}
+
+func TestMCPSessionAndStreaming(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	s.Seed()
+	s.StreamMCP = true
+	_, m := newClients(t, s, "")
+
+	tools, err := m.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error: %v", err)
+	}
+	if len(tools) != len(mcpTools) {
+		t.Errorf("got %d tools, want %d", len(tools), len(mcpTools))
+	}
+
+	var explore struct {
+		Total int `json:"total"`
+	}
+	if err := m.CallToolTyped(ctx, "context_explore", map[string]any{"project": DemoProject}, &explore); err != nil {
+		t.Fatalf("context_explore error: %v", err)
+	}
+	if explore.Total != 3 {
+		t.Errorf("Total = %d, want 3", explore.Total)
+	}
+
+	if err := m.Close(ctx); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if len(s.sessions) != 0 {
+		t.Errorf("sessions = %v, want none after Close", s.sessions)
+	}
+}
+
+func TestLoginSessions(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	current := s.AddLoginSession(stompy.SessionResponse{DeviceName: "desk", Current: true})
+	lost := s.AddLoginSession(stompy.SessionResponse{DeviceName: "lost-laptop"})
+	c, _ := newClients(t, s, "")
+
+	list, err := c.ListSessions(ctx)
+	if err != nil {
+		t.Fatalf("ListSessions() error: %v", err)
+	}
+	if list.Total != 2 || !list.Sessions[0].Current || list.Sessions[0].ID != current {
+		t.Errorf("sessions = %+v, want 2 with %s current", list.Sessions, current)
+	}
+
+	if err := c.RevokeSession(ctx, lost); err != nil {
+		t.Fatalf("RevokeSession() error: %v", err)
+	}
+	if err := c.RevokeSession(ctx, lost); !errors.Is(err, stompy.ErrNotFound) {
+		t.Errorf("second RevokeSession() error = %v, want ErrNotFound", err)
+	}
+
+	revoked, err := c.RevokeAllSessions(ctx)
+	if err != nil {
+		t.Fatalf("RevokeAllSessions() error: %v", err)
+	}
+	if revoked.Revoked != 1 {
+		t.Errorf("Revoked = %d, want 1", revoked.Revoked)
+	}
+	if list, _ := c.ListSessions(ctx); list.Total != 0 {
+		t.Errorf("%d sessions left after RevokeAllSessions()", list.Total)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClients(t, New(), "")
+
+	created, err := c.CreateAPIKey(ctx, stompy.APIKeyCreate{Name: "ci", Scopes: []string{"tickets:write"}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error: %v", err)
+	}
+	if created.Secret == "" || !strings.HasPrefix(created.Secret, created.Prefix) {
+		t.Errorf("secret %q does not start with prefix %q", created.Secret, created.Prefix)
+	}
+	if _, err := c.CreateAPIKey(ctx, stompy.APIKeyCreate{Name: "ci"}); !errors.Is(err, stompy.ErrConflict) {
+		t.Errorf("duplicate CreateAPIKey() error = %v, want ErrConflict", err)
+	}
+
+	rotated, err := c.RotateAPIKey(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("RotateAPIKey() error: %v", err)
+	}
+	if rotated.Secret == created.Secret || rotated.Name != "ci" {
+		t.Errorf("rotated = %+v, want a new secret for the same key", rotated)
+	}
+
+	list, err := c.ListAPIKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListAPIKeys() error: %v", err)
+	}
+	if list.Total != 1 || list.APIKeys[0].Prefix != rotated.Prefix {
+		t.Errorf("keys = %+v, want the rotated key", list.APIKeys)
+	}
+
+	if err := c.RevokeAPIKey(ctx, created.ID); err != nil {
+		t.Fatalf("RevokeAPIKey() error: %v", err)
+	}
+	if _, err := c.RotateAPIKey(ctx, created.ID); !errors.Is(err, stompy.ErrNotFound) {
+		t.Errorf("RotateAPIKey() after revoke error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,164 @@
+package fakestompy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// maxUploadBytes caps the size of a single fake upload.
+const maxUploadBytes = 32 << 20
+
+type fileEntry struct {
+	stompy.FileResponse
+	data []byte
+}
+
+func (s *Server) handleListFiles(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	search := r.URL.Query().Get("search")
+	matched := []stompy.FileResponse{}
+	for _, id := range sortedIDs(p.files) {
+		f := p.files[id]
+		if search == "" || containsFold(f.Filename, search) || containsFold(f.Label, search) {
+			matched = append(matched, f.FileResponse)
+		}
+	}
+	writeJSON(w, http.StatusOK, stompy.FileListResponse{Files: page(r, matched), Total: len(matched)})
+}
+
+func (s *Server) handleUploadFile(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid multipart body: "+err.Error())
+		return
+	}
+	part, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "file field is required")
+		return
+	}
+	defer part.Close()
+	data, err := io.ReadAll(part)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "reading upload: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	if s.FailUploads > 0 {
+		s.FailUploads--
+		writeError(w, http.StatusServiceUnavailable, "injected upload failure")
+		return
+	}
+
+	var metadata map[string]string
+	if raw := r.FormValue("metadata"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "metadata must be a JSON object of strings")
+			return
+		}
+	}
+
+	filename := r.FormValue("filename")
+	if filename == "" {
+		filename = header.Filename
+	}
+	f := s.addFileLocked(p, filename, r.FormValue("label"), metadata, data)
+	writeJSON(w, http.StatusCreated, f.FileResponse)
+}
+
+// addFileLocked stores an uploaded file in p. Callers hold s.mu.
+func (s *Server) addFileLocked(p *project, filename, label string, metadata map[string]string, data []byte) *fileEntry {
+	f := &fileEntry{
+		FileResponse: stompy.FileResponse{
+			ID:        s.newID(),
+			Filename:  filename,
+			Label:     label,
+			Metadata:  metadata,
+			MimeType:  http.DetectContentType(data),
+			SizeBytes: len(data),
+			CreatedAt: time.Now().UTC(),
+		},
+		data: data,
+	}
+	p.files[f.ID] = f
+	return f
+}
+
+func (s *Server) lookupFile(w http.ResponseWriter, r *http.Request, p *project) (*fileEntry, bool) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+	f, ok := p.files[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "file not found")
+	}
+	return f, ok
+}
+
+func (s *Server) handleGetFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	f, ok := s.lookupFile(w, r, p)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, f.FileResponse)
+}
+
+func (s *Server) handleDownloadFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	f, ok := s.lookupFile(w, r, p)
+	if !ok {
+		return
+	}
+	sum := sha256.Sum256(f.data)
+	w.Header().Set("Content-Type", f.MimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(f.data)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.Filename}))
+	w.Header().Set("X-Checksum-SHA256", hex.EncodeToString(sum[:]))
+	w.Write(f.data) //nolint:errcheck
+}
+
+func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	f, ok := s.lookupFile(w, r, p)
+	if !ok {
+		return
+	}
+	delete(p.files, f.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,378 @@
+package fakestompy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// mcpProtocolVersions are the MCP protocol revisions the fake server speaks,
+// newest first.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26"}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// mcpTool describes a tool in tools/list.
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// toolHandler runs a tool against p and returns the value to encode as the
+// tool's text content. Callers hold s.mu.
+type toolHandler func(p *project, args map[string]any) (any, error)
+
+func objectSchema(required []string, props map[string]any) map[string]any {
+	return map[string]any{"type": "object", "properties": props, "required": required}
+}
+
+var (
+	projectProp = map[string]any{"type": "string", "description": "Project name"}
+	boolProp    = map[string]any{"type": "boolean"}
+	stringProp  = map[string]any{"type": "string"}
+)
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "context_explore",
+		Description: "Browse contexts grouped by priority",
+		InputSchema: objectSchema([]string{"project"}, map[string]any{
+			"project": projectProp, "grep": stringProp, "verbose": boolProp,
+		}),
+	},
+	{
+		Name:        "context_dashboard",
+		Description: "Context statistics and health",
+		InputSchema: objectSchema([]string{"project"}, map[string]any{
+			"project": projectProp, "detail": stringProp,
+		}),
+	},
+	{
+		Name:        "recall_batch",
+		Description: "Fetch several contexts in one call",
+		InputSchema: objectSchema([]string{"project", "topics"}, map[string]any{
+			"project":      projectProp,
+			"topics":       map[string]any{"type": "array", "items": stringProp},
+			"preview_only": boolProp,
+		}),
+	},
+	{
+		Name:        "project_brief",
+		Description: "Project overview",
+		InputSchema: objectSchema([]string{"project"}, map[string]any{
+			"project": projectProp, "refresh": boolProp,
+		}),
+	},
+}
+
+var toolHandlers = map[string]toolHandler{
+	"context_explore":   toolContextExplore,
+	"context_dashboard": toolContextDashboard,
+	"recall_batch":      toolRecallBatch,
+	"project_brief":     toolProjectBrief,
+}
+
+// handleMCP serves the Streamable HTTP MCP endpoint. Sessions are created by
+// initialize; requests naming an unknown session get 404 so clients
+// renegotiate.
+func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRPCError(w, nil, rpcParseError, "parse error: "+err.Error())
+		return
+	}
+
+	if sid := r.Header.Get("Mcp-Session-Id"); sid != "" && req.Method != "initialize" {
+		s.mu.Lock()
+		live := s.sessions[sid]
+		s.mu.Unlock()
+		if !live {
+			writeError(w, http.StatusNotFound, "unknown MCP session")
+			return
+		}
+	}
+
+	// Notifications carry no id and get no response body.
+	if len(req.ID) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if s.StreamMCP {
+		w = &sseWriter{ResponseWriter: w}
+	}
+
+	switch req.Method {
+	case "initialize":
+		s.handleInitialize(w, req)
+	case "ping":
+		writeRPCResult(w, req.ID, map[string]any{})
+	case "tools/list":
+		writeRPCResult(w, req.ID, map[string]any{"tools": mcpTools})
+	case "tools/call":
+		s.handleToolCall(w, req)
+	default:
+		writeRPCError(w, req.ID, rpcMethodNotFound, "Method not found: "+req.Method)
+	}
+}
+
+func (s *Server) handleToolCall(w http.ResponseWriter, req rpcRequest) {
+	var params struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		writeRPCError(w, req.ID, rpcInvalidParams, "invalid params: "+err.Error())
+		return
+	}
+	handler, ok := toolHandlers[params.Name]
+	if !ok {
+		writeRPCError(w, req.ID, rpcInvalidParams, "unknown tool: "+params.Name)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Tool failures are reported in the result with isError, per the MCP spec.
+	name, _ := params.Arguments["project"].(string)
+	p, ok := s.projects[name]
+	if !ok {
+		writeToolResult(w, req.ID, fmt.Sprintf("Tool error: project %q not found", name), true)
+		return
+	}
+	out, err := handler(p, params.Arguments)
+	if err != nil {
+		writeToolResult(w, req.ID, "Tool error: "+err.Error(), true)
+		return
+	}
+	text, err := json.Marshal(out)
+	if err != nil {
+		writeToolResult(w, req.ID, "Tool error: "+err.Error(), true)
+		return
+	}
+	writeToolResult(w, req.ID, string(text), false)
+}
+
+func (s *Server) handleInitialize(w http.ResponseWriter, req rpcRequest) {
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	json.Unmarshal(req.Params, &params) //nolint:errcheck
+	version := mcpProtocolVersions[0]
+	if slices.Contains(mcpProtocolVersions, params.ProtocolVersion) {
+		version = params.ProtocolVersion
+	}
+
+	s.mu.Lock()
+	sid := fmt.Sprintf("fake-session-%d", s.newID())
+	s.sessions[sid] = true
+	s.mu.Unlock()
+
+	w.Header().Set("Mcp-Session-Id", sid)
+	writeRPCResult(w, req.ID, map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{"tools": map[string]any{}},
+		"serverInfo":      map[string]any{"name": "fakestompy", "version": APIVersion},
+	})
+}
+
+func (s *Server) handleEndSession(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sid := r.Header.Get("Mcp-Session-Id")
+	if !s.sessions[sid] {
+		writeError(w, http.StatusNotFound, "unknown MCP session")
+		return
+	}
+	delete(s.sessions, sid)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sseWriter re-frames a JSON response body as a single server-sent event.
+type sseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *sseWriter) WriteHeader(status int) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sseWriter) Write(b []byte) (int, error) {
+	if _, err := fmt.Fprintf(w.ResponseWriter, "event: message\ndata: %s\n\n", bytes.TrimSpace(b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func writeRPCResult(w http.ResponseWriter, id json.RawMessage, result any) {
+	writeJSON(w, http.StatusOK, map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+func writeRPCError(w http.ResponseWriter, id json.RawMessage, code int, message string) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"jsonrpc": "2.0", "id": id, "error": rpcError{Code: code, Message: message}})
+}
+
+func writeToolResult(w http.ResponseWriter, id json.RawMessage, text string, isError bool) {
+	writeRPCResult(w, id, map[string]any{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	})
+}
+
+// priorityOrder ranks context priorities for explore and brief output.
+var priorityOrder = []string{"always_check", "important", "reference"}
+
+func priorityRank(p string) int {
+	if i := slices.Index(priorityOrder, p); i >= 0 {
+		return i
+	}
+	return len(priorityOrder)
+}
+
+// topicsByPriority returns p's topics ordered by priority, then name.
+func (p *project) topicsByPriority() []string {
+	topics := p.sortedTopics()
+	slices.SortStableFunc(topics, func(a, b string) int {
+		return priorityRank(p.contexts[a].priority) - priorityRank(p.contexts[b].priority)
+	})
+	return topics
+}
+
+func toolContextExplore(p *project, args map[string]any) (any, error) {
+	grep, _ := args["grep"].(string)
+	type item struct {
+		Topic       string `json:"topic"`
+		Priority    string `json:"priority"`
+		Version     string `json:"version"`
+		Tags        string `json:"tags,omitempty"`
+		Preview     string `json:"preview,omitempty"`
+		AccessCount int    `json:"access_count"`
+	}
+	items := []item{}
+	for _, topic := range p.topicsByPriority() {
+		c := p.contexts[topic]
+		latest := c.latest()
+		if grep != "" && !containsFold(topic, grep) && !containsFold(latest.content, grep) {
+			continue
+		}
+		items = append(items, item{
+			Topic:       topic,
+			Priority:    c.priority,
+			Version:     latest.version,
+			Tags:        strings.Join(c.tags, ","),
+			Preview:     preview(latest.content),
+			AccessCount: c.accessCount,
+		})
+	}
+	return map[string]any{"project": p.name, "contexts": items, "total": len(items)}, nil
+}
+
+// staleAfter is how long a context can go without a new version before the
+// dashboard counts it as stale.
+const staleAfter = 30 * 24 * time.Hour
+
+func toolContextDashboard(p *project, _ map[string]any) (any, error) {
+	byPriority := map[string]int{}
+	stale := 0
+	topics := p.sortedTopics()
+	for _, topic := range topics {
+		c := p.contexts[topic]
+		byPriority[c.priority]++
+		if time.Since(c.latest().createdAt) > staleAfter {
+			stale++
+		}
+	}
+	slices.SortStableFunc(topics, func(a, b string) int {
+		return p.contexts[b].latest().createdAt.Compare(p.contexts[a].latest().createdAt)
+	})
+	if len(topics) > 5 {
+		topics = topics[:5]
+	}
+	return map[string]any{
+		"project":        p.name,
+		"total_contexts": len(p.contexts),
+		"by_priority":    byPriority,
+		"recent_topics":  topics,
+		"stale_count":    stale,
+	}, nil
+}
+
+func toolRecallBatch(p *project, args map[string]any) (any, error) {
+	rawTopics, ok := args["topics"].([]any)
+	if !ok {
+		return nil, fmt.Errorf("topics must be an array of strings")
+	}
+	previewOnly, _ := args["preview_only"].(bool)
+
+	results := []map[string]any{}
+	for _, raw := range rawTopics {
+		topic, _ := raw.(string)
+		c, ok := p.contexts[topic]
+		if !ok {
+			results = append(results, map[string]any{"topic": topic, "found": false, "error": "not found"})
+			continue
+		}
+		latest := c.latest()
+		res := map[string]any{"topic": topic, "found": true, "version": latest.version}
+		if previewOnly {
+			res["preview"] = preview(latest.content)
+		} else {
+			res["content"] = latest.content
+		}
+		results = append(results, res)
+	}
+	return map[string]any{"results": results}, nil
+}
+
+func toolProjectBrief(p *project, _ map[string]any) (any, error) {
+	type topTopic struct {
+		Topic    string `json:"topic"`
+		Priority string `json:"priority"`
+	}
+	top := []topTopic{}
+	for _, topic := range p.topicsByPriority() {
+		if len(top) == 5 {
+			break
+		}
+		top = append(top, topTopic{Topic: topic, Priority: p.contexts[topic].priority})
+	}
+
+	summary := fmt.Sprintf("%s has %d contexts and %d tickets.", p.name, len(p.contexts), len(p.tickets))
+	if p.description != nil {
+		summary = *p.description + " " + summary
+	}
+	return map[string]any{
+		"project":       p.name,
+		"summary":       summary,
+		"context_count": len(p.contexts),
+		"top_topics":    top,
+		"generated_at":  time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,130 @@
+package fakestompy
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+func (p *project) response(withStats bool) stompy.ProjectResponse {
+	resp := stompy.ProjectResponse{
+		Name:        p.name,
+		SchemaName:  "proj_" + p.name,
+		CreatedAt:   p.createdAt,
+		Role:        "owner",
+		Description: p.description,
+	}
+	if withStats {
+		var bytes int
+		for _, f := range p.files {
+			bytes += len(f.data)
+		}
+		resp.Stats = &stompy.ProjectStats{
+			ContextCount:   len(p.contexts),
+			FileCount:      len(p.files),
+			StorageBytesS3: bytes,
+		}
+	}
+	return resp
+}
+
+func (s *Server) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	withStats := r.URL.Query().Get("stats") == "true"
+	names := make([]string, 0, len(s.projects))
+	for name := range s.projects {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	resp := stompy.ProjectListResponse{Projects: []stompy.ProjectResponse{}, Total: len(names)}
+	for _, name := range names {
+		resp.Projects = append(resp.Projects, s.projects[name].response(withStats))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleCreateProject(w http.ResponseWriter, r *http.Request) {
+	var req stompy.ProjectCreate
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.projects[req.Name]; exists {
+		writeError(w, http.StatusConflict, "project already exists")
+		return
+	}
+	p := s.addProjectLocked(req.Name, req.Description)
+	writeJSON(w, http.StatusCreated, p.response(false))
+}
+
+func (s *Server) handleGetProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, p.response(r.URL.Query().Get("stats") == "true"))
+}
+
+func (s *Server) handleDeleteProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	if r.URL.Query().Get("confirm") != "true" {
+		writeError(w, http.StatusBadRequest, "confirm=true is required")
+		return
+	}
+	delete(s.projects, p.name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSearch performs a plain substring search across contexts, tickets and files.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query().Get("q")
+	results := []stompy.SearchResult{}
+	for _, topic := range p.sortedTopics() {
+		c := p.contexts[topic]
+		latest := c.latest()
+		if containsFold(topic, q) || containsFold(latest.content, q) {
+			results = append(results, stompy.SearchResult{
+				ID: c.id, Topic: topic, Type: "context", Preview: preview(latest.content), Score: 1, Priority: c.priority,
+			})
+		}
+	}
+	for _, id := range sortedIDs(p.tickets) {
+		t := p.tickets[id]
+		if containsFold(t.Title, q) || (t.Description != nil && containsFold(*t.Description, q)) {
+			results = append(results, stompy.SearchResult{ID: id, Topic: t.Title, Type: "ticket", Score: 1, Priority: t.Priority})
+		}
+	}
+	for _, id := range sortedIDs(p.files) {
+		f := p.files[id]
+		if containsFold(f.Filename, q) || containsFold(f.Label, q) {
+			results = append(results, stompy.SearchResult{ID: id, Topic: f.Filename, Type: "file", Preview: f.Label, Score: 1})
+		}
+	}
+
+	total := len(results)
+	writeJSON(w, http.StatusOK, stompy.SearchResponse{Results: page(r, results), Total: total, Query: q})
+}
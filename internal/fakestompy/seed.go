@@ -0,0 +1,60 @@
+package fakestompy
+
+import (
+	"time"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// DemoProject is the project created by Seed.
+const DemoProject = "demo"
+
+// Seed populates s with a small demo project: a few contexts across
+// priorities, tickets of each type, a pending conflict and a bug report,
+// plus two login sessions.
+func (s *Server) Seed() {
+	s.mu.Lock()
+	desc := "Demo project served by the fake Stompy API."
+	p := s.addProjectLocked(DemoProject, &desc)
+
+	for _, c := range []struct{ topic, priority, tags, content string }{
+		{"architecture", "always_check", "design", "Services talk over HTTP; the CLI is a thin client over the REST API."},
+		{"coding-standards", "important", "style,go", "Wrap errors with %w. Keep commands thin; logic lives in pkg/stompy."},
+		{"release-process", "reference", "ops", "Tag vX.Y.Z on main; goreleaser publishes binaries and the Homebrew tap."},
+	} {
+		entry := &contextEntry{id: s.newID(), topic: c.topic, priority: c.priority, tags: splitTags(c.tags)}
+		entry.addVersion(c.content)
+		p.contexts[c.topic] = entry
+	}
+
+	for i, t := range []struct{ title, typ, priority string }{
+		{"Add pagination to list commands", "feature", "high"},
+		{"Upload fails for files over 10MB", "bug", "urgent"},
+		{"Write release notes", "task", "low"},
+		{"Pick a credential storage backend", "decision", "medium"},
+	} {
+		now := unixNow()
+		entry := &ticketEntry{stompy.TicketResponse{
+			ID: s.newID(), Title: t.title, Type: t.typ, Priority: t.priority,
+			Status: workflows[t.typ][i%2], CreatedAt: &now,
+		}}
+		entry.record("created", "", nil, &entry.Status)
+		p.tickets[entry.ID] = entry
+	}
+	s.mu.Unlock()
+
+	s.AddConflict(DemoProject, stompy.ConflictResponse{
+		ContextATopic: "coding-standards", ContextBTopic: "architecture",
+		ContextAVersion: "1.0", ContextBVersion: "1.0",
+		ConflictType: "contradiction", Severity: "medium",
+		Description: "Disagreement about where business logic should live.",
+	})
+	s.AddBugReport(DemoProject, stompy.BugReportResponse{
+		Title: "Dashboard shows stale counts", Description: "Counts lag behind after locking a context.",
+		Severity: "low",
+	})
+
+	lastUsed := time.Now().UTC().Add(-72 * time.Hour)
+	s.AddLoginSession(stompy.SessionResponse{DeviceName: "workstation", IPAddress: "127.0.0.1", UserAgent: "stompy-cli", Current: true})
+	s.AddLoginSession(stompy.SessionResponse{DeviceName: "old-laptop", IPAddress: "203.0.113.7", UserAgent: "stompy-cli", LastUsedAt: &lastUsed})
+}
@@ -0,0 +1,257 @@
+// Package fakestompy is an in-memory implementation of the Stompy REST and MCP
+// APIs. It backs integration tests, offline demos and `stompy dev fake-server`.
+package fakestompy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// APIVersion is reported in the X-Stompy-API-Version header of every response.
+const APIVersion = "6.0.0-fake"
+
+// Server is an in-memory Stompy API. The zero value is not usable; call New.
+type Server struct {
+	// Token, if set, must be presented as a Bearer token on every request.
+	Token string
+
+	// StreamMCP makes the MCP endpoint answer requests with a
+	// text/event-stream instead of a single JSON body.
+	StreamMCP bool
+
+	// NoResumableUploads hides the resumable upload endpoints, as on servers
+	// that only accept single multipart uploads.
+	NoResumableUploads bool
+
+	// FailChunks makes the next FailChunks resumable upload chunks fail with
+	// 503 before any of their bytes are stored.
+	FailChunks int
+
+	// FailUploads makes the next FailUploads single multipart uploads fail
+	// with 503 before the file is stored.
+	FailUploads int
+
+	mu       sync.Mutex
+	nextID   int
+	projects map[string]*project
+	sessions map[string]bool                    // live MCP session IDs
+	uploads  map[string]*uploadEntry            // open resumable uploads by ID
+	logins   map[string]*stompy.SessionResponse // OAuth login sessions by ID
+	revoked  []string                           // tokens sent to /oauth/revoke
+	apiKeys  map[string]*stompy.APIKeyResponse  // API keys by ID
+	mux      *http.ServeMux
+}
+
+// project holds all resources that live inside one Stompy project.
+type project struct {
+	name        string
+	description *string
+	createdAt   time.Time
+	contexts    map[string]*contextEntry
+	tickets     map[int]*ticketEntry
+	files       map[int]*fileEntry
+	conflicts   map[int]*conflictEntry
+	bugs        map[int]*bugEntry
+}
+
+// New returns an empty fake server.
+func New() *Server {
+	s := &Server{
+		projects: make(map[string]*project),
+		sessions: make(map[string]bool),
+		uploads:  make(map[string]*uploadEntry),
+		logins:   make(map[string]*stompy.SessionResponse),
+		apiKeys:  make(map[string]*stompy.APIKeyResponse),
+	}
+	s.mux = http.NewServeMux()
+	s.routes()
+	return s
+}
+
+// NewTestServer starts s on a local port and returns the running server and
+// the REST base URL to pass to stompy.NewClient (".../api/v1").
+func NewTestServer(s *Server) (*httptest.Server, string) {
+	srv := httptest.NewServer(s)
+	return srv, srv.URL + "/api/v1"
+}
+
+func (s *Server) routes() {
+	const p = "/api/v1/projects/{project}"
+
+	s.mux.HandleFunc("GET /api/v1/health", s.handleHealth)
+
+	s.mux.HandleFunc("GET /api/v1/me", s.handleMe)
+	s.mux.HandleFunc("GET /api/v1/auth/sessions", s.handleListLoginSessions)
+	s.mux.HandleFunc("DELETE /api/v1/auth/sessions", s.handleRevokeAllLoginSessions)
+	s.mux.HandleFunc("DELETE /api/v1/auth/sessions/{id}", s.handleRevokeLoginSession)
+	s.mux.HandleFunc("POST /oauth/revoke", s.handleRevokeToken)
+	s.mux.HandleFunc("GET /api/v1/api-keys", s.handleListAPIKeys)
+	s.mux.HandleFunc("POST /api/v1/api-keys", s.handleCreateAPIKey)
+	s.mux.HandleFunc("POST /api/v1/api-keys/{id}/rotate", s.handleRotateAPIKey)
+	s.mux.HandleFunc("DELETE /api/v1/api-keys/{id}", s.handleRevokeAPIKey)
+
+	s.mux.HandleFunc("GET /api/v1/projects", s.handleListProjects)
+	s.mux.HandleFunc("POST /api/v1/projects", s.handleCreateProject)
+	s.mux.HandleFunc("GET "+p, s.handleGetProject)
+	s.mux.HandleFunc("DELETE "+p, s.handleDeleteProject)
+	s.mux.HandleFunc("GET "+p+"/search", s.handleSearch)
+
+	s.mux.HandleFunc("GET "+p+"/contexts", s.handleListContexts)
+	s.mux.HandleFunc("POST "+p+"/contexts", s.handleLockContext)
+	s.mux.HandleFunc("GET "+p+"/contexts/{topic}", s.handleGetContext)
+	s.mux.HandleFunc("PUT "+p+"/contexts/{topic}", s.handleUpdateContext)
+	s.mux.HandleFunc("DELETE "+p+"/contexts/{topic}", s.handleUnlockContext)
+	s.mux.HandleFunc("POST "+p+"/contexts/{topic}/move", s.handleMoveContext)
+
+	s.mux.HandleFunc("GET "+p+"/tickets", s.handleListTickets)
+	s.mux.HandleFunc("POST "+p+"/tickets", s.handleCreateTicket)
+	s.mux.HandleFunc("GET "+p+"/tickets/search", s.handleSearchTickets)
+	s.mux.HandleFunc("GET "+p+"/tickets/board", s.handleBoard)
+	s.mux.HandleFunc("GET "+p+"/tickets/{id}", s.handleGetTicket)
+	s.mux.HandleFunc("PUT "+p+"/tickets/{id}", s.handleUpdateTicket)
+	s.mux.HandleFunc("POST "+p+"/tickets/{id}/move", s.handleTransitionTicket)
+	s.mux.HandleFunc("GET "+p+"/tickets/{id}/links", s.handleListLinks)
+	s.mux.HandleFunc("POST "+p+"/tickets/{id}/links", s.handleAddLink)
+	s.mux.HandleFunc("DELETE "+p+"/tickets/{id}/links/{linkID}", s.handleRemoveLink)
+
+	s.mux.HandleFunc("GET "+p+"/files", s.handleListFiles)
+	s.mux.HandleFunc("POST "+p+"/files", s.handleUploadFile)
+	s.mux.HandleFunc("GET "+p+"/files/{id}", s.handleGetFile)
+	s.mux.HandleFunc("GET "+p+"/files/{id}/content", s.handleDownloadFile)
+	s.mux.HandleFunc("DELETE "+p+"/files/{id}", s.handleDeleteFile)
+	s.mux.HandleFunc("POST "+p+"/uploads", s.handleCreateUpload)
+	s.mux.HandleFunc("GET "+p+"/uploads/{uploadID}", s.handleGetUpload)
+	s.mux.HandleFunc("PUT "+p+"/uploads/{uploadID}", s.handleUploadChunk)
+	s.mux.HandleFunc("POST "+p+"/uploads/{uploadID}/complete", s.handleCompleteUpload)
+
+	s.mux.HandleFunc("GET "+p+"/conflicts", s.handleListConflicts)
+	s.mux.HandleFunc("POST "+p+"/conflicts/detect", s.handleDetectConflicts)
+	s.mux.HandleFunc("GET "+p+"/conflicts/{id}", s.handleGetConflict)
+	s.mux.HandleFunc("POST "+p+"/conflicts/{id}/resolve", s.handleResolveConflict)
+
+	s.mux.HandleFunc("GET "+p+"/bug-reports", s.handleListBugReports)
+	s.mux.HandleFunc("GET "+p+"/bug-reports/{id}", s.handleGetBugReport)
+
+	s.mux.HandleFunc("POST /mcp", s.handleMCP)
+	s.mux.HandleFunc("DELETE /mcp", s.handleEndSession)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Stompy-API-Version", APIVersion)
+	if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeError(w, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": APIVersion})
+}
+
+// AddProject creates an empty project if it does not already exist.
+func (s *Server) AddProject(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addProjectLocked(name, nil)
+}
+
+func (s *Server) addProjectLocked(name string, description *string) *project {
+	if p, ok := s.projects[name]; ok {
+		return p
+	}
+	p := &project{
+		name:        name,
+		description: description,
+		createdAt:   time.Now().UTC(),
+		contexts:    make(map[string]*contextEntry),
+		tickets:     make(map[int]*ticketEntry),
+		files:       make(map[int]*fileEntry),
+		conflicts:   make(map[int]*conflictEntry),
+		bugs:        make(map[int]*bugEntry),
+	}
+	s.projects[name] = p
+	return p
+}
+
+// lookupProject resolves the {project} path value, writing a 404 if it is unknown.
+// Callers must hold s.mu.
+func (s *Server) lookupProject(w http.ResponseWriter, r *http.Request) (*project, bool) {
+	p, ok := s.projects[r.PathValue("project")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "project not found")
+	}
+	return p, ok
+}
+
+func (s *Server) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"status_code": status, "message": message})
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// pathID parses an integer path value, writing a 404 if it is malformed.
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		writeError(w, http.StatusNotFound, name+" not found")
+		return 0, false
+	}
+	return id, true
+}
+
+// page applies the limit/offset query parameters to items.
+func page[T any](r *http.Request, items []T) []T {
+	q := r.URL.Query()
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	offset = min(max(offset, 0), len(items))
+	items = items[offset:]
+	if limit, _ := strconv.Atoi(q.Get("limit")); limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// sortedIDs returns the keys of m in ascending order.
+func sortedIDs[V any](m map[int]V) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func unixNow() float64 {
+	return float64(time.Now().UnixNano()) / 1e9
+}
@@ -0,0 +1,369 @@
+package fakestompy
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// workflows lists the statuses of each ticket type in board order. The first
+// status is the initial one; the last is terminal.
+var workflows = map[string][]string{
+	"task":     {"backlog", "todo", "in_progress", "done"},
+	"bug":      {"triage", "confirmed", "in_progress", "resolved"},
+	"feature":  {"proposed", "approved", "in_progress", "shipped"},
+	"decision": {"draft", "under_review", "decided"},
+}
+
+type ticketEntry struct {
+	stompy.TicketResponse
+}
+
+// record appends a history entry. Values are copied so later edits to the
+// ticket do not rewrite its history.
+func (t *ticketEntry) record(action, field string, oldValue, newValue *string) {
+	now := unixNow()
+	t.UpdatedAt = &now
+	t.History = append(t.History, stompy.TicketHistory{
+		Action: action, Field: field, OldValue: clone(oldValue), NewValue: clone(newValue), Timestamp: now,
+	})
+}
+
+func clone(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
+
+// summary is the list/board representation, without history and links.
+func (t *ticketEntry) summary() stompy.TicketResponse {
+	resp := t.TicketResponse
+	resp.History, resp.Links = nil, nil
+	return resp
+}
+
+func (t *ticketEntry) matches(ticketType, status, priority string) bool {
+	return (ticketType == "" || t.Type == ticketType) &&
+		(status == "" || t.Status == status) &&
+		(priority == "" || t.Priority == priority)
+}
+
+func (s *Server) lookupTicket(w http.ResponseWriter, r *http.Request, p *project) (*ticketEntry, bool) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+	t, ok := p.tickets[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ticket not found")
+	}
+	return t, ok
+}
+
+func (s *Server) handleListTickets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	matched := []stompy.TicketResponse{}
+	for _, id := range sortedIDs(p.tickets) {
+		if t := p.tickets[id]; t.matches(q.Get("type"), q.Get("status"), q.Get("priority")) {
+			matched = append(matched, t.summary())
+		}
+	}
+	writeJSON(w, http.StatusOK, stompy.TicketListResponse{Tickets: page(r, matched), Total: len(matched)})
+}
+
+func (s *Server) handleCreateTicket(w http.ResponseWriter, r *http.Request) {
+	var req stompy.TicketCreate
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Type == "" {
+		req.Type = "task"
+	}
+	if req.Priority == "" {
+		req.Priority = "medium"
+	}
+	workflow, ok := workflows[req.Type]
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, "unknown ticket type: "+req.Type)
+		return
+	}
+	if req.Title == "" {
+		writeError(w, http.StatusUnprocessableEntity, "title is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	now := unixNow()
+	t := &ticketEntry{stompy.TicketResponse{
+		ID:          s.newID(),
+		Title:       req.Title,
+		Description: req.Description,
+		Type:        req.Type,
+		Status:      workflow[0],
+		Priority:    req.Priority,
+		Assignee:    req.Assignee,
+		Tags:        req.Tags,
+		CreatedAt:   &now,
+	}}
+	t.record("created", "", nil, &t.Status)
+	p.tickets[t.ID] = t
+	writeJSON(w, http.StatusCreated, t.TicketResponse)
+}
+
+func (s *Server) handleGetTicket(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	t, ok := s.lookupTicket(w, r, p)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, t.TicketResponse)
+}
+
+func (s *Server) handleUpdateTicket(w http.ResponseWriter, r *http.Request) {
+	var req stompy.TicketUpdate
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	t, ok := s.lookupTicket(w, r, p)
+	if !ok {
+		return
+	}
+
+	if req.Title != nil {
+		old := t.Title
+		t.Title = *req.Title
+		t.record("updated", "title", &old, req.Title)
+	}
+	if req.Description != nil {
+		t.record("updated", "description", t.Description, req.Description)
+		t.Description = req.Description
+	}
+	if req.Priority != nil {
+		old := t.Priority
+		t.Priority = *req.Priority
+		t.record("updated", "priority", &old, req.Priority)
+	}
+	if req.Assignee != nil {
+		t.record("updated", "assignee", t.Assignee, req.Assignee)
+		t.Assignee = req.Assignee
+	}
+	if req.Tags != nil {
+		t.Tags = req.Tags
+		t.record("updated", "tags", nil, nil)
+	}
+	writeJSON(w, http.StatusOK, t.TicketResponse)
+}
+
+func (s *Server) handleTransitionTicket(w http.ResponseWriter, r *http.Request) {
+	var req stompy.TransitionRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	t, ok := s.lookupTicket(w, r, p)
+	if !ok {
+		return
+	}
+
+	workflow := workflows[t.Type]
+	if !slices.Contains(workflow, req.Status) {
+		writeError(w, http.StatusUnprocessableEntity, "invalid status "+req.Status+" for "+t.Type+" ticket")
+		return
+	}
+
+	old := t.Status
+	t.Status = req.Status
+	t.record("transitioned", "status", &old, &t.Status)
+	if req.Status == workflow[len(workflow)-1] {
+		t.ClosedAt = t.UpdatedAt
+	} else {
+		t.ClosedAt = nil
+	}
+	writeJSON(w, http.StatusOK, t.TicketResponse)
+}
+
+func (s *Server) handleSearchTickets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	query := q.Get("query")
+	results := []stompy.TicketResponse{}
+	for _, id := range sortedIDs(p.tickets) {
+		t := p.tickets[id]
+		if !t.matches(q.Get("type"), q.Get("status"), "") {
+			continue
+		}
+		if containsFold(t.Title, query) || (t.Description != nil && containsFold(*t.Description, query)) {
+			results = append(results, t.summary())
+		}
+	}
+	total := len(results)
+	writeJSON(w, http.StatusOK, stompy.TicketSearchResponse{Results: page(r, results), Total: total, Query: query})
+}
+
+func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	ticketType, status := q.Get("type"), q.Get("status")
+	summaryOnly := q.Get("view") == "summary"
+
+	// Columns follow workflow order; statuses shared between types (in_progress)
+	// appear once, at their first position.
+	var order []string
+	for _, typ := range []string{"task", "bug", "feature", "decision"} {
+		if ticketType != "" && typ != ticketType {
+			continue
+		}
+		for _, st := range workflows[typ] {
+			if !slices.Contains(order, st) && (status == "" || st == status) {
+				order = append(order, st)
+			}
+		}
+	}
+
+	board := stompy.BoardView{Columns: []stompy.BoardColumn{}}
+	for _, st := range order {
+		col := stompy.BoardColumn{Status: st, Tickets: []stompy.TicketResponse{}}
+		for _, id := range sortedIDs(p.tickets) {
+			if t := p.tickets[id]; t.matches(ticketType, st, "") {
+				col.Count++
+				if !summaryOnly {
+					col.Tickets = append(col.Tickets, t.summary())
+				}
+			}
+		}
+		board.Total += col.Count
+		board.Columns = append(board.Columns, col)
+	}
+	writeJSON(w, http.StatusOK, board)
+}
+
+func (s *Server) handleListLinks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	t, ok := s.lookupTicket(w, r, p)
+	if !ok {
+		return
+	}
+
+	links := []stompy.TicketLinkResp{}
+	for _, l := range t.Links {
+		if target, ok := p.tickets[l.TargetID]; ok {
+			l.TargetTitle, l.TargetStatus = target.Title, target.Status
+		}
+		links = append(links, l)
+	}
+	writeJSON(w, http.StatusOK, links)
+}
+
+func (s *Server) handleAddLink(w http.ResponseWriter, r *http.Request) {
+	var req stompy.LinkCreate
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	t, ok := s.lookupTicket(w, r, p)
+	if !ok {
+		return
+	}
+	target, ok := p.tickets[req.TargetID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "target ticket not found")
+		return
+	}
+	if req.TargetID == t.ID {
+		writeError(w, http.StatusUnprocessableEntity, "a ticket cannot link to itself")
+		return
+	}
+
+	link := stompy.TicketLinkResp{
+		ID:           s.newID(),
+		SourceID:     t.ID,
+		TargetID:     target.ID,
+		LinkType:     req.LinkType,
+		TargetTitle:  target.Title,
+		TargetStatus: target.Status,
+	}
+	t.Links = append(t.Links, link)
+	writeJSON(w, http.StatusCreated, link)
+}
+
+func (s *Server) handleRemoveLink(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	t, ok := s.lookupTicket(w, r, p)
+	if !ok {
+		return
+	}
+	linkID, ok := pathID(w, r, "linkID")
+	if !ok {
+		return
+	}
+
+	i := slices.IndexFunc(t.Links, func(l stompy.TicketLinkResp) bool { return l.ID == linkID })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "link not found")
+		return
+	}
+	t.Links = slices.Delete(t.Links, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,131 @@
+package fakestompy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// uploadChunkSize is the chunk size the fake server suggests to clients.
+const uploadChunkSize = 1 << 20
+
+// uploadEntry is an open resumable upload.
+type uploadEntry struct {
+	id      string
+	project string
+	req     stompy.UploadSessionRequest
+	data    []byte
+}
+
+func (u *uploadEntry) session() stompy.UploadSession {
+	return stompy.UploadSession{ID: u.id, Offset: int64(len(u.data)), ChunkSize: uploadChunkSize}
+}
+
+func (s *Server) handleCreateUpload(w http.ResponseWriter, r *http.Request) {
+	if s.NoResumableUploads {
+		http.NotFound(w, r)
+		return
+	}
+	var req stompy.UploadSessionRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Filename == "" || req.SizeBytes < 0 || req.SizeBytes > maxUploadBytes {
+		writeError(w, http.StatusUnprocessableEntity, "filename and a size_bytes within limits are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return
+	}
+	u := &uploadEntry{id: fmt.Sprintf("up-%d", s.newID()), project: p.name, req: req}
+	s.uploads[u.id] = u
+	writeJSON(w, http.StatusCreated, u.session())
+}
+
+// lookupUpload finds the upload named in the path. Callers hold s.mu.
+func (s *Server) lookupUpload(w http.ResponseWriter, r *http.Request) (*project, *uploadEntry, bool) {
+	if s.NoResumableUploads {
+		http.NotFound(w, r)
+		return nil, nil, false
+	}
+	p, ok := s.lookupProject(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	u, ok := s.uploads[r.PathValue("uploadID")]
+	if !ok || u.project != p.name {
+		writeError(w, http.StatusNotFound, "upload not found")
+		return nil, nil, false
+	}
+	return p, u, true
+}
+
+func (s *Server) handleGetUpload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, u, ok := s.lookupUpload(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, u.session())
+}
+
+func (s *Server) handleUploadChunk(w http.ResponseWriter, r *http.Request) {
+	var start, end, total int64
+	if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total); err != nil || end < start {
+		writeError(w, http.StatusBadRequest, "Content-Range: bytes start-end/total is required")
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, end-start+2))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "reading chunk: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, u, ok := s.lookupUpload(w, r)
+	if !ok {
+		return
+	}
+	if s.FailChunks > 0 {
+		s.FailChunks--
+		writeError(w, http.StatusServiceUnavailable, "injected chunk failure")
+		return
+	}
+	switch {
+	case total != u.req.SizeBytes || end >= total:
+		writeError(w, http.StatusBadRequest, "Content-Range does not match the upload size")
+		return
+	case int64(len(data)) != end-start+1:
+		writeError(w, http.StatusBadRequest, "chunk length does not match Content-Range")
+		return
+	case start != int64(len(u.data)):
+		writeError(w, http.StatusConflict, fmt.Sprintf("chunk starts at %d, expected %d", start, len(u.data)))
+		return
+	}
+	u.data = append(u.data, data...)
+	writeJSON(w, http.StatusOK, u.session())
+}
+
+func (s *Server) handleCompleteUpload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, u, ok := s.lookupUpload(w, r)
+	if !ok {
+		return
+	}
+	if int64(len(u.data)) != u.req.SizeBytes {
+		writeError(w, http.StatusConflict, fmt.Sprintf("upload has %d of %d bytes", len(u.data), u.req.SizeBytes))
+		return
+	}
+	delete(s.uploads, u.id)
+	f := s.addFileLocked(p, u.req.Filename, u.req.Label, u.req.Metadata, u.data)
+	writeJSON(w, http.StatusCreated, f.FileResponse)
+}
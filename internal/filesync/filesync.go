@@ -0,0 +1,473 @@
+// Package filesync uploads a directory tree to a Stompy project, skipping
+// files whose content is already there and optionally deleting remote copies
+// of files removed locally. What has been uploaded is tracked in a local
+// manifest keyed by path relative to the tree's root.
+package filesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// Client is the subset of stompy.Client used to sync files.
+type Client interface {
+	UploadFile(ctx context.Context, project, filePath string, opts stompy.UploadOptions) (*stompy.FileResponse, error)
+	DeleteFile(ctx context.Context, project string, id int) error
+	AllFiles(ctx context.Context, project, search string, pageSize int) iter.Seq2[stompy.FileResponse, error]
+}
+
+// Manifest records the files uploaded from one directory tree.
+type Manifest struct {
+	Files map[string]Entry `json:"files"` // keyed by slash-separated relative path
+
+	// Superseded holds previous versions of changed files, keyed like Files,
+	// that a new upload replaced but that have not been deleted yet. A later
+	// sync with Delete removes them.
+	Superseded map[string][]Entry `json:"superseded,omitempty"`
+}
+
+// Entry is one uploaded file. Size and ModTime let an unchanged file skip
+// rehashing on the next run.
+type Entry struct {
+	FileID  int       `json:"file_id"`
+	SHA256  string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// LoadManifest reads a manifest, returning an empty one if path does not exist.
+func LoadManifest(path string) (*Manifest, error) {
+	m := &Manifest{Files: map[string]Entry{}, Superseded: map[string][]Entry{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", path, err)
+	}
+	if m.Files == nil {
+		m.Files = map[string]Entry{}
+	}
+	if m.Superseded == nil {
+		m.Superseded = map[string][]Entry{}
+	}
+	return m, nil
+}
+
+// Save writes the manifest to path, creating its directory if needed.
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("saving manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("saving manifest: %w", err)
+	}
+	return nil
+}
+
+// Options controls which files are synced and how.
+type Options struct {
+	Recursive bool     // descend into subdirectories
+	Include   []string // glob patterns a file must match (any); all files if empty
+	Exclude   []string // glob patterns that exclude a file
+
+	Label    string
+	Metadata map[string]string
+
+	Concurrency int   // parallel uploads; 1 if <= 0
+	ChunkSize   int64 // see stompy.UploadOptions.ChunkSize
+
+	// Sync finds remote copies of files removed locally, and previous
+	// versions of files that changed. Unless Delete is also set they are
+	// only reported, as dry-run results. Only files recorded in the manifest
+	// are ever deleted.
+	Sync   bool
+	Delete bool
+	// DryRun plans the sync without uploading or deleting anything.
+	DryRun bool
+
+	// StateFile returns where a large file's resumable upload state is kept;
+	// see stompy.UploadOptions.StateFile. Optional.
+	StateFile func(path string) string
+}
+
+// Action is what a sync does with one file.
+type Action string
+
+const (
+	ActionUpload  Action = "upload"
+	ActionReplace Action = "replace" // upload a changed file
+	ActionSkip    Action = "skip"    // already uploaded with the same content
+	ActionDelete  Action = "delete"  // removed locally
+	ActionFailed  Action = "failed"
+)
+
+// Result reports what happened to one file.
+type Result struct {
+	Path   string // relative, slash-separated
+	Action Action
+	FileID int
+	Size   int64
+	DryRun bool // Action was planned but not carried out
+	// Previous marks the deletion of a superseded version of Path rather
+	// than of the file itself.
+	Previous bool
+	Err      error
+}
+
+// localFile is a file found under the root.
+type localFile struct {
+	rel    string
+	abs    string
+	info   fs.FileInfo
+	sha256 string // filled in while planning
+}
+
+// Run syncs root into project, updating m, and reports each file to report
+// (which may be nil) as it is handled. Failures of individual files are
+// reported and counted, not returned; the error is only for failures that
+// stop the whole sync.
+func Run(ctx context.Context, c Client, project, root string, m *Manifest, opts Options, report func(Result)) error {
+	if report == nil {
+		report = func(Result) {}
+	}
+	if m.Superseded == nil {
+		m.Superseded = map[string][]Entry{}
+	}
+
+	files, err := scan(root, opts)
+	if err != nil {
+		return err
+	}
+
+	remote := map[int]bool{}
+	for f, err := range c.AllFiles(ctx, project, "", stompy.DefaultPageSize) {
+		if err != nil {
+			return err
+		}
+		remote[f.ID] = true
+	}
+
+	var (
+		mu         sync.Mutex // guards m.Files while uploads run
+		uploads    []localFile
+		superseded = map[string]int{} // rel path → file ID replaced by a new upload
+	)
+	seen := map[string]bool{}
+	for _, f := range files {
+		seen[f.rel] = true
+		e, tracked := m.Files[f.rel]
+		sum, err := fileHash(f, e, tracked)
+		if err != nil {
+			report(Result{Path: f.rel, Action: ActionFailed, Size: f.info.Size(), Err: err})
+			continue
+		}
+		f.sha256 = sum
+		if tracked && remote[e.FileID] {
+			if e.SHA256 == sum {
+				// Refresh the stat shortcut even when skipping
+				m.Files[f.rel] = Entry{FileID: e.FileID, SHA256: sum, Size: f.info.Size(), ModTime: f.info.ModTime()}
+				report(Result{Path: f.rel, Action: ActionSkip, FileID: e.FileID, Size: f.info.Size()})
+				continue
+			}
+			superseded[f.rel] = e.FileID
+		}
+		uploads = append(uploads, f)
+	}
+
+	// Deletion candidates: tracked files that are gone locally but would
+	// have been picked up by this run's filters.
+	var deletions []string
+	for rel, e := range m.Files {
+		if seen[rel] || !inScope(rel, opts) {
+			continue
+		}
+		if !remote[e.FileID] {
+			delete(m.Files, rel) // already gone remotely
+			continue
+		}
+		deletions = append(deletions, rel)
+	}
+	slices.Sort(deletions)
+
+	// Forget previous versions that are already gone remotely
+	for rel, entries := range m.Superseded {
+		entries = slices.DeleteFunc(entries, func(e Entry) bool { return !remote[e.FileID] })
+		if len(entries) == 0 {
+			delete(m.Superseded, rel)
+		} else {
+			m.Superseded[rel] = entries
+		}
+	}
+
+	if opts.DryRun {
+		for _, f := range uploads {
+			action := ActionUpload
+			_, replaced := superseded[f.rel]
+			if replaced {
+				action = ActionReplace
+			}
+			report(Result{Path: f.rel, Action: action, Size: f.info.Size(), DryRun: true})
+			if replaced && opts.Sync {
+				old := m.Files[f.rel]
+				report(Result{Path: f.rel, Action: ActionDelete, FileID: old.FileID, Size: old.Size, DryRun: true, Previous: true})
+			}
+		}
+		if opts.Sync {
+			for _, rel := range deletions {
+				e := m.Files[rel]
+				report(Result{Path: rel, Action: ActionDelete, FileID: e.FileID, Size: e.Size, DryRun: true})
+			}
+			for _, rel := range supersededPaths(m, opts) {
+				for _, e := range m.Superseded[rel] {
+					report(Result{Path: rel, Action: ActionDelete, FileID: e.FileID, Size: e.Size, DryRun: true, Previous: true})
+				}
+			}
+		}
+		return nil
+	}
+
+	concurrency := max(opts.Concurrency, 1)
+	jobs := make(chan localFile)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				res := upload(ctx, c, project, f, opts)
+				mu.Lock()
+				old := m.Files[f.rel]
+				oldID, replaced := superseded[f.rel]
+				if res.Err == nil {
+					m.Files[f.rel] = Entry{FileID: res.FileID, SHA256: f.sha256, Size: f.info.Size(), ModTime: f.info.ModTime()}
+				}
+				mu.Unlock()
+				if replaced {
+					res.Action = ActionReplace
+				}
+				if res.Err == nil && replaced {
+					keep := !opts.Sync || !opts.Delete
+					if !keep {
+						if err := c.DeleteFile(ctx, project, oldID); err != nil {
+							res.Err = fmt.Errorf("uploaded as file %d, but deleting previous version %d failed: %w", res.FileID, oldID, err)
+							res.Action = ActionFailed
+							keep = true
+						}
+					}
+					// Kept versions are deleted by a later sync
+					if keep {
+						mu.Lock()
+						m.Superseded[f.rel] = append(m.Superseded[f.rel], old)
+						mu.Unlock()
+					}
+				}
+				report(res)
+			}
+		}()
+	}
+	for _, f := range uploads {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if opts.Sync {
+		for _, rel := range deletions {
+			e := m.Files[rel]
+			if !opts.Delete {
+				report(Result{Path: rel, Action: ActionDelete, FileID: e.FileID, Size: e.Size, DryRun: true})
+				continue
+			}
+			if err := c.DeleteFile(ctx, project, e.FileID); err != nil {
+				report(Result{Path: rel, Action: ActionFailed, FileID: e.FileID, Size: e.Size, Err: err})
+				continue
+			}
+			delete(m.Files, rel)
+			report(Result{Path: rel, Action: ActionDelete, FileID: e.FileID, Size: e.Size})
+		}
+		// Previous versions, including those this run replaced but kept
+		for _, rel := range supersededPaths(m, opts) {
+			var kept []Entry
+			for _, e := range m.Superseded[rel] {
+				if !opts.Delete {
+					report(Result{Path: rel, Action: ActionDelete, FileID: e.FileID, Size: e.Size, DryRun: true, Previous: true})
+					kept = append(kept, e)
+					continue
+				}
+				if err := c.DeleteFile(ctx, project, e.FileID); err != nil {
+					report(Result{Path: rel, Action: ActionFailed, FileID: e.FileID, Size: e.Size, Previous: true, Err: err})
+					kept = append(kept, e)
+					continue
+				}
+				report(Result{Path: rel, Action: ActionDelete, FileID: e.FileID, Size: e.Size, Previous: true})
+			}
+			if len(kept) == 0 {
+				delete(m.Superseded, rel)
+			} else {
+				m.Superseded[rel] = kept
+			}
+		}
+	}
+	return nil
+}
+
+// supersededPaths returns the sorted paths with previous versions recorded in
+// m that are within the scope of opts.
+func supersededPaths(m *Manifest, opts Options) []string {
+	var paths []string
+	for rel := range m.Superseded {
+		if inScope(rel, opts) {
+			paths = append(paths, rel)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// upload sends one file under its relative path.
+func upload(ctx context.Context, c Client, project string, f localFile, opts Options) Result {
+	res := Result{Path: f.rel, Action: ActionUpload, Size: f.info.Size()}
+	uo := stompy.UploadOptions{Filename: f.rel, Label: opts.Label, Metadata: opts.Metadata, ChunkSize: opts.ChunkSize}
+	if opts.StateFile != nil {
+		uo.StateFile = opts.StateFile(f.abs)
+	}
+	resp, err := c.UploadFile(ctx, project, f.abs, uo)
+	if err != nil {
+		res.Action, res.Err = ActionFailed, err
+		return res
+	}
+	res.FileID = resp.ID
+	return res
+}
+
+// scan lists the files under root selected by opts, sorted by path. Hidden
+// files and directories are skipped.
+func scan(root string, opts Options) ([]localFile, error) {
+	var files []localFile
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if !opts.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !inScope(rel, opts) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, localFile{rel: rel, abs: p, info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// inScope reports whether a relative path is selected by the recursion and
+// include/exclude settings. Patterns match the base name or the whole path.
+func inScope(rel string, opts Options) bool {
+	if !opts.Recursive && strings.Contains(rel, "/") {
+		return false
+	}
+	matches := func(patterns []string) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, path.Base(rel)); ok {
+				return true
+			}
+			if ok, _ := path.Match(p, rel); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if len(opts.Include) > 0 && !matches(opts.Include) {
+		return false
+	}
+	return !matches(opts.Exclude)
+}
+
+// ValidatePatterns reports the first malformed glob pattern.
+func ValidatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("%w: bad pattern %q: %w", stompy.ErrValidation, p, err)
+		}
+	}
+	return nil
+}
+
+// fileHash returns the file's SHA-256, reusing the manifest's when the file's
+// size and modification time are unchanged.
+func fileHash(f localFile, e Entry, tracked bool) (string, error) {
+	if tracked && e.SHA256 != "" && e.Size == f.info.Size() && e.ModTime.Equal(f.info.ModTime()) {
+		return e.SHA256, nil
+	}
+	return hashFile(f.abs)
+}
+
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing %s: %w", p, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
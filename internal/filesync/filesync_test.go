@@ -0,0 +1,272 @@
+package filesync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/fakestompy"
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+func newClient(t *testing.T) *stompy.Client {
+	t.Helper()
+	s := fakestompy.New()
+	s.Seed()
+	srv, baseURL := fakestompy.NewTestServer(s)
+	t.Cleanup(srv.Close)
+	return stompy.NewClient(baseURL)
+}
+
+func writeFile(t *testing.T, root, rel, content string, mtime time.Time) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// run syncs and returns the reported actions keyed by path, with previous
+// versions under "<path>@previous".
+func run(t *testing.T, c *stompy.Client, root string, m *Manifest, opts Options) map[string]Result {
+	t.Helper()
+	var mu sync.Mutex // report is called from the upload workers
+	got := map[string]Result{}
+	err := Run(context.Background(), c, "demo", root, m, opts, func(r Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Path, r.Err)
+		}
+		key := r.Path
+		if r.Previous {
+			key += "@previous"
+		}
+		got[key] = r
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return got
+}
+
+func remoteNames(t *testing.T, c *stompy.Client) []string {
+	t.Helper()
+	var names []string
+	for f, err := range c.AllFiles(context.Background(), "demo", "", 0) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Filename)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestRun_SkipsUnchangedAndReplacesChanged(t *testing.T) {
+	c := newClient(t)
+	root := t.TempDir()
+	t0 := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeFile(t, root, "a.pdf", "alpha", t0)
+	writeFile(t, root, "sub/b.pdf", "bravo", t0)
+	writeFile(t, root, "sub/c.txt", "charlie", t0)
+	writeFile(t, root, ".hidden.pdf", "secret", t0)
+
+	m := &Manifest{Files: map[string]Entry{}}
+	opts := Options{Recursive: true, Include: []string{"*.pdf"}, Concurrency: 4}
+
+	got := run(t, c, root, m, opts)
+	if len(got) != 2 || got["a.pdf"].Action != ActionUpload || got["sub/b.pdf"].Action != ActionUpload {
+		t.Fatalf("first run = %+v, want a.pdf and sub/b.pdf uploaded", got)
+	}
+	if names := remoteNames(t, c); !slices.Equal(names, []string{"a.pdf", "sub/b.pdf"}) {
+		t.Fatalf("remote files = %v", names)
+	}
+
+	got = run(t, c, root, m, opts)
+	for _, p := range []string{"a.pdf", "sub/b.pdf"} {
+		if got[p].Action != ActionSkip {
+			t.Errorf("second run %s = %q, want skip", p, got[p].Action)
+		}
+	}
+
+	// Same size, new mtime: must be rehashed and found changed
+	writeFile(t, root, "a.pdf", "ALPHA", t0.Add(time.Minute))
+	oldID := m.Files["a.pdf"].FileID
+	got = run(t, c, root, m, opts)
+	if got["a.pdf"].Action != ActionReplace || got["a.pdf"].FileID == oldID {
+		t.Fatalf("changed a.pdf = %+v, want replaced with a new ID", got["a.pdf"])
+	}
+	if got["sub/b.pdf"].Action != ActionSkip {
+		t.Errorf("sub/b.pdf = %q, want skip", got["sub/b.pdf"].Action)
+	}
+	// Without Sync the previous version is kept
+	if names := remoteNames(t, c); len(names) != 3 {
+		t.Errorf("remote files = %v, want the old a.pdf kept", names)
+	}
+}
+
+func TestRun_SyncDeletes(t *testing.T) {
+	c := newClient(t)
+	root := t.TempDir()
+	t0 := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeFile(t, root, "keep.md", "keep", t0)
+	writeFile(t, root, "gone.md", "gone", t0)
+
+	m := &Manifest{Files: map[string]Entry{}}
+	run(t, c, root, m, Options{})
+	if err := os.Remove(filepath.Join(root, "gone.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sync without Delete only plans the deletion
+	got := run(t, c, root, m, Options{Sync: true})
+	if r := got["gone.md"]; r.Action != ActionDelete || !r.DryRun {
+		t.Fatalf("gone.md = %+v, want planned delete", r)
+	}
+	if names := remoteNames(t, c); len(names) != 2 {
+		t.Fatalf("remote files = %v, want nothing deleted yet", names)
+	}
+
+	got = run(t, c, root, m, Options{Sync: true, Delete: true})
+	if r := got["gone.md"]; r.Action != ActionDelete || r.DryRun {
+		t.Fatalf("gone.md = %+v, want deleted", r)
+	}
+	if names := remoteNames(t, c); !slices.Equal(names, []string{"keep.md"}) {
+		t.Fatalf("remote files = %v, want [keep.md]", names)
+	}
+	if _, ok := m.Files["gone.md"]; ok {
+		t.Error("gone.md still in manifest")
+	}
+
+	// A changed file replaces its previous version
+	writeFile(t, root, "keep.md", "kept", t0.Add(time.Minute))
+	run(t, c, root, m, Options{Sync: true, Delete: true})
+	if names := remoteNames(t, c); !slices.Equal(names, []string{"keep.md"}) {
+		t.Fatalf("remote files = %v, want only the new keep.md", names)
+	}
+}
+
+func TestRun_ReplaceWithoutConfirmKeepsPreviousForLaterSync(t *testing.T) {
+	c := newClient(t)
+	root := t.TempDir()
+	t0 := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeFile(t, root, "a.md", "one", t0)
+
+	m := &Manifest{Files: map[string]Entry{}}
+	run(t, c, root, m, Options{})
+	firstID := m.Files["a.md"].FileID
+
+	// Replaced by a plain upload: the old version is remembered, not lost
+	writeFile(t, root, "a.md", "two", t0.Add(time.Minute))
+	run(t, c, root, m, Options{})
+	if prev := m.Superseded["a.md"]; len(prev) != 1 || prev[0].FileID != firstID {
+		t.Fatalf("Superseded = %+v, want file %d", m.Superseded, firstID)
+	}
+
+	// Replaced again under --sync without --confirm: both are listed
+	writeFile(t, root, "a.md", "three", t0.Add(2*time.Minute))
+	secondID := m.Files["a.md"].FileID
+	var planned []int
+	err := Run(context.Background(), c, "demo", root, m, Options{Sync: true}, func(r Result) {
+		if r.Previous && r.Action == ActionDelete && r.DryRun {
+			planned = append(planned, r.FileID)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(planned)
+	if !slices.Equal(planned, []int{firstID, secondID}) {
+		t.Fatalf("planned deletions = %v, want [%d %d]", planned, firstID, secondID)
+	}
+	if names := remoteNames(t, c); len(names) != 3 {
+		t.Fatalf("remote files = %v, want all three versions kept", names)
+	}
+
+	// The manifest survives a save and load, and --confirm deletes them
+	p := filepath.Join(t.TempDir(), "m.json")
+	if err := m.Save(p); err != nil {
+		t.Fatal(err)
+	}
+	m, err = LoadManifest(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := run(t, c, root, m, Options{Sync: true, Delete: true})
+	if got["a.md"].Action != ActionSkip {
+		t.Errorf("a.md = %q, want skip", got["a.md"].Action)
+	}
+	if r := got["a.md@previous"]; r.Action != ActionDelete || r.DryRun {
+		t.Errorf("previous a.md = %+v, want deleted", r)
+	}
+	if names := remoteNames(t, c); !slices.Equal(names, []string{"a.md"}) {
+		t.Errorf("remote files = %v, want only the current a.md", names)
+	}
+	if len(m.Superseded) != 0 {
+		t.Errorf("Superseded = %+v, want empty after deletion", m.Superseded)
+	}
+}
+
+func TestRun_DryRun(t *testing.T) {
+	c := newClient(t)
+	root := t.TempDir()
+	writeFile(t, root, "a.txt", "a", time.Now())
+
+	m := &Manifest{Files: map[string]Entry{}}
+	got := run(t, c, root, m, Options{DryRun: true})
+	if r := got["a.txt"]; r.Action != ActionUpload || !r.DryRun {
+		t.Fatalf("a.txt = %+v, want planned upload", r)
+	}
+	if names := remoteNames(t, c); len(names) != 0 {
+		t.Errorf("dry run uploaded %v", names)
+	}
+	if len(m.Files) != 0 {
+		t.Errorf("dry run recorded %v in manifest", m.Files)
+	}
+}
+
+func TestManifest_RoundTrip(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "manifests", "m.json")
+	m, err := LoadManifest(p)
+	if err != nil || len(m.Files) != 0 {
+		t.Fatalf("LoadManifest(missing) = %v, %v", m, err)
+	}
+	m.Files["a"] = Entry{FileID: 3, SHA256: "abc", Size: 1, ModTime: time.Unix(100, 0).UTC()}
+	if err := m.Save(p); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadManifest(p)
+	if err != nil || got.Files["a"] != m.Files["a"] {
+		t.Fatalf("LoadManifest = %+v, %v", got, err)
+	}
+}
+
+func TestInScope(t *testing.T) {
+	tests := []struct {
+		rel  string
+		opts Options
+		want bool
+	}{
+		{"a.pdf", Options{}, true},
+		{"sub/a.pdf", Options{}, false},
+		{"sub/a.pdf", Options{Recursive: true, Include: []string{"*.pdf"}}, true},
+		{"sub/a.txt", Options{Recursive: true, Include: []string{"*.pdf"}}, false},
+		{"sub/a.pdf", Options{Recursive: true, Exclude: []string{"sub/*"}}, false},
+	}
+	for _, tt := range tests {
+		if got := inScope(tt.rel, tt.opts); got != tt.want {
+			t.Errorf("inScope(%q, %+v) = %v, want %v", tt.rel, tt.opts, got, tt.want)
+		}
+	}
+}
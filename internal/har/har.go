@@ -0,0 +1,553 @@
+// Package har records HTTP exchanges, with per-phase timings, as a HAR 1.2
+// log (http://www.softwareishard.com/blog/har-12-spec/) for diagnosing slow
+// or failing commands. Credentials are redacted from headers and bodies
+// before anything is written.
+package har
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxBodyBytes caps how much of each request and response body a Capture
+// keeps, so recording a large upload or download doesn't buffer the whole
+// file.
+const MaxBodyBytes = 1 << 20
+
+const redacted = "REDACTED"
+
+// redactedHeaders are replaced before an exchange is recorded, here and in
+// cassettes.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// secretFields are form and JSON body fields whose values are redacted.
+var secretFields = []string{
+	"access_token", "refresh_token", "id_token", "device_code", "code", "code_verifier",
+	"client_secret", "password", "api_key", "secret",
+}
+
+// File is the top-level HAR document.
+type File struct {
+	Log Log `json:"log"`
+}
+
+// Log is a HAR log.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator identifies the program that wrote the log.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is one request/response exchange.
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"` // total milliseconds
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         Timings   `json:"timings"`
+	ServerIPAddress string    `json:"serverIPAddress,omitempty"`
+	Connection      string    `json:"connection,omitempty"`
+	Error           string    `json:"_error,omitempty"` // transport failure; HAR custom field
+}
+
+// Request is the recorded request.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+// Response is the recorded response. Status is 0 if none was received.
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+// NameValue is a header, cookie or query parameter.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is a recorded request body.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Content is a recorded response body.
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Timings are the phases of an exchange in milliseconds, -1 when a phase
+// did not happen (e.g. DNS and connect on a reused connection). Connect
+// includes SSL, as the spec requires.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// TTFB returns the milliseconds from the start of the exchange to the first
+// response byte.
+func (t Timings) TTFB() float64 {
+	var total float64
+	for _, d := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait} {
+		total += max(d, 0)
+	}
+	return total
+}
+
+// Recorder is an http.RoundTripper that forwards requests to Next and
+// records each exchange, finishing an entry when its response body has been
+// read or closed.
+type Recorder struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	entries []*exchange
+}
+
+// NewRecorder returns a Recorder forwarding to next, or to
+// http.DefaultTransport if next is nil.
+func NewRecorder(next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next}
+}
+
+// exchange is an entry being recorded. Its fields are guarded by Recorder.mu.
+type exchange struct {
+	entry Entry
+	t     phases
+	done  bool
+
+	reqBody  *Capture
+	respBody *Capture
+}
+
+// phases are the httptrace timestamps of one exchange.
+type phases struct {
+	start, dnsStart, dnsDone, connStart, connDone, tlsStart, tlsDone time.Time
+	gotConn, wrote, firstByte, end                                   time.Time
+	reused                                                           bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	x := &exchange{}
+	x.t.start = time.Now()
+	x.entry.StartedDateTime = x.t.start
+	x.entry.Request = recordRequest(req)
+
+	// Trace hooks may fire on the transport's goroutines
+	at := func(f func()) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		f()
+	}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { at(func() { x.t.dnsStart = time.Now() }) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { at(func() { x.t.dnsDone = time.Now() }) },
+		ConnectStart: func(string, string) {
+			at(func() {
+				if x.t.connStart.IsZero() {
+					x.t.connStart = time.Now()
+				}
+			})
+		},
+		ConnectDone:       func(string, string, error) { at(func() { x.t.connDone = time.Now() }) },
+		TLSHandshakeStart: func() { at(func() { x.t.tlsStart = time.Now() }) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { at(func() { x.t.tlsDone = time.Now() }) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			at(func() {
+				x.t.gotConn, x.t.reused = time.Now(), info.Reused
+				if host, port, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+					x.entry.ServerIPAddress, x.entry.Connection = host, port
+				}
+			})
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { at(func() { x.t.wrote = time.Now() }) },
+		GotFirstResponseByte: func() { at(func() { x.t.firstByte = time.Now() }) },
+	}
+
+	out := req.Clone(httptrace.WithClientTrace(req.Context(), trace))
+	if req.Body != nil && req.Body != http.NoBody {
+		x.reqBody = &Capture{ReadCloser: req.Body}
+		out.Body = x.reqBody
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, x)
+	r.mu.Unlock()
+
+	resp, err := r.next.RoundTrip(out)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		x.entry.Error = err.Error()
+		r.finishLocked(x)
+		return nil, err
+	}
+	x.entry.Response = recordResponse(resp)
+	x.respBody = &Capture{ReadCloser: resp.Body, OnDone: func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.finishLocked(x)
+	}}
+	resp.Body = x.respBody
+	return resp, nil
+}
+
+// finishLocked completes x's timings and bodies. Callers hold r.mu.
+func (r *Recorder) finishLocked(x *exchange) {
+	if x.done {
+		return
+	}
+	x.done = true
+	x.t.end = time.Now()
+	x.entry.Timings = x.t.timings()
+	x.entry.Time = ms(x.t.start, x.t.end)
+
+	req := &x.entry.Request
+	if x.reqBody != nil {
+		body, size, truncated := x.reqBody.Snapshot()
+		req.BodySize = size
+		mimeType := headerValue(req.Headers, "Content-Type")
+		req.PostData = &PostData{MimeType: mimeType}
+		switch {
+		case !utf8.Valid(body):
+			req.PostData.Comment = fmt.Sprintf("%d-byte binary body omitted", size)
+		default:
+			req.PostData.Text = RedactBody(mimeType, body, truncated)
+			if truncated {
+				req.PostData.Comment = fmt.Sprintf("truncated to %d of %d bytes", len(body), size)
+			}
+		}
+	}
+
+	if x.respBody != nil {
+		body, size, truncated := x.respBody.Snapshot()
+		c := &x.entry.Response.Content
+		c.Size, x.entry.Response.BodySize = size, size
+		if utf8.Valid(body) {
+			c.Text = RedactBody(c.MimeType, body, truncated)
+		} else {
+			c.Text, c.Encoding = base64.StdEncoding.EncodeToString(body), "base64"
+		}
+		if truncated {
+			c.Comment = fmt.Sprintf("truncated to %d of %d bytes", len(body), size)
+		}
+	}
+}
+
+// Entries returns the exchanges recorded so far, in the order they started.
+// Exchanges still in flight are included with an explanatory Error.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]Entry, 0, len(r.entries))
+	for _, x := range r.entries {
+		if !x.done {
+			if x.respBody == nil && x.entry.Error == "" {
+				x.entry.Error = "no response before the command ended"
+			}
+			r.finishLocked(x)
+		}
+		entries = append(entries, x.entry)
+	}
+	return entries
+}
+
+// Save writes the recorded exchanges to path as a HAR file, naming version
+// as the creator's version.
+func (r *Recorder) Save(path, version string) error {
+	f := File{Log: Log{
+		Version: "1.2",
+		Creator: Creator{Name: "stompy-cli", Version: version},
+		Entries: r.Entries(),
+	}}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding HAR: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("writing HAR: %w", err)
+	}
+	return nil
+}
+
+func (p phases) timings() Timings {
+	t := Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Send: -1, Wait: -1, Receive: -1}
+	if p.gotConn.IsZero() {
+		return t
+	}
+	switch {
+	case p.reused:
+		t.Blocked = ms(p.start, p.gotConn)
+	case !p.dnsStart.IsZero():
+		t.Blocked = ms(p.start, p.dnsStart)
+	case !p.connStart.IsZero():
+		t.Blocked = ms(p.start, p.connStart)
+	}
+	if !p.reused {
+		if !p.dnsStart.IsZero() && !p.dnsDone.IsZero() {
+			t.DNS = ms(p.dnsStart, p.dnsDone)
+		}
+		if !p.connStart.IsZero() {
+			t.Connect = ms(p.connStart, p.gotConn)
+		}
+		if !p.tlsStart.IsZero() && !p.tlsDone.IsZero() {
+			t.SSL = ms(p.tlsStart, p.tlsDone)
+		}
+	}
+	if !p.wrote.IsZero() {
+		t.Send = ms(p.gotConn, p.wrote)
+		if !p.firstByte.IsZero() {
+			t.Wait = ms(p.wrote, p.firstByte)
+			t.Receive = ms(p.firstByte, p.end)
+		}
+	}
+	return t
+}
+
+func ms(from, to time.Time) float64 {
+	return float64(to.Sub(from).Microseconds()) / 1000
+}
+
+func recordRequest(req *http.Request) Request {
+	return Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []NameValue{},
+		Headers:     headers(req.Header),
+		QueryString: query(req.URL.Query()),
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+}
+
+func recordResponse(resp *http.Response) Response {
+	mimeType := resp.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []NameValue{},
+		Headers:     headers(resp.Header),
+		Content:     Content{MimeType: mimeType},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// headers flattens h into sorted name/value pairs, redacting credentials.
+func headers(h http.Header) []NameValue {
+	out := []NameValue{}
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		for _, v := range h[name] {
+			if isRedactedHeader(name) {
+				v = redacted
+			}
+			out = append(out, NameValue{Name: name, Value: v})
+		}
+	}
+	return out
+}
+
+// RedactHeaders returns a copy of h with credentials replaced by "REDACTED".
+func RedactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for name := range out {
+		if isRedactedHeader(name) {
+			out[name] = []string{redacted}
+		}
+	}
+	return out
+}
+
+func isRedactedHeader(name string) bool {
+	return slices.ContainsFunc(redactedHeaders, func(s string) bool { return strings.EqualFold(s, name) })
+}
+
+func query(q url.Values) []NameValue {
+	out := []NameValue{}
+	for _, name := range slices.Sorted(maps.Keys(q)) {
+		for _, v := range q[name] {
+			out = append(out, NameValue{Name: name, Value: v})
+		}
+	}
+	return out
+}
+
+func headerValue(h []NameValue, name string) string {
+	for _, nv := range h {
+		if strings.EqualFold(nv.Name, name) {
+			return nv.Value
+		}
+	}
+	return ""
+}
+
+// RedactBody hides secret fields in form and JSON bodies. Truncated bodies
+// can't be parsed, so they are replaced entirely if they mention a secret.
+func RedactBody(mimeType string, body []byte, truncated bool) string {
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	if truncated {
+		for _, f := range secretFields {
+			if bytes.Contains(body, []byte(f)) {
+				return redacted
+			}
+		}
+		return string(body)
+	}
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return redacted
+		}
+		for k := range form {
+			if slices.Contains(secretFields, strings.ToLower(k)) {
+				form.Set(k, redacted)
+			}
+		}
+		return form.Encode()
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v any
+		if json.Unmarshal(body, &v) != nil {
+			return string(body)
+		}
+		if !redactJSON(v) {
+			return string(body)
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return redacted
+		}
+		return string(out)
+	}
+	return string(body)
+}
+
+// redactJSON replaces secret fields in v in place, reporting whether any were found.
+func redactJSON(v any) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if _, isString := val.(string); isString && slices.Contains(secretFields, strings.ToLower(k)) {
+				v[k] = redacted
+				found = true
+				continue
+			}
+			found = redactJSON(val) || found
+		}
+	case []any:
+		for _, val := range v {
+			found = redactJSON(val) || found
+		}
+	}
+	return found
+}
+
+// Capture passes a body through, keeping its first MaxBodyBytes and calling
+// OnDone once when it is exhausted or closed.
+type Capture struct {
+	io.ReadCloser
+	OnDone func()
+	once   sync.Once
+
+	mu  sync.Mutex // the body may still be read while entries are saved
+	buf bytes.Buffer
+	n   int64
+}
+
+func (c *Capture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if n > 0 {
+		c.mu.Lock()
+		if room := MaxBodyBytes - c.buf.Len(); room > 0 {
+			c.buf.Write(p[:min(n, room)])
+		}
+		c.n += int64(n)
+		c.mu.Unlock()
+	}
+	if err == io.EOF {
+		c.finish()
+	}
+	return n, err
+}
+
+func (c *Capture) Close() error {
+	err := c.ReadCloser.Close()
+	c.finish()
+	return err
+}
+
+func (c *Capture) finish() {
+	if c.OnDone != nil {
+		c.once.Do(c.OnDone)
+	}
+}
+
+// Snapshot returns the captured prefix, the full size read so far, and
+// whether the prefix is truncated.
+func (c *Capture) Snapshot() ([]byte, int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return bytes.Clone(c.buf.Bytes()), c.n, c.n > int64(c.buf.Len())
+}
@@ -0,0 +1,128 @@
+package har
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_RecordsAndRedacts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) //nolint:errcheck
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Write([]byte(`{"access_token":"at-secret","expires_in":3600,"user":{"refresh_token":"rt-secret"}}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	rec := NewRecorder(nil)
+	client := &http.Client{Transport: rec}
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/oauth/token?x=1",
+		strings.NewReader(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"rt-old"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer sk-live")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "at-secret") {
+		t.Fatalf("caller got a redacted body: %s", body)
+	}
+
+	path := filepath.Join(t.TempDir(), "trace.har")
+	if err := rec.Save(path, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"sk-live", "rt-old", "at-secret", "rt-secret", "session=abc"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("HAR contains secret %q", secret)
+		}
+	}
+
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Log.Version != "1.2" || f.Log.Creator.Version != "1.2.3" || len(f.Log.Entries) != 1 {
+		t.Fatalf("log = %+v", f.Log)
+	}
+	e := f.Log.Entries[0]
+	if e.Request.Method != http.MethodPost || e.Response.Status != http.StatusOK {
+		t.Errorf("entry = %s %d", e.Request.Method, e.Response.Status)
+	}
+	if len(e.Request.QueryString) != 1 || e.Request.QueryString[0] != (NameValue{"x", "1"}) {
+		t.Errorf("queryString = %v", e.Request.QueryString)
+	}
+	if e.Request.PostData == nil || !strings.Contains(e.Request.PostData.Text, "grant_type=refresh_token") {
+		t.Errorf("postData = %+v", e.Request.PostData)
+	}
+	if !strings.Contains(e.Response.Content.Text, `"expires_in":3600`) || e.Response.Content.Size != int64(len(body)) {
+		t.Errorf("content = %+v", e.Response.Content)
+	}
+	if e.Timings.Connect < 0 || e.Timings.Wait < 0 || e.Timings.Receive < 0 || e.Timings.SSL != -1 {
+		t.Errorf("timings = %+v", e.Timings)
+	}
+	if e.Time <= 0 || e.Timings.TTFB() > e.Time {
+		t.Errorf("time = %v, ttfb = %v", e.Time, e.Timings.TTFB())
+	}
+}
+
+func TestRecorder_ReusedConnectionAndLargeBody(t *testing.T) {
+	big := strings.Repeat("x", MaxBodyBytes+10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(big)) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	rec := NewRecorder(nil)
+	client := &http.Client{Transport: rec}
+	for range 2 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := io.Copy(io.Discard, resp.Body); n != int64(len(big)) {
+			t.Fatalf("caller read %d bytes, want %d", n, len(big))
+		}
+		resp.Body.Close()
+	}
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	c := entries[0].Response.Content
+	if c.Size != int64(len(big)) || len(c.Text) != MaxBodyBytes || c.Comment == "" {
+		t.Errorf("content size %d, text %d bytes, comment %q; want truncated text", c.Size, len(c.Text), c.Comment)
+	}
+	if tm := entries[1].Timings; tm.Connect != -1 || tm.DNS != -1 {
+		t.Errorf("second request timings = %+v, want no connect on a reused connection", tm)
+	}
+}
+
+func TestRecorder_TransportError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.URL
+	srv.Close()
+
+	rec := NewRecorder(nil)
+	if _, err := (&http.Client{Transport: rec}).Get(addr); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	entries := rec.Entries()
+	if len(entries) != 1 || entries[0].Error == "" || entries[0].Response.Status != 0 {
+		t.Fatalf("entries = %+v, want one failed entry", entries)
+	}
+}
@@ -0,0 +1,259 @@
+// Package httpclient builds the HTTP transport shared by every outgoing
+// request the CLI makes — REST, MCP, OAuth and update checks — so proxy and
+// TLS settings apply to all of them alike.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures the shared transport. The zero value behaves like
+// http.DefaultTransport.
+type Options struct {
+	// Proxy is the proxy URL for all requests. When empty, HTTPS_PROXY and
+	// HTTP_PROXY from the environment are used.
+	Proxy string
+	// NoProxy is a comma-separated list of hosts, domains (".corp.example"),
+	// IPs or CIDR ranges reached directly. When empty, NO_PROXY is used.
+	// Loopback addresses are never proxied.
+	NoProxy string
+
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and key presented
+	// for mutual TLS. Both or neither must be set.
+	CertFile string
+	KeyFile  string
+	// MinTLSVersion is the lowest TLS version accepted: "1.2" or "1.3".
+	// Defaults to 1.2 when empty.
+	MinTLSVersion string
+}
+
+var (
+	mu     sync.RWMutex
+	shared http.RoundTripper = http.DefaultTransport
+)
+
+// Configure builds a transport from opts and makes it the one returned by
+// Transport and used by New.
+func Configure(opts Options) error {
+	t, err := NewTransport(opts)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	shared = t
+	mu.Unlock()
+	return nil
+}
+
+// Wrap replaces the shared transport with wrap applied to it, e.g. to
+// record every request the CLI makes.
+func Wrap(wrap func(http.RoundTripper) http.RoundTripper) {
+	mu.Lock()
+	shared = wrap(shared)
+	mu.Unlock()
+}
+
+// Transport returns the shared transport: http.DefaultTransport until
+// Configure is called.
+func Transport() http.RoundTripper {
+	mu.RLock()
+	defer mu.RUnlock()
+	return shared
+}
+
+// New returns a client with the given timeout that uses the shared transport.
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: Transport()}
+}
+
+// NewTransport builds a transport with http.DefaultTransport's pooling and
+// timeouts and the proxy and TLS settings in opts.
+func NewTransport(opts Options) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.Proxy != "" {
+		if _, err := parseProxyURL(opts.Proxy); err != nil {
+			return nil, err
+		}
+	}
+	t.Proxy = opts.proxyFunc()
+
+	tlsConfig := &tls.Config{}
+	if opts.MinTLSVersion != "" {
+		v, err := ParseTLSVersion(opts.MinTLSVersion)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.MinVersion = v
+	}
+	if opts.CAFile != "" {
+		pool, err := loadCAs(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	t.TLSClientConfig = tlsConfig
+	return t, nil
+}
+
+// ParseTLSVersion converts "1.2" or "1.3" to a crypto/tls version. Older
+// versions are rejected: they can only lower the default minimum.
+func ParseTLSVersion(s string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(s), "tls") {
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	case "1.0", "10", "1.1", "11":
+		return 0, fmt.Errorf("invalid TLS version %q: TLS below 1.2 is not supported; expected 1.2 or 1.3", s)
+	}
+	return 0, fmt.Errorf("invalid TLS version %q: expected 1.2 or 1.3", s)
+}
+
+// loadCAs returns the system roots plus the certificates in the PEM file.
+func loadCAs(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+// proxyFunc returns the transport's proxy selector for opts.
+func (o Options) proxyFunc() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := o.Proxy
+		if proxy == "" {
+			proxy = envProxy(req.URL.Scheme)
+		}
+		noProxy := o.NoProxy
+		if noProxy == "" {
+			noProxy = getenv("NO_PROXY", "no_proxy")
+		}
+		if proxy == "" || bypassProxy(noProxy, req.URL) {
+			return nil, nil
+		}
+		return parseProxyURL(proxy)
+	}
+}
+
+func envProxy(scheme string) string {
+	if scheme == "https" {
+		if p := getenv("HTTPS_PROXY", "https_proxy"); p != "" {
+			return p
+		}
+	}
+	return getenv("HTTP_PROXY", "http_proxy")
+}
+
+func getenv(names ...string) string {
+	for _, n := range names {
+		if v := os.Getenv(n); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseProxyURL parses a proxy setting, which may omit the http:// scheme.
+func parseProxyURL(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", s)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	}
+	return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", s)
+}
+
+// bypassProxy reports whether u is reached directly under a NO_PROXY list.
+// "*" matches everything; "example.com" and ".example.com" match the domain
+// and its subdomains; entries may carry a ":port"; IPs and CIDR ranges match
+// addresses.
+func bypassProxy(noProxy string, u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if h, port, err := net.SplitHostPort(entry); err == nil {
+			if port != portOf(u) {
+				continue
+			}
+			entry = h
+		}
+		if e := net.ParseIP(entry); e != nil {
+			if ip != nil && e.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		entry = strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+func portOf(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
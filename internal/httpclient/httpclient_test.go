@@ -0,0 +1,216 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBypassProxy(t *testing.T) {
+	tests := []struct {
+		noProxy, url string
+		want         bool
+	}{
+		{"", "https://api.stompy.ai", false},
+		{"", "http://localhost:8080", true},
+		{"", "http://127.0.0.1:8080", true},
+		{"*", "https://api.stompy.ai", true},
+		{"stompy.ai", "https://api.stompy.ai", true},
+		{".stompy.ai", "https://api.stompy.ai", true},
+		{"stompy.ai", "https://notstompy.ai", false},
+		{"corp.example, stompy.ai:443", "https://api.stompy.ai", true},
+		{"stompy.ai:8443", "https://api.stompy.ai", false},
+		{"10.0.0.0/8", "https://10.1.2.3", true},
+		{"10.0.0.0/8", "https://11.1.2.3", false},
+		{"192.168.1.5", "http://192.168.1.5:9000", true},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := bypassProxy(tt.noProxy, u); got != tt.want {
+			t.Errorf("bypassProxy(%q, %s) = %v, want %v", tt.noProxy, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "env-proxy:3128")
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("NO_PROXY", "internal.example")
+
+	proxyFor := func(o Options, rawURL string) string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, rawURL, nil)
+		u, err := o.proxyFunc()(req)
+		if err != nil {
+			t.Fatalf("proxy(%s): %v", rawURL, err)
+		}
+		if u == nil {
+			return ""
+		}
+		return u.String()
+	}
+
+	if got := proxyFor(Options{}, "https://api.stompy.ai"); got != "http://env-proxy:3128" {
+		t.Errorf("env proxy = %q", got)
+	}
+	if got := proxyFor(Options{}, "https://internal.example"); got != "" {
+		t.Errorf("NO_PROXY host proxied via %q", got)
+	}
+	if got := proxyFor(Options{}, "http://api.stompy.ai"); got != "" {
+		t.Errorf("http URL proxied via %q with only HTTPS_PROXY set", got)
+	}
+
+	o := Options{Proxy: "https://corp-proxy:8443", NoProxy: "stompy.dev"}
+	if got := proxyFor(o, "https://internal.example"); got != "https://corp-proxy:8443" {
+		t.Errorf("configured proxy = %q, want it to override the environment", got)
+	}
+	if got := proxyFor(o, "https://api.stompy.dev"); got != "" {
+		t.Errorf("configured no_proxy host proxied via %q", got)
+	}
+}
+
+func TestNewTransport_Proxy(t *testing.T) {
+	var gotURI string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURI = r.RequestURI
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer proxy.Close()
+
+	tr, err := NewTransport(Options{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get("http://api.stompy.test/api/v1/projects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot || gotURI != "http://api.stompy.test/api/v1/projects" {
+		t.Errorf("status %d, proxy saw %q; want the request sent through the proxy", resp.StatusCode, gotURI)
+	}
+}
+
+func TestNewTransport_CABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	tr, err := NewTransport(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&http.Client{Transport: tr}).Get(srv.URL); err == nil {
+		t.Fatal("request to a server with a private CA succeeded without the CA bundle")
+	}
+
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+	tr, err = NewTransport(Options{CAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request with CA bundle: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewTransport_ClientCertificate(t *testing.T) {
+	var peerCN string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			peerCN = r.TLS.PeerCertificates[0].Subject.CommonName
+		}
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	certFile, keyFile := clientCert(t, "stompy-cli-test")
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+	tr, err := NewTransport(Options{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, MinTLSVersion: "1.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("mTLS request: %v", err)
+	}
+	resp.Body.Close()
+	if peerCN != "stompy-cli-test" {
+		t.Errorf("server saw client certificate %q", peerCN)
+	}
+	if resp.TLS.Version != tls.VersionTLS13 {
+		t.Errorf("negotiated TLS version %x, want 1.3", resp.TLS.Version)
+	}
+}
+
+func TestNewTransport_Errors(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0o600) //nolint:errcheck
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"tls version", Options{MinTLSVersion: "1.4"}, "invalid TLS version"},
+		{"tls version below 1.2", Options{MinTLSVersion: "1.1"}, "below 1.2 is not supported"},
+		{"cert without key", Options{CertFile: "client.pem"}, "must be set together"},
+		{"missing CA bundle", Options{CAFile: "/nonexistent/ca.pem"}, "reading CA bundle"},
+		{"empty CA bundle", Options{CAFile: empty}, "no PEM certificates"},
+		{"proxy scheme", Options{Proxy: "ftp://proxy:21"}, "scheme must be"},
+	}
+	for _, tt := range tests {
+		_, err := NewTransport(tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func writePEM(t *testing.T, name, typ string, der []byte) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// clientCert writes a self-signed client certificate and its key.
+func clientCert(t *testing.T, cn string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "client.pem", "CERTIFICATE", der), writePEM(t, "client-key.pem", "PRIVATE KEY", keyDER)
+}
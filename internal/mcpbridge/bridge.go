@@ -0,0 +1,333 @@
+// Package mcpbridge serves MCP over stdio and forwards every request to the
+// remote Stompy MCP endpoint, so editors and agents that only speak stdio MCP
+// can use the CLI's stored credentials.
+package mcpbridge
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcInternalError  = -32603
+)
+
+// maxMessageSize bounds a single newline-delimited message read from stdin.
+const maxMessageSize = 16 << 20
+
+// supportedVersions are the protocol revisions offered to the local client,
+// newest first.
+var supportedVersions = []string{stompy.MCPProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// Bridge answers the local side of the MCP handshake itself and forwards all
+// other requests and notifications through Upstream, which owns the remote
+// session and the credentials.
+type Bridge struct {
+	Upstream *stompy.MCPClient
+
+	// DefaultProject fills the "project" argument of tools/call when the
+	// tool declares one and the caller left it out.
+	DefaultProject string
+
+	Verbose bool
+
+	writeMu sync.Mutex // serializes messages written to the client
+	out     io.Writer
+
+	mu       sync.Mutex
+	inFlight map[string]context.CancelFunc // keyed by raw request id
+}
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes responses
+// to w until r reaches EOF or ctx is canceled. Requests and notifications are
+// handled concurrently so a slow tool call does not block pings or
+// cancellations.
+func (b *Bridge) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	b.out = w
+	b.inFlight = make(map[string]context.CancelFunc)
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			select {
+			case lines <- slices.Clone(line):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- sc.Err()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			b.cancelAll()
+			return ctx.Err()
+		case err := <-readErr:
+			if err != nil {
+				b.cancelAll()
+				return fmt.Errorf("reading MCP messages: %w", err)
+			}
+			return nil
+		case line := <-lines:
+			if line[0] == '[' {
+				b.writeError(json.RawMessage("null"), rpcInvalidRequest, "JSON-RPC batches are not supported")
+				continue
+			}
+			var msg message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				b.writeError(json.RawMessage("null"), rpcParseError, "parse error: "+err.Error())
+				continue
+			}
+			if msg.Method == "" {
+				// Responses to server-initiated requests; the bridge sends none.
+				continue
+			}
+			if len(msg.ID) == 0 {
+				// Forwarding may block on the network, so keep reading
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					b.handleNotification(ctx, msg)
+				}()
+				continue
+			}
+
+			reqCtx, cancel := context.WithCancel(ctx)
+			b.mu.Lock()
+			b.inFlight[string(msg.ID)] = cancel
+			b.mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer b.finish(msg.ID)
+				b.handleRequest(reqCtx, msg)
+			}()
+		}
+	}
+}
+
+// handleRequest answers initialize and ping locally and forwards the rest.
+func (b *Bridge) handleRequest(ctx context.Context, msg message) {
+	b.debugf("<-- %s", msg.Method)
+
+	switch msg.Method {
+	case "initialize":
+		result, err := b.initialize(ctx, msg.Params)
+		b.reply(msg.ID, result, err)
+		return
+	case "ping":
+		b.reply(msg.ID, map[string]any{}, nil)
+		return
+	}
+
+	var params any = msg.Params
+	if len(msg.Params) == 0 {
+		params = map[string]any{}
+	}
+	if msg.Method == "tools/call" && b.DefaultProject != "" {
+		params = b.withDefaultProject(ctx, msg.Params)
+	}
+
+	var result json.RawMessage
+	err := b.Upstream.Call(ctx, msg.Method, params, &result)
+	b.reply(msg.ID, result, err)
+}
+
+// handleNotification forwards client notifications upstream, except the ones
+// that only concern the local connection.
+func (b *Bridge) handleNotification(ctx context.Context, msg message) {
+	b.debugf("<-- %s (notification)", msg.Method)
+
+	switch msg.Method {
+	case "notifications/initialized":
+		// The upstream session completes its own handshake.
+		return
+	case "notifications/cancelled":
+		var p struct {
+			RequestID json.RawMessage `json:"requestId"`
+		}
+		if json.Unmarshal(msg.Params, &p) == nil {
+			b.mu.Lock()
+			cancel := b.inFlight[string(p.RequestID)]
+			b.mu.Unlock()
+			if cancel != nil {
+				cancel()
+			}
+		}
+		return
+	}
+
+	var params any
+	if len(msg.Params) > 0 {
+		params = msg.Params
+	}
+	if err := b.Upstream.Notify(ctx, msg.Method, params); err != nil {
+		b.debugf("forwarding %s failed: %v", msg.Method, err)
+	}
+}
+
+// initialize opens the upstream session and answers the client with the
+// remote server's capabilities, at a protocol version both sides speak.
+func (b *Bridge) initialize(ctx context.Context, raw json.RawMessage) (any, error) {
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	json.Unmarshal(raw, &params) //nolint:errcheck
+
+	session, err := b.Upstream.Initialize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	version := supportedVersions[0]
+	if slices.Contains(supportedVersions, params.ProtocolVersion) {
+		version = params.ProtocolVersion
+	}
+	capabilities := session.Capabilities
+	if capabilities == nil {
+		capabilities = map[string]any{"tools": map[string]any{}}
+	}
+	serverInfo := session.ServerInfo
+	if serverInfo.Name == "" {
+		serverInfo = stompy.MCPImplementation{Name: "stompy", Version: "unknown"}
+	}
+	return stompy.MCPInitializeResult{
+		ProtocolVersion: version,
+		Capabilities:    capabilities,
+		ServerInfo:      serverInfo,
+		Instructions:    session.Instructions,
+	}, nil
+}
+
+// withDefaultProject returns tools/call params with DefaultProject added when
+// the tool takes a "project" argument that the caller did not supply. The
+// params are returned unchanged if anything about them is unexpected.
+func (b *Bridge) withDefaultProject(ctx context.Context, raw json.RawMessage) any {
+	var params map[string]any
+	if err := json.Unmarshal(raw, &params); err != nil || params == nil {
+		return raw
+	}
+	name, _ := params["name"].(string)
+	args, _ := params["arguments"].(map[string]any)
+	if args == nil {
+		args = map[string]any{}
+	}
+	if _, ok := args["project"]; ok {
+		return raw
+	}
+
+	tool, err := b.Upstream.Tool(ctx, name)
+	if err != nil {
+		return raw
+	}
+	schema, err := tool.Schema()
+	if err != nil {
+		return raw
+	}
+	if _, ok := schema.Properties["project"]; !ok {
+		return raw
+	}
+	args["project"] = b.DefaultProject
+	params["arguments"] = args
+	return params
+}
+
+// reply writes the result of a request, translating errors into JSON-RPC
+// errors. Errors the remote server reported keep their code.
+func (b *Bridge) reply(id json.RawMessage, result any, err error) {
+	if err == nil {
+		if raw, ok := result.(json.RawMessage); ok && len(raw) == 0 {
+			result = map[string]any{}
+		}
+		b.write(response{JSONRPC: "2.0", ID: id, Result: result})
+		return
+	}
+
+	var rpcErr *stompy.RPCError
+	if errors.As(err, &rpcErr) {
+		b.writeError(id, rpcErr.Code, rpcErr.Message)
+		return
+	}
+	b.writeError(id, rpcInternalError, err.Error())
+}
+
+func (b *Bridge) writeError(id json.RawMessage, code int, msg string) {
+	b.write(response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: msg}})
+}
+
+func (b *Bridge) write(resp response) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{Code: rpcInternalError, Message: err.Error()}})
+	}
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	b.out.Write(append(data, '\n')) //nolint:errcheck
+}
+
+// finish forgets a completed request.
+func (b *Bridge) finish(id json.RawMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cancel := b.inFlight[string(id)]; cancel != nil {
+		cancel()
+		delete(b.inFlight, string(id))
+	}
+}
+
+func (b *Bridge) cancelAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, cancel := range b.inFlight {
+		cancel()
+	}
+}
+
+// debugf logs to stderr; stdout carries only protocol messages.
+func (b *Bridge) debugf(format string, args ...any) {
+	if b.Verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] bridge: "+format+"\n", args...)
+	}
+}
@@ -0,0 +1,156 @@
+package mcpbridge
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/fakestompy"
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+// serve runs the bridge over the given input lines and returns the decoded
+// responses keyed by request id.
+func serve(t *testing.T, b *Bridge, lines ...string) map[string]map[string]any {
+	t.Helper()
+	in := strings.NewReader(strings.Join(lines, "\n") + "\n")
+	pr, pw := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Serve(context.Background(), in, pw)
+		pw.Close()
+	}()
+
+	got := map[string]map[string]any{}
+	sc := bufio.NewScanner(pr)
+	for sc.Scan() {
+		var resp map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &resp); err != nil {
+			t.Fatalf("bridge wrote invalid JSON %q: %v", sc.Text(), err)
+		}
+		id, _ := json.Marshal(resp["id"])
+		got[string(id)] = resp
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+	return got
+}
+
+func newBridge(t *testing.T) *Bridge {
+	t.Helper()
+	s := fakestompy.New()
+	s.Seed()
+	srv, baseURL := fakestompy.NewTestServer(s)
+	t.Cleanup(srv.Close)
+	return &Bridge{Upstream: stompy.NewMCPClient(stompy.MCPBaseURL(baseURL))}
+}
+
+func TestBridge_ForwardsRequests(t *testing.T) {
+	b := newBridge(t)
+	b.DefaultProject = "demo"
+
+	got := serve(t, b,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"editor","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":"two","method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"project_brief","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"prompts/list"}`,
+		`not json`,
+	)
+
+	init := got["1"]["result"].(map[string]any)
+	if init["protocolVersion"] != "2025-03-26" {
+		t.Errorf("protocolVersion = %v, want the client's 2025-03-26", init["protocolVersion"])
+	}
+	if name := init["serverInfo"].(map[string]any)["name"]; name != "fakestompy" {
+		t.Errorf("serverInfo.name = %v, want upstream's", name)
+	}
+
+	tools := got[`"two"`]["result"].(map[string]any)["tools"].([]any)
+	if len(tools) == 0 {
+		t.Error("tools/list returned no tools")
+	}
+
+	call := got["3"]["result"].(map[string]any)
+	if call["isError"] == true {
+		t.Errorf("tools/call failed; default project not applied: %v", call)
+	}
+
+	if _, ok := got["4"]["result"]; !ok {
+		t.Errorf("ping response = %v", got["4"])
+	}
+
+	rpcErr := got["5"]["error"].(map[string]any)
+	if rpcErr["code"] != float64(-32601) {
+		t.Errorf("prompts/list error code = %v, want upstream's -32601", rpcErr["code"])
+	}
+
+	if parseErr := got["null"]["error"].(map[string]any); parseErr["code"] != float64(rpcParseError) {
+		t.Errorf("parse error code = %v", parseErr["code"])
+	}
+}
+
+func TestBridge_ExplicitProjectWins(t *testing.T) {
+	b := newBridge(t)
+	b.DefaultProject = "demo"
+
+	got := serve(t, b,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"project_brief","arguments":{"project":"missing"}}}`,
+	)
+	if call := got["1"]["result"].(map[string]any); call["isError"] != true {
+		t.Errorf("expected tool error for explicit unknown project, got %v", call)
+	}
+}
+
+func TestBridge_SlowNotificationDoesNotBlockRequests(t *testing.T) {
+	s := fakestompy.New()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("notifications/progress")) {
+			<-release
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		s.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	defer close(release)
+	b := &Bridge{Upstream: stompy.NewMCPClient(stompy.MCPBaseURL(srv.URL + "/api/v1"))}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Serve(ctx, inR, outW) //nolint:errcheck
+
+	go io.WriteString(inW, `{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":1,"progress":1}}`+"\n"+
+		`{"jsonrpc":"2.0","id":1,"method":"ping"}`+"\n") //nolint:errcheck
+
+	line := make(chan string, 1)
+	go func() {
+		sc := bufio.NewScanner(outR)
+		if sc.Scan() {
+			line <- sc.Text()
+		}
+	}()
+	select {
+	case got := <-line:
+		if !strings.Contains(got, `"id":1`) {
+			t.Errorf("response = %s, want the ping answered", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ping was not answered while a notification was being forwarded")
+	}
+}
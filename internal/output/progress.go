@@ -0,0 +1,86 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const progressBarWidth = 30
+
+// Progress draws a single-line transfer progress bar. It only draws when its
+// file is a terminal, so commands can report progress unconditionally without
+// polluting redirected output.
+type Progress struct {
+	f      *os.File
+	label  string
+	active bool
+
+	mu    sync.Mutex
+	start time.Time
+	drawn time.Time
+}
+
+// NewProgress returns a progress bar for label drawn on f (normally os.Stderr).
+func NewProgress(f *os.File, label string) *Progress {
+	return &Progress{
+		f:      f,
+		label:  label,
+		active: term.IsTerminal(int(f.Fd())),
+		start:  time.Now(),
+	}
+}
+
+// Update redraws the bar for sent of total bytes, at most ten times a second.
+func (p *Progress) Update(sent, total int64) {
+	if !p.active {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.drawn) < 100*time.Millisecond && sent < total {
+		return
+	}
+	p.drawn = time.Now()
+
+	frac := 1.0
+	if total > 0 {
+		frac = min(float64(sent)/float64(total), 1)
+	}
+	filled := int(frac * progressBarWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+
+	rate := ""
+	if secs := time.Since(p.start).Seconds(); secs > 0.5 {
+		rate = "  " + humanBytes(float64(sent)/secs) + "/s"
+	}
+	fmt.Fprintf(p.f, "\r\033[K%s %s %3.0f%%  %s / %s%s",
+		Dim(p.label), Teal(bar), frac*100, humanBytes(float64(sent)), humanBytes(float64(total)), rate)
+}
+
+// Done clears the bar so later output starts on a clean line.
+func (p *Progress) Done() {
+	if !p.active {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprint(p.f, "\r\033[K")
+}
+
+func humanBytes(b float64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%.0f B", b)
+	}
+	exp := 0
+	for b >= unit*unit && exp < 5 {
+		b /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", b/unit, "KMGTPE"[exp])
+}
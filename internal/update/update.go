@@ -13,6 +13,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/banton/stompy-cli/internal/httpclient"
 )
 
 const (
@@ -59,7 +61,7 @@ func CheckForUpdate(currentVersion, configDir string) string {
 	}
 
 	// Fetch latest release from GitHub (with short timeout)
-	client := &http.Client{Timeout: 3 * time.Second}
+	client := httpclient.New(3 * time.Second)
 	resp, err := client.Get(releaseAPI)
 	if err != nil {
 		return ""
@@ -140,7 +142,7 @@ func compareSemver(a, b [3]int) int {
 
 // GetLatestRelease fetches the latest release info from GitHub.
 func GetLatestRelease() (*Release, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := httpclient.New(10 * time.Second)
 	resp, err := client.Get(releaseAPI)
 	if err != nil {
 		return nil, fmt.Errorf("checking GitHub releases: %w", err)
@@ -178,7 +180,7 @@ func SelfUpdate(currentVersion string) error {
 	fmt.Printf("Downloading %s (%s)...\n", release.TagName, formatSize(asset.Size))
 
 	// Download the archive
-	client := &http.Client{Timeout: 120 * time.Second}
+	client := httpclient.New(120 * time.Second)
 	resp, err := client.Get(asset.BrowserDownloadURL)
 	if err != nil {
 		return fmt.Errorf("downloading release: %w", err)
@@ -0,0 +1,73 @@
+package stompy
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// APIKeyCreate is the request body for creating an API key.
+type APIKeyCreate struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes,omitempty"`
+	// ExpiresAt is nil for a key that never expires.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyResponse describes an API key. The secret itself is only returned
+// once, by CreateAPIKey and RotateAPIKey.
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// APIKeySecretResponse is a newly minted key together with its secret.
+type APIKeySecretResponse struct {
+	APIKeyResponse
+	Secret string `json:"secret"`
+}
+
+// APIKeyListResponse wraps a list of API keys.
+type APIKeyListResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+	Total   int              `json:"total"`
+}
+
+// ListAPIKeys returns the caller's API keys, without their secrets.
+func (c *Client) ListAPIKeys(ctx context.Context) (*APIKeyListResponse, error) {
+	var resp APIKeyListResponse
+	if err := c.Get(ctx, "/api-keys", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateAPIKey mints a new API key.
+func (c *Client) CreateAPIKey(ctx context.Context, req APIKeyCreate) (*APIKeySecretResponse, error) {
+	var resp APIKeySecretResponse
+	if err := c.Post(ensureIdempotencyKey(ctx), "/api-keys", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RotateAPIKey replaces the secret of an API key, keeping its name, scopes
+// and expiry. The old secret stops working immediately.
+func (c *Client) RotateAPIKey(ctx context.Context, id string) (*APIKeySecretResponse, error) {
+	var resp APIKeySecretResponse
+	if err := c.Post(ensureIdempotencyKey(ctx), fmt.Sprintf("/api-keys/%s/rotate", url.PathEscape(id)), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RevokeAPIKey deletes an API key.
+func (c *Client) RevokeAPIKey(ctx context.Context, id string) error {
+	return c.Delete(ctx, fmt.Sprintf("/api-keys/%s", url.PathEscape(id)), nil)
+}
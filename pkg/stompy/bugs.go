@@ -1,7 +1,9 @@
-package api
+package stompy
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"net/url"
 	"strconv"
 	"time"
@@ -28,7 +30,7 @@ type BugReportListResponse struct {
 }
 
 // ListBugReports fetches bug reports with optional status filter.
-func (c *Client) ListBugReports(project, status string, limit, offset int) (*BugReportListResponse, error) {
+func (c *Client) ListBugReports(ctx context.Context, project, status string, limit, offset int) (*BugReportListResponse, error) {
 	params := url.Values{}
 	if status != "" {
 		params.Set("status", status)
@@ -41,16 +43,27 @@ func (c *Client) ListBugReports(project, status string, limit, offset int) (*Bug
 	}
 
 	var resp BugReportListResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/bug-reports", project), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/bug-reports", project), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
+// AllBugReports iterates over every bug report with the given status, fetching pageSize at a time.
+func (c *Client) AllBugReports(ctx context.Context, project, status string, pageSize int) iter.Seq2[BugReportResponse, error] {
+	return Paginate(ctx, pageSize, func(ctx context.Context, limit, offset int) ([]BugReportResponse, int, error) {
+		resp, err := c.ListBugReports(ctx, project, status, limit, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.BugReports, resp.Total, nil
+	})
+}
+
 // GetBugReport fetches a single bug report by ID.
-func (c *Client) GetBugReport(project string, id int) (*BugReportResponse, error) {
+func (c *Client) GetBugReport(ctx context.Context, project string, id int) (*BugReportResponse, error) {
 	var resp BugReportResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/bug-reports/%d", project, id), nil, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/bug-reports/%d", project, id), nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
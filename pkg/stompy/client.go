@@ -0,0 +1,367 @@
+package stompy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/httpclient"
+)
+
+const retryBaseDelay = 1 * time.Second
+
+// Client calls the Stompy REST API. Create one with NewClient; it is safe
+// for concurrent use.
+type Client struct {
+	BaseURL    string
+	AuthToken  string
+	Tokens     TokenSource // overrides AuthToken and enables refresh on 401
+	UserAgent  string
+	Version    string // CLI version (e.g., "0.2.0" or "dev")
+	HTTPClient *http.Client
+	MaxRetries int
+	Verbose    bool
+
+	// RateLimiter, when set, throttles every outgoing request (including retries).
+	RateLimiter *RateLimiter
+
+	onWarning func(string)
+
+	mu           sync.Mutex // guards the server info below
+	apiVersion   string     // X-Stompy-API-Version
+	compatWarned bool       // only warn once per client
+}
+
+// NewClient returns a client for the REST API at baseURL, e.g.
+// "https://api.stompy.ai/api/v1".
+func NewClient(baseURL string, opts ...Option) *Client {
+	o := newOptions(opts)
+	hc := o.httpClient
+	if hc == nil {
+		hc = &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: httpclient.Transport(),
+		}
+	}
+	return &Client{
+		BaseURL:     strings.TrimRight(baseURL, "/"),
+		AuthToken:   o.token,
+		Tokens:      o.tokens,
+		UserAgent:   o.userAgent,
+		Version:     o.version,
+		HTTPClient:  hc,
+		MaxRetries:  o.maxRetries,
+		Verbose:     o.verbose,
+		RateLimiter: o.limiter,
+		onWarning:   o.onWarning,
+	}
+}
+
+// APIVersion returns the server version reported by the last response, or ""
+// before the first one.
+func (c *Client) APIVersion() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.apiVersion
+}
+
+// Do performs an HTTP request against the API, retrying idempotent methods on
+// network errors and gateway failures. Cancelling ctx aborts both the in-flight
+// request and any pending retry backoff.
+func (c *Client) Do(ctx context.Context, method, path string, body any, params url.Values) ([]byte, int, error) {
+	u := c.BaseURL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	var reqBytes []byte
+	if body != nil {
+		var err error
+		reqBytes, err = json.Marshal(body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("marshaling request body: %w", err)
+		}
+	}
+
+	idemKey := ""
+	if method == http.MethodPost {
+		idemKey = idempotencyKey(ctx)
+	}
+
+	if c.Verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] --> %s %s\n", method, u)
+		if idemKey != "" {
+			fmt.Fprintf(os.Stderr, "[DEBUG]     Idempotency-Key: %s\n", idemKey)
+		}
+		if len(reqBytes) > 0 {
+			preview := string(reqBytes)
+			if len(preview) > 200 {
+				preview = preview[:200] + "..."
+			}
+			fmt.Fprintf(os.Stderr, "[DEBUG]     Body: %s\n", preview)
+		}
+	}
+
+	// Network errors and gateway failures are only retried for idempotent
+	// methods, or POSTs carrying an Idempotency-Key the server can dedupe on;
+	// 429 means the request was not processed, so it is always retried.
+	idempotent := isIdempotent(method) || idemKey != ""
+
+	var lastErr error
+	var delay time.Duration
+	refreshed, replay := false, false
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 && !replay {
+			if c.Verbose {
+				fmt.Fprintf(os.Stderr, "[DEBUG]     Retry %d/%d after %s\n", attempt, c.MaxRetries, delay)
+			}
+			if err := sleepCtx(ctx, delay); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		replay = false
+
+		if c.RateLimiter != nil {
+			if err := c.RateLimiter.Wait(ctx); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		var reqBody io.Reader
+		if len(reqBytes) > 0 {
+			reqBody = bytes.NewReader(reqBytes)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+		if err != nil {
+			return nil, 0, fmt.Errorf("creating request: %w", err)
+		}
+
+		token, err := currentToken(c.Tokens, c.AuthToken)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set("User-Agent", c.UserAgent)
+
+		if noCache(ctx) {
+			req.Header.Set("Cache-Control", "no-cache")
+		}
+
+		if idemKey != "" {
+			req.Header.Set("Idempotency-Key", idemKey)
+		}
+
+		if body != nil && (method == http.MethodPost || method == http.MethodPut) {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		start := time.Now()
+		resp, err := c.HTTPClient.Do(req)
+		elapsed := time.Since(start)
+
+		if err != nil {
+			if c.Verbose {
+				fmt.Fprintf(os.Stderr, "[DEBUG] <-- ERROR after %s: %v\n", elapsed, err)
+			}
+			if ctx.Err() != nil {
+				return nil, 0, fmt.Errorf("executing request: %w", err)
+			}
+			lastErr = &NetworkError{Op: "executing request", Err: err}
+			if !idempotent {
+				return nil, 0, lastErr
+			}
+			delay = backoffDelay(attempt + 1)
+			continue
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, resp.StatusCode, fmt.Errorf("reading response body: %w", err)
+		}
+
+		if c.Verbose {
+			fmt.Fprintf(os.Stderr, "[DEBUG] <-- %d %s (%s, %d bytes)\n", resp.StatusCode, http.StatusText(resp.StatusCode), elapsed, len(respBody))
+			if xCache := resp.Header.Get("X-Cache"); xCache != "" {
+				fmt.Fprintf(os.Stderr, "[DEBUG]     X-Cache: %s\n", xCache)
+			}
+			if len(respBody) > 0 {
+				preview := string(respBody)
+				if len(preview) > 300 {
+					preview = preview[:300] + "..."
+				}
+				fmt.Fprintf(os.Stderr, "[DEBUG]     Body: %s\n", preview)
+			}
+		}
+
+		c.checkServerInfo(resp.Header)
+
+		// A rejected token is refreshed once and the request replayed; the
+		// replay does not count against the retry budget.
+		if resp.StatusCode == http.StatusUnauthorized && !refreshed {
+			refreshed = true
+			if refreshToken(ctx, c.Tokens, token, c.Verbose) {
+				attempt--
+				replay = true
+				continue
+			}
+		}
+
+		if isRetryableStatus(resp.StatusCode) && (idempotent || resp.StatusCode == http.StatusTooManyRequests) {
+			lastErr = &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+			var ok bool
+			if delay, ok = retryDelay(attempt+1, resp.Header); !ok {
+				return nil, resp.StatusCode, lastErr
+			}
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			apiErr := &APIError{StatusCode: resp.StatusCode}
+			if err := json.Unmarshal(respBody, apiErr); err != nil {
+				apiErr.Message = string(respBody)
+			}
+			if apiErr.Message == "" {
+				apiErr.Message = http.StatusText(resp.StatusCode)
+			}
+			return nil, resp.StatusCode, apiErr
+		}
+
+		return respBody, resp.StatusCode, nil
+	}
+
+	return nil, 0, lastErr
+}
+
+// checkServerInfo records the server version from response headers and
+// reports, once per client, a CLI version below the server's minimum.
+func (c *Client) checkServerInfo(h http.Header) {
+	c.mu.Lock()
+	if apiVer := h.Get("X-Stompy-API-Version"); apiVer != "" {
+		c.apiVersion = apiVer
+	}
+	warn := ""
+	if !c.compatWarned {
+		if warn = CheckCompat(c.Version, h.Get("X-Stompy-Min-CLI-Version")); warn != "" {
+			c.compatWarned = true
+		}
+	}
+	c.mu.Unlock()
+
+	if warn != "" && c.onWarning != nil {
+		c.onWarning(warn)
+	}
+}
+
+type noCacheCtx struct{}
+
+// WithNoCache returns a copy of ctx whose requests ask the server to bypass
+// its cache with Cache-Control: no-cache.
+func WithNoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheCtx{}, true)
+}
+
+// noCache reports whether ctx was marked by WithNoCache.
+func noCache(ctx context.Context) bool {
+	v, _ := ctx.Value(noCacheCtx{}).(bool)
+	return v
+}
+
+// sleepCtx waits for d or until ctx is done, whichever comes first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (c *Client) Get(ctx context.Context, path string, params url.Values, result any) error {
+	data, _, err := c.Do(ctx, http.MethodGet, path, nil, params)
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) Post(ctx context.Context, path string, body any, result any) error {
+	data, _, err := c.Do(ctx, http.MethodPost, path, body, nil)
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) Put(ctx context.Context, path string, body any, result any) error {
+	data, _, err := c.Do(ctx, http.MethodPut, path, body, nil)
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) Delete(ctx context.Context, path string, params url.Values) error {
+	_, _, err := c.Do(ctx, http.MethodDelete, path, nil, params)
+	return err
+}
+
+// DeleteWithResult performs a DELETE and decodes the JSON response body.
+// Use for endpoints that return data (e.g. context unlock returns ContextDeleteResponse).
+func (c *Client) DeleteWithResult(ctx context.Context, path string, params url.Values, result any) error {
+	data, statusCode, err := c.Do(ctx, http.MethodDelete, path, nil, params)
+	if err != nil {
+		return err
+	}
+	if result != nil && statusCode != http.StatusNoContent && len(data) > 0 {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return nil
+}
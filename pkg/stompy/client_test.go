@@ -1,17 +1,22 @@
-package api
+package stompy
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func TestNewClient(t *testing.T) {
-	c := NewClient("https://api.example.com", "test-token", "1.2.3", false)
+	c := NewClient("https://api.example.com", WithToken("test-token"), WithVersion("1.2.3"))
 
 	if c.BaseURL != "https://api.example.com" {
 		t.Errorf("BaseURL = %q, want %q", c.BaseURL, "https://api.example.com")
@@ -28,26 +33,64 @@ func TestNewClient(t *testing.T) {
 }
 
 func TestNewClient_DevVersion(t *testing.T) {
-	c := NewClient("https://api.example.com", "tok", "dev", false)
+	c := NewClient("https://api.example.com", WithToken("tok"))
 	if c.UserAgent != "stompy-cli/dev" {
 		t.Errorf("UserAgent = %q, want %q", c.UserAgent, "stompy-cli/dev")
 	}
 }
 
 func TestNewClient_EmptyVersion(t *testing.T) {
-	c := NewClient("https://api.example.com", "tok", "", false)
+	c := NewClient("https://api.example.com", WithToken("tok"), WithVersion(""))
 	if c.UserAgent != "stompy-cli/dev" {
 		t.Errorf("UserAgent = %q, want %q (fallback for empty version)", c.UserAgent, "stompy-cli/dev")
 	}
 }
 
 func TestNewClient_TrimsTrailingSlash(t *testing.T) {
-	c := NewClient("https://api.example.com/", "tok", "dev", false)
+	c := NewClient("https://api.example.com/", WithToken("tok"))
 	if c.BaseURL != "https://api.example.com" {
 		t.Errorf("BaseURL = %q, want trailing slash trimmed", c.BaseURL)
 	}
 }
 
+func TestNewClient_Options(t *testing.T) {
+	var gotUA, gotAuth string
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		gotUA, gotAuth = r.Header.Get("User-Agent"), r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{Timeout: 5 * time.Second}
+	tokens := &stubTokens{current: "from-source"}
+	c := NewClient(srv.URL,
+		WithToken("static"),
+		WithTokenSource(tokens),
+		WithHTTPClient(hc),
+		WithUserAgent("deploy-bot/1.0"),
+		WithRetries(0),
+	)
+	if c.HTTPClient != hc {
+		t.Error("WithHTTPClient was not used")
+	}
+	if _, _, err := c.Do(context.Background(), http.MethodGet, "/test", nil, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 with retries disabled", attempts)
+	}
+	if gotUA != "deploy-bot/1.0" || gotAuth != "Bearer from-source" {
+		t.Errorf("User-Agent = %q, Authorization = %q", gotUA, gotAuth)
+	}
+
+	m := NewMCPClient(srv.URL, WithRetries(5), WithVersion("2.0.0"))
+	if m.MaxRetries != 5 || m.UserAgent != "stompy-cli/2.0.0" || m.HTTPClient.Timeout != 60*time.Second {
+		t.Errorf("MCPClient = retries %d, UA %q, timeout %s", m.MaxRetries, m.UserAgent, m.HTTPClient.Timeout)
+	}
+}
+
 func TestClient_Do_SetsHeaders(t *testing.T) {
 	var gotHeaders http.Header
 	var gotMethod string
@@ -59,8 +102,8 @@ func TestClient_Do_SetsHeaders(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "my-token", "0.2.0", false)
-	_, _, err := c.Do(http.MethodPost, "/test", map[string]string{"key": "val"}, nil)
+	c := NewClient(srv.URL, WithToken("my-token"), WithVersion("0.2.0"))
+	_, _, err := c.Do(context.Background(), http.MethodPost, "/test", map[string]string{"key": "val"}, nil)
 	if err != nil {
 		t.Fatalf("Do() error: %v", err)
 	}
@@ -88,9 +131,9 @@ func TestClient_Do_QueryParams(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "", "dev", false)
+	c := NewClient(srv.URL)
 	params := url.Values{"foo": {"bar"}, "baz": {"1"}}
-	_, _, err := c.Do(http.MethodGet, "/items", nil, params)
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/items", nil, params)
 	if err != nil {
 		t.Fatalf("Do() error: %v", err)
 	}
@@ -114,8 +157,8 @@ func TestClient_Do_NonOKReturnsAPIError(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	_, _, err := c.Do(http.MethodGet, "/missing", nil, nil)
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/missing", nil, nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -139,8 +182,8 @@ func TestClient_Do_NonJSONErrorBody(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "", "dev", false)
-	_, _, err := c.Do(http.MethodGet, "/fail", nil, nil)
+	c := NewClient(srv.URL)
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/fail", nil, nil)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -159,9 +202,9 @@ func TestClient_Get(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
+	c := NewClient(srv.URL, WithToken("tok"))
 	var result map[string]string
-	err := c.Get("/resource", nil, &result)
+	err := c.Get(context.Background(), "/resource", nil, &result)
 	if err != nil {
 		t.Fatalf("Get() error: %v", err)
 	}
@@ -183,9 +226,9 @@ func TestClient_Post(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
+	c := NewClient(srv.URL, WithToken("tok"))
 	var result map[string]string
-	err := c.Post("/resource", map[string]string{"name": "new"}, &result)
+	err := c.Post(context.Background(), "/resource", map[string]string{"name": "new"}, &result)
 	if err != nil {
 		t.Fatalf("Post() error: %v", err)
 	}
@@ -206,9 +249,9 @@ func TestClient_Put(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
+	c := NewClient(srv.URL, WithToken("tok"))
 	var result map[string]string
-	err := c.Put("/resource/1", map[string]string{"name": "updated"}, &result)
+	err := c.Put(context.Background(), "/resource/1", map[string]string{"name": "updated"}, &result)
 	if err != nil {
 		t.Fatalf("Put() error: %v", err)
 	}
@@ -226,8 +269,8 @@ func TestClient_Delete(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	err := c.Delete("/resource/1", nil)
+	c := NewClient(srv.URL, WithToken("tok"))
+	err := c.Delete(context.Background(), "/resource/1", nil)
 	if err != nil {
 		t.Fatalf("Delete() error: %v", err)
 	}
@@ -253,11 +296,11 @@ func TestClient_Do_RetriesOnTimeout(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
+	c := NewClient(srv.URL, WithToken("tok"))
 	// Use a short timeout so the test is fast.
 	c.HTTPClient.Timeout = 500 * time.Millisecond
 
-	data, code, err := c.Do(http.MethodGet, "/test", nil, nil)
+	data, code, err := c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
 	if err != nil {
 		t.Fatalf("Do() error: %v", err)
 	}
@@ -286,8 +329,8 @@ func TestClient_Do_RetriesOn502(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	data, code, err := c.Do(http.MethodGet, "/test", nil, nil)
+	c := NewClient(srv.URL, WithToken("tok"))
+	data, code, err := c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
 	if err != nil {
 		t.Fatalf("Do() error: %v", err)
 	}
@@ -311,8 +354,8 @@ func TestClient_Do_NoRetryOnPost(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	_, _, err := c.Do(http.MethodPost, "/test", map[string]string{"k": "v"}, nil)
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, _, err := c.Do(context.Background(), http.MethodPost, "/test", map[string]string{"k": "v"}, nil)
 	if err == nil {
 		t.Fatal("expected error for 502 on POST")
 	}
@@ -330,8 +373,8 @@ func TestClient_Do_NoRetryOn4xx(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	_, _, err := c.Do(http.MethodGet, "/test", nil, nil)
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
 	if err == nil {
 		t.Fatal("expected error for 404")
 	}
@@ -349,8 +392,8 @@ func TestClient_Do_ExhaustsRetries(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	_, _, err := c.Do(http.MethodGet, "/test", nil, nil)
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
 	if err == nil {
 		t.Fatal("expected error after exhausting retries")
 	}
@@ -385,6 +428,7 @@ func TestIsRetryableStatus(t *testing.T) {
 		code int
 		want bool
 	}{
+		{429, true},
 		{502, true},
 		{503, true},
 		{504, true},
@@ -409,13 +453,13 @@ func TestClient_Do_ReadsAPIVersionHeader(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "0.2.0", false)
-	_, _, err := c.Do(http.MethodGet, "/test", nil, nil)
+	c := NewClient(srv.URL, WithToken("tok"), WithVersion("0.2.0"))
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
 	if err != nil {
 		t.Fatalf("Do() error: %v", err)
 	}
-	if c.APIVersion != "6.0.0" {
-		t.Errorf("APIVersion = %q, want %q", c.APIVersion, "6.0.0")
+	if c.APIVersion() != "6.0.0" {
+		t.Errorf("APIVersion() = %q, want %q", c.APIVersion(), "6.0.0")
 	}
 }
 
@@ -427,19 +471,18 @@ func TestClient_Do_CompatWarningPrintedOnce(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "0.2.0", false)
+	var warnings []string
+	c := NewClient(srv.URL, WithToken("tok"), WithVersion("0.2.0"), WithWarningHandler(func(msg string) {
+		warnings = append(warnings, msg)
+	}))
 
-	// First call should set compatWarned
-	_, _, _ = c.Do(http.MethodGet, "/test", nil, nil)
-	if !c.compatWarned {
-		t.Error("compatWarned should be true after outdated version response")
+	_, _, _ = c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
+	_, _, _ = c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings, want 1: %q", len(warnings), warnings)
 	}
-
-	// Second call should not warn again (flag already set)
-	prevWarned := c.compatWarned
-	_, _, _ = c.Do(http.MethodGet, "/test", nil, nil)
-	if c.compatWarned != prevWarned {
-		t.Error("compatWarned should remain true (no double warning)")
+	if !strings.Contains(warnings[0], "99.0.0") {
+		t.Errorf("warning = %q, want it to name the minimum version", warnings[0])
 	}
 }
 
@@ -451,10 +494,11 @@ func TestClient_Do_NoWarningWhenCompatible(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "0.2.0", false)
-	_, _, _ = c.Do(http.MethodGet, "/test", nil, nil)
-	if c.compatWarned {
-		t.Error("compatWarned should be false when CLI version is compatible")
+	warned := false
+	c := NewClient(srv.URL, WithToken("tok"), WithVersion("0.2.0"), WithWarningHandler(func(string) { warned = true }))
+	_, _, _ = c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
+	if warned {
+		t.Error("warning should not be reported when CLI version is compatible")
 	}
 }
 
@@ -467,8 +511,8 @@ func TestClient_Do_NoContentType_ForGetRequests(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "", "dev", false)
-	_, _, err := c.Do(http.MethodGet, "/test", nil, nil)
+	c := NewClient(srv.URL)
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
 	if err != nil {
 		t.Fatalf("Do() error: %v", err)
 	}
@@ -486,9 +530,8 @@ func TestClient_Do_NoCacheSendsCacheControlHeader(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	c.NoCache = true
-	_, _, err := c.Do(http.MethodGet, "/test", nil, nil)
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, _, err := c.Do(WithNoCache(context.Background()), http.MethodGet, "/test", nil, nil)
 	if err != nil {
 		t.Fatalf("Do() error: %v", err)
 	}
@@ -497,18 +540,54 @@ func TestClient_Do_NoCacheSendsCacheControlHeader(t *testing.T) {
 	}
 }
 
-func TestClient_Do_NoCacheResetAfterRequest(t *testing.T) {
+func TestClient_Do_NoCacheScopedToRequest(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Cache-Control"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, _, _ = c.Do(WithNoCache(context.Background()), http.MethodGet, "/test", nil, nil)
+	_, _, _ = c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
+	if len(got) != 2 || got[0] != "no-cache" || got[1] != "" {
+		t.Errorf("Cache-Control headers = %q, want [no-cache \"\"]", got)
+	}
+}
+
+func TestClient_Do_Concurrent(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Stompy-API-Version", "6.0.0")
+		w.Header().Set("X-Stompy-Min-CLI-Version", "99.0.0")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{}`))
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	c.NoCache = true
-	_, _, _ = c.Do(http.MethodGet, "/test", nil, nil)
-	if c.NoCache {
-		t.Error("NoCache should be reset to false after Do()")
+	var warnings atomic.Int32
+	c := NewClient(srv.URL, WithToken("tok"), WithVersion("0.2.0"), WithWarningHandler(func(string) {
+		warnings.Add(1)
+	}))
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			ctx := context.Background()
+			if i%2 == 0 {
+				ctx = WithNoCache(ctx)
+			}
+			if _, _, err := c.Do(ctx, http.MethodGet, "/test", nil, nil); err != nil {
+				t.Errorf("Do() error: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	if n := warnings.Load(); n != 1 {
+		t.Errorf("got %d warnings, want 1", n)
+	}
+	if c.APIVersion() != "6.0.0" {
+		t.Errorf("APIVersion() = %q, want %q", c.APIVersion(), "6.0.0")
 	}
 }
 
@@ -521,8 +600,8 @@ func TestClient_Do_NoCacheNotSetByDefault(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	_, _, err := c.Do(http.MethodGet, "/test", nil, nil)
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
 	if err != nil {
 		t.Fatalf("Do() error: %v", err)
 	}
@@ -530,3 +609,116 @@ func TestClient_Do_NoCacheNotSetByDefault(t *testing.T) {
 		t.Errorf("Cache-Control = %q, want empty (no-cache not set)", gotCacheControl)
 	}
 }
+
+func TestClient_Do_CancelledContextAbortsRetryBackoff(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := c.Do(ctx, http.MethodGet, "/test", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > retryBaseDelay {
+		t.Errorf("Do() took %s, want it to stop before the first backoff (%s) finished", elapsed, retryBaseDelay)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestClient_Do_CancelledContextAbortsRequest(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, _, err := c.Do(ctx, http.MethodGet, "/hang", nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestClient_Do_Retries429OnPostWithRetryAfter(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	start := time.Now()
+	_, code, err := c.Do(context.Background(), http.MethodPost, "/test", map[string]string{"k": "v"}, nil)
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	if code != http.StatusCreated {
+		t.Errorf("status = %d, want 201", code)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > retryBaseDelay/2 {
+		t.Errorf("Do() took %s, want Retry-After: 0 to skip backoff", elapsed)
+	}
+}
+
+func TestClient_Do_429WithLongRetryAfterFailsFast(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/test", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want APIError 429", err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestClient_Do_UsesRateLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	c.RateLimiter = NewRateLimiter(0.1, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := c.Do(ctx, http.MethodGet, "/a", nil, nil); err != nil {
+		t.Fatalf("first Do() error: %v", err)
+	}
+	if _, _, err := c.Do(ctx, http.MethodGet, "/b", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second Do() = %v, want to block on the limiter until the deadline", err)
+	}
+}
@@ -1,7 +1,9 @@
-package api
+package stompy
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"net/url"
 	"strconv"
 	"time"
@@ -46,7 +48,7 @@ type ConflictResolveRequest struct {
 }
 
 // ListConflicts fetches conflicts with optional status filter.
-func (c *Client) ListConflicts(project, status string, limit, offset int) (*ConflictListResponse, error) {
+func (c *Client) ListConflicts(ctx context.Context, project, status string, limit, offset int) (*ConflictListResponse, error) {
 	params := url.Values{}
 	if status != "" {
 		params.Set("status", status)
@@ -59,34 +61,45 @@ func (c *Client) ListConflicts(project, status string, limit, offset int) (*Conf
 	}
 
 	var resp ConflictListResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/conflicts", project), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/conflicts", project), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
+// AllConflicts iterates over every conflict with the given status, fetching pageSize at a time.
+func (c *Client) AllConflicts(ctx context.Context, project, status string, pageSize int) iter.Seq2[ConflictResponse, error] {
+	return Paginate(ctx, pageSize, func(ctx context.Context, limit, offset int) ([]ConflictResponse, int, error) {
+		resp, err := c.ListConflicts(ctx, project, status, limit, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Conflicts, resp.Total, nil
+	})
+}
+
 // GetConflict fetches a single conflict by ID.
-func (c *Client) GetConflict(project string, id int) (*ConflictResponse, error) {
+func (c *Client) GetConflict(ctx context.Context, project string, id int) (*ConflictResponse, error) {
 	var resp ConflictResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/conflicts/%d", project, id), nil, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/conflicts/%d", project, id), nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
 // DetectConflicts triggers conflict detection.
-func (c *Client) DetectConflicts(project string, req ConflictDetectRequest) (*ConflictDetectResponse, error) {
+func (c *Client) DetectConflicts(ctx context.Context, project string, req ConflictDetectRequest) (*ConflictDetectResponse, error) {
 	var resp ConflictDetectResponse
-	if err := c.Post(fmt.Sprintf("/projects/%s/conflicts/detect", project), req, &resp); err != nil {
+	if err := c.Post(ensureIdempotencyKey(ctx), fmt.Sprintf("/projects/%s/conflicts/detect", project), req, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
 // ResolveConflict resolves a conflict by ID.
-func (c *Client) ResolveConflict(project string, id int, req ConflictResolveRequest) (*ConflictResponse, error) {
+func (c *Client) ResolveConflict(ctx context.Context, project string, id int, req ConflictResolveRequest) (*ConflictResponse, error) {
 	var resp ConflictResponse
-	if err := c.Post(fmt.Sprintf("/projects/%s/conflicts/%d/resolve", project, id), req, &resp); err != nil {
+	if err := c.Post(ensureIdempotencyKey(ctx), fmt.Sprintf("/projects/%s/conflicts/%d/resolve", project, id), req, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
@@ -1,7 +1,9 @@
-package api
+package stompy
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"net/url"
 	"strconv"
 	"time"
@@ -69,7 +71,7 @@ type ContextListResponse struct {
 	Total    int               `json:"total"`
 }
 
-func (c *Client) ListContexts(project string, priority, tags string, limit, offset int) (*ContextListResponse, error) {
+func (c *Client) ListContexts(ctx context.Context, project string, priority, tags string, limit, offset int) (*ContextListResponse, error) {
 	params := url.Values{}
 	if priority != "" {
 		params.Set("priority", priority)
@@ -84,33 +86,44 @@ func (c *Client) ListContexts(project string, priority, tags string, limit, offs
 		params.Set("offset", strconv.Itoa(offset))
 	}
 	var resp ContextListResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/contexts", url.PathEscape(project)), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/contexts", url.PathEscape(project)), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) GetContext(project, topic string, version string) (*ContextDetailResponse, error) {
+// AllContexts iterates over every context matching the filters, fetching pageSize at a time.
+func (c *Client) AllContexts(ctx context.Context, project string, priority, tags string, pageSize int) iter.Seq2[ContextResponse, error] {
+	return Paginate(ctx, pageSize, func(ctx context.Context, limit, offset int) ([]ContextResponse, int, error) {
+		resp, err := c.ListContexts(ctx, project, priority, tags, limit, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Contexts, resp.Total, nil
+	})
+}
+
+func (c *Client) GetContext(ctx context.Context, project, topic string, version string) (*ContextDetailResponse, error) {
 	params := url.Values{}
 	if version != "" {
 		params.Set("version", version)
 	}
 	var resp ContextDetailResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/contexts/%s", url.PathEscape(project), url.PathEscape(topic)), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/contexts/%s", url.PathEscape(project), url.PathEscape(topic)), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) LockContext(project string, req ContextCreateRequest) (*ContextCreateResponse, error) {
+func (c *Client) LockContext(ctx context.Context, project string, req ContextCreateRequest) (*ContextCreateResponse, error) {
 	var resp ContextCreateResponse
-	if err := c.Post(fmt.Sprintf("/projects/%s/contexts", url.PathEscape(project)), req, &resp); err != nil {
+	if err := c.Post(ensureIdempotencyKey(ctx), fmt.Sprintf("/projects/%s/contexts", url.PathEscape(project)), req, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) UnlockContext(project, topic string, version string, force, noArchive bool) (*ContextDeleteResponse, error) {
+func (c *Client) UnlockContext(ctx context.Context, project, topic string, version string, force, noArchive bool) (*ContextDeleteResponse, error) {
 	params := url.Values{}
 	if version != "" {
 		params.Set("version", version)
@@ -123,37 +136,37 @@ func (c *Client) UnlockContext(project, topic string, version string, force, noA
 	}
 
 	var resp ContextDeleteResponse
-	if err := c.DeleteWithResult(fmt.Sprintf("/projects/%s/contexts/%s", url.PathEscape(project), url.PathEscape(topic)), params, &resp); err != nil {
+	if err := c.DeleteWithResult(ctx, fmt.Sprintf("/projects/%s/contexts/%s", url.PathEscape(project), url.PathEscape(topic)), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) UpdateContext(project, topic string, req ContextUpdateRequest) (*ContextResponse, error) {
+func (c *Client) UpdateContext(ctx context.Context, project, topic string, req ContextUpdateRequest) (*ContextResponse, error) {
 	var resp ContextResponse
-	if err := c.Put(fmt.Sprintf("/projects/%s/contexts/%s", url.PathEscape(project), url.PathEscape(topic)), req, &resp); err != nil {
+	if err := c.Put(ctx, fmt.Sprintf("/projects/%s/contexts/%s", url.PathEscape(project), url.PathEscape(topic)), req, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) SearchContexts(project, query string, limit int) (*ContextListResponse, error) {
+func (c *Client) SearchContexts(ctx context.Context, project, query string, limit int) (*ContextListResponse, error) {
 	params := url.Values{}
 	params.Set("search", query)
 	if limit > 0 {
 		params.Set("limit", strconv.Itoa(limit))
 	}
 	var resp ContextListResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/contexts", url.PathEscape(project)), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/contexts", url.PathEscape(project)), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) MoveContext(project, topic, targetProject string) (*ContextMoveResponse, error) {
+func (c *Client) MoveContext(ctx context.Context, project, topic, targetProject string) (*ContextMoveResponse, error) {
 	body := map[string]string{"target_project": targetProject}
 	var resp ContextMoveResponse
-	if err := c.Post(fmt.Sprintf("/projects/%s/contexts/%s/move", url.PathEscape(project), url.PathEscape(topic)), body, &resp); err != nil {
+	if err := c.Post(ensureIdempotencyKey(ctx), fmt.Sprintf("/projects/%s/contexts/%s/move", url.PathEscape(project), url.PathEscape(topic)), body, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
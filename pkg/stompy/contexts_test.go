@@ -1,6 +1,7 @@
-package api
+package stompy
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -31,8 +32,8 @@ func TestListContexts(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.ListContexts("myproj", "important", "", 10, 0)
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.ListContexts(context.Background(), "myproj", "important", "", 10, 0)
 	if err != nil {
 		t.Fatalf("ListContexts() error: %v", err)
 	}
@@ -58,8 +59,8 @@ func TestGetContext(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.GetContext("myproj", "arch_decisions", "")
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.GetContext(context.Background(), "myproj", "arch_decisions", "")
 	if err != nil {
 		t.Fatalf("GetContext() error: %v", err)
 	}
@@ -80,8 +81,8 @@ func TestGetContext_WithVersion(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.GetContext("proj", "t", "2.0")
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.GetContext(context.Background(), "proj", "t", "2.0")
 	if err != nil {
 		t.Fatalf("error: %v", err)
 	}
@@ -108,9 +109,9 @@ func TestLockContext(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
+	c := NewClient(srv.URL, WithToken("tok"))
 	req := ContextCreateRequest{Topic: "new_ctx", Content: "content here", Priority: "important"}
-	resp, err := c.LockContext("myproj", req)
+	resp, err := c.LockContext(context.Background(), "myproj", req)
 	if err != nil {
 		t.Fatalf("LockContext() error: %v", err)
 	}
@@ -139,8 +140,8 @@ func TestUnlockContext(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.UnlockContext("myproj", "old_ctx", "", true, false)
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.UnlockContext(context.Background(), "myproj", "old_ctx", "", true, false)
 	if err != nil {
 		t.Fatalf("UnlockContext() error: %v", err)
 	}
@@ -163,8 +164,8 @@ func TestUpdateContext(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.UpdateContext("proj", "ctx", ContextUpdateRequest{Priority: "always_check"})
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.UpdateContext(context.Background(), "proj", "ctx", ContextUpdateRequest{Priority: "always_check"})
 	if err != nil {
 		t.Fatalf("UpdateContext() error: %v", err)
 	}
@@ -185,8 +186,8 @@ func TestSearchContexts(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.SearchContexts("proj", "architecture", 0)
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.SearchContexts(context.Background(), "proj", "architecture", 0)
 	if err != nil {
 		t.Fatalf("SearchContexts() error: %v", err)
 	}
@@ -212,8 +213,8 @@ func TestMoveContext(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.MoveContext("proj", "ctx", "other")
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.MoveContext(context.Background(), "proj", "ctx", "other")
 	if err != nil {
 		t.Fatalf("MoveContext() error: %v", err)
 	}
@@ -0,0 +1,24 @@
+// Package stompy is a Go client for the Stompy API, used by the stompy CLI
+// and importable by other programs.
+//
+// Client covers the REST API: projects, contexts, tickets, files, bug
+// reports, conflicts and search. MCPClient speaks to the MCP endpoint and can
+// call any tool the server advertises. Both are configured with options:
+//
+//	c := stompy.NewClient("https://api.stompy.ai/api/v1",
+//		stompy.WithToken(os.Getenv("STOMPY_API_KEY")),
+//		stompy.WithRetries(4),
+//	)
+//	_, err := c.LockContext(ctx, "my-project", stompy.ContextCreateRequest{
+//		Topic:   "deploy-notes",
+//		Content: "Rolled out v2.3 to eu-west.",
+//	})
+//
+// Every method takes a context.Context, which bounds the request including
+// its retries. List endpoints have All* variants returning iterators that
+// fetch further pages on demand.
+//
+// Errors match one of the category sentinels (ErrNotFound, ErrUnauthorized,
+// ErrNetwork, ...) with errors.Is; *APIError, *RPCError and *NetworkError
+// carry the details.
+package stompy
@@ -0,0 +1,102 @@
+package stompy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error categories. Errors returned by Client and MCPClient match at most one
+// of these with errors.Is, so callers can branch on the kind of failure
+// without inspecting status codes or messages.
+var (
+	ErrUnauthorized = errors.New("not authenticated")
+	ErrForbidden    = errors.New("permission denied")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("invalid request")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrNetwork      = errors.New("network error")
+	ErrServer       = errors.New("server error")
+)
+
+// APIError is an error response from the REST API.
+type APIError struct {
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("API error %d: %s — %s", e.StatusCode, e.Message, e.Detail)
+	}
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
+}
+
+// Is reports whether the status code falls into the target category.
+func (e *APIError) Is(target error) bool {
+	return statusCategory(e.StatusCode) == target
+}
+
+func statusCategory(code int) error {
+	switch {
+	case code == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case code == http.StatusForbidden:
+		return ErrForbidden
+	case code == http.StatusNotFound || code == http.StatusGone:
+		return ErrNotFound
+	case code == http.StatusConflict || code == http.StatusPreconditionFailed:
+		return ErrConflict
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// RPCError is a JSON-RPC 2.0 error returned by the MCP endpoint.
+type RPCError struct {
+	Code    int
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("MCP error %d: %s", e.Code, e.Message)
+}
+
+// Is maps the standard JSON-RPC error codes onto the error categories.
+func (e *RPCError) Is(target error) bool {
+	switch e.Code {
+	case -32601: // method not found
+		return target == ErrNotFound
+	case -32700, -32600, -32602: // parse error, invalid request, invalid params
+		return target == ErrValidation
+	case -32603: // internal error
+		return target == ErrServer
+	}
+	return false
+}
+
+// NetworkError reports that a request never got an HTTP response: DNS,
+// connection, TLS or timeout failures. It matches ErrNetwork.
+type NetworkError struct {
+	Op  string // e.g. "executing request"
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+func (e *NetworkError) Is(target error) bool {
+	return target == ErrNetwork
+}
@@ -0,0 +1,83 @@
+package stompy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIError_Is(t *testing.T) {
+	tests := []struct {
+		code int
+		want error
+	}{
+		{400, ErrValidation},
+		{401, ErrUnauthorized},
+		{403, ErrForbidden},
+		{404, ErrNotFound},
+		{409, ErrConflict},
+		{422, ErrValidation},
+		{429, ErrRateLimited},
+		{500, ErrServer},
+		{503, ErrServer},
+	}
+	for _, tt := range tests {
+		err := error(&APIError{StatusCode: tt.code, Message: "x"})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("status %d: errors.Is(%v) = false", tt.code, tt.want)
+		}
+		if tt.want != ErrServer && errors.Is(err, ErrServer) {
+			t.Errorf("status %d unexpectedly matches ErrServer", tt.code)
+		}
+	}
+
+	if errors.Is(&APIError{StatusCode: 418}, ErrValidation) {
+		t.Error("418 should not be classified")
+	}
+}
+
+func TestRPCError_Is(t *testing.T) {
+	if !errors.Is(&RPCError{Code: -32601}, ErrNotFound) {
+		t.Error("-32601 should match ErrNotFound")
+	}
+	if !errors.Is(&RPCError{Code: -32602}, ErrValidation) {
+		t.Error("-32602 should match ErrValidation")
+	}
+	if !errors.Is(&RPCError{Code: -32603}, ErrServer) {
+		t.Error("-32603 should match ErrServer")
+	}
+	if errors.Is(&RPCError{Code: -32000}, ErrServer) {
+		t.Error("server-defined codes should not be classified")
+	}
+}
+
+func TestDo_NetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	// POST without an idempotency key is not retried, so this fails fast.
+	_, _, err := c.Do(context.Background(), http.MethodPost, "/x", nil, nil)
+	if !errors.Is(err, ErrNetwork) {
+		t.Fatalf("err = %v, want ErrNetwork", err)
+	}
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) || netErr.Op != "executing request" {
+		t.Errorf("err = %#v, want *NetworkError", err)
+	}
+}
+
+func TestDo_CancelledIsNotNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, _, err := c.Do(ctx, http.MethodPost, "/x", nil, nil)
+	if errors.Is(err, ErrNetwork) {
+		t.Errorf("cancelled request classified as network error: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package stompy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/banton/stompy-cli/pkg/stompy"
+)
+
+func Example() {
+	c := stompy.NewClient("https://api.stompy.ai/api/v1",
+		stompy.WithToken("sk-..."),
+		stompy.WithRetries(4),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	_, err := c.LockContext(ctx, "my-project", stompy.ContextCreateRequest{
+		Topic:   "deploy-notes",
+		Content: "Rolled out v2.3 to eu-west.",
+	})
+	switch {
+	case errors.Is(err, stompy.ErrConflict):
+		log.Print("topic already locked")
+	case err != nil:
+		log.Fatal(err)
+	}
+}
+
+func ExampleClient_AllTickets() {
+	c := stompy.NewClient("https://api.stompy.ai/api/v1", stompy.WithToken("sk-..."))
+
+	for t, err := range c.AllTickets(context.Background(), "my-project", "open", "", "", 0) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(t.ID, t.Title)
+	}
+}
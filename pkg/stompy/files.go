@@ -0,0 +1,128 @@
+package stompy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileResponse represents an uploaded file/document.
+type FileResponse struct {
+	ID        int               `json:"id"`
+	Filename  string            `json:"filename"`
+	Label     string            `json:"label,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	MimeType  string            `json:"mime_type,omitempty"`
+	SizeBytes int               `json:"size_bytes"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// FileListResponse wraps a list of files.
+type FileListResponse struct {
+	Files []FileResponse `json:"files"`
+	Total int            `json:"total"`
+}
+
+// ListFiles fetches files for a project with optional search.
+func (c *Client) ListFiles(ctx context.Context, project, search string, limit, offset int) (*FileListResponse, error) {
+	params := url.Values{}
+	if search != "" {
+		params.Set("search", search)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+
+	var resp FileListResponse
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/files", project), params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AllFiles iterates over every file matching search, fetching pageSize at a time.
+func (c *Client) AllFiles(ctx context.Context, project, search string, pageSize int) iter.Seq2[FileResponse, error] {
+	return Paginate(ctx, pageSize, func(ctx context.Context, limit, offset int) ([]FileResponse, int, error) {
+		resp, err := c.ListFiles(ctx, project, search, limit, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Files, resp.Total, nil
+	})
+}
+
+// GetFile fetches a single file by ID.
+func (c *Client) GetFile(ctx context.Context, project string, id int) (*FileResponse, error) {
+	var resp FileResponse
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/files/%d", project, id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ErrChecksumMismatch means downloaded content does not match the checksum
+// the server sent with it.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// DownloadFile streams the content of a file to w and returns the number of
+// bytes written. When the server sends an X-Checksum-SHA256 header the
+// content is verified against it; a mismatch is reported after the bytes
+// have been written, so callers writing to disk should discard the result.
+// Network errors and gateway failures are retried until the content starts
+// arriving; a download that breaks off after that is not restarted.
+func (c *Client) DownloadFile(ctx context.Context, project string, id int, w io.Writer) (int64, error) {
+	u := c.BaseURL + fmt.Sprintf("/projects/%s/files/%d/content", project, id)
+	if c.Verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] --> GET %s\n", u)
+	}
+
+	resp, err := c.sendBody(ctx, http.MethodGet, u, nil, 0, func() (io.ReadCloser, error) {
+		return http.NoBody, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return 0, responseError(resp.StatusCode, body)
+	}
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, hash), resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return n, fmt.Errorf("downloading file %d: %w", id, err)
+		}
+		return n, &NetworkError{Op: fmt.Sprintf("downloading file %d", id), Err: err}
+	}
+
+	if want := resp.Header.Get("X-Checksum-SHA256"); want != "" {
+		if got := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(got, want) {
+			return n, fmt.Errorf("%w: file %d has SHA-256 %s, server reported %s", ErrChecksumMismatch, id, got, want)
+		}
+		if c.Verbose {
+			fmt.Fprintf(os.Stderr, "[DEBUG]     Verified SHA-256 %s\n", want)
+		}
+	}
+	return n, nil
+}
+
+// DeleteFile deletes a file by ID.
+func (c *Client) DeleteFile(ctx context.Context, project string, id int) error {
+	return c.Delete(ctx, fmt.Sprintf("/projects/%s/files/%d", project, id), nil)
+}
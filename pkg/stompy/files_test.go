@@ -0,0 +1,80 @@
+package stompy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownloadFile(t *testing.T) {
+	checksum := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" // sha256("hello")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/projects/p/files/1/content":
+			w.Header().Set("X-Checksum-SHA256", checksum)
+			w.Write([]byte("hello"))
+		case "/projects/p/files/2/content":
+			w.Header().Set("X-Checksum-SHA256", checksum)
+			w.Write([]byte("jello"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "file not found"}`))
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, WithToken("token"))
+
+	var buf bytes.Buffer
+	n, err := c.DownloadFile(context.Background(), "p", 1, &buf)
+	if err != nil {
+		t.Fatalf("DownloadFile() error: %v", err)
+	}
+	if n != 5 || buf.String() != "hello" {
+		t.Errorf("got %d bytes %q, want hello", n, buf.String())
+	}
+
+	if _, err := c.DownloadFile(context.Background(), "p", 2, &bytes.Buffer{}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("corrupted download error = %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := c.DownloadFile(context.Background(), "p", 3, &bytes.Buffer{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing file error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDownloadFile_RetriesBeforeContent(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			hj, ok := w.(http.Hijacker)
+			if !ok {
+				t.Fatal("server does not support hijacking")
+			}
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("hello"))
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, WithToken("token"))
+
+	var buf bytes.Buffer
+	n, err := c.DownloadFile(context.Background(), "p", 1, &buf)
+	if err != nil {
+		t.Fatalf("DownloadFile() error: %v", err)
+	}
+	if n != 5 || buf.String() != "hello" {
+		t.Errorf("got %d bytes %q, want hello", n, buf.String())
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
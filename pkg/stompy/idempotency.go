@@ -0,0 +1,55 @@
+package stompy
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey returns a copy of ctx whose POST requests carry key in the
+// Idempotency-Key header. Scripts use it to dedupe a mutation across separate
+// CLI invocations; within one invocation, retries always reuse the same key.
+// Operations made of several POSTs, such as file uploads, derive a key per
+// request from it (key + ":" + step) so the server dedupes each on its own.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// idempotencyKey returns the key attached to ctx, or "" if none.
+func idempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+	return key
+}
+
+// ensureIdempotencyKey marks ctx as one logical operation: it keeps a
+// caller-supplied key, or attaches a freshly generated one.
+func ensureIdempotencyKey(ctx context.Context) context.Context {
+	if idempotencyKey(ctx) != "" {
+		return ctx
+	}
+	return WithIdempotencyKey(ctx, newIdempotencyKey())
+}
+
+// idempotencyStep scopes a caller-supplied key in ctx to one step of a larger
+// operation. Without a key ctx is returned as is, and each step gets its own
+// random key from ensureIdempotencyKey.
+func idempotencyStep(ctx context.Context, step string) context.Context {
+	if key := idempotencyKey(ctx); key != "" {
+		return WithIdempotencyKey(ctx, key+":"+step)
+	}
+	return ctx
+}
+
+// newIdempotencyKey returns a random RFC 4122 version 4 UUID.
+func newIdempotencyKey() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
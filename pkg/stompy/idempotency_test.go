@@ -0,0 +1,146 @@
+package stompy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNewIdempotencyKey_Format(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	k1, k2 := newIdempotencyKey(), newIdempotencyKey()
+	if !uuidV4.MatchString(k1) {
+		t.Errorf("key %q is not a v4 UUID", k1)
+	}
+	if k1 == k2 {
+		t.Error("two generated keys are identical")
+	}
+}
+
+func TestEnsureIdempotencyKey_KeepsOverride(t *testing.T) {
+	ctx := WithIdempotencyKey(context.Background(), "script-key")
+	if got := idempotencyKey(ensureIdempotencyKey(ctx)); got != "script-key" {
+		t.Errorf("key = %q, want script-key", got)
+	}
+	if got := idempotencyKey(ensureIdempotencyKey(context.Background())); got == "" {
+		t.Error("ensureIdempotencyKey did not attach a key")
+	}
+}
+
+func TestClient_Do_PostWithIdempotencyKeyRetriesWithSameKey(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	ctx := WithIdempotencyKey(context.Background(), "abc-123")
+	if _, _, err := c.Do(ctx, http.MethodPost, "/test", map[string]string{"k": "v"}, nil); err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(keys))
+	}
+	if keys[0] != "abc-123" || keys[1] != "abc-123" {
+		t.Errorf("Idempotency-Key headers = %v, want abc-123 on every attempt", keys)
+	}
+}
+
+func TestClient_Do_NoIdempotencyKeyOnGet(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Idempotency-Key")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	ctx := WithIdempotencyKey(context.Background(), "abc-123")
+	if _, _, err := c.Do(ctx, http.MethodGet, "/test", nil, nil); err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	if got != "" {
+		t.Errorf("Idempotency-Key on GET = %q, want empty", got)
+	}
+}
+
+func TestCreateTicket_SendsIdempotencyKey(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Idempotency-Key")
+		json.NewEncoder(w).Encode(TicketResponse{ID: 1})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	if _, err := c.CreateTicket(context.Background(), "proj", TicketCreate{Title: "x"}); err != nil {
+		t.Fatalf("CreateTicket() error: %v", err)
+	}
+	if got == "" {
+		t.Error("CreateTicket did not send an Idempotency-Key header")
+	}
+}
+
+func TestUploadFile_ScopesIdempotencyKeyPerRequest(t *testing.T) {
+	keys := map[string]string{} // POST path → Idempotency-Key
+	var offset int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			keys[r.URL.Path] = r.Header.Get("Idempotency-Key")
+			if strings.HasSuffix(r.URL.Path, "/uploads") {
+				json.NewEncoder(w).Encode(UploadSession{ID: "u1"})
+				return
+			}
+			io.Copy(io.Discard, r.Body)
+			json.NewEncoder(w).Encode(FileResponse{ID: 1})
+		case r.Method == http.MethodPut:
+			n, _ := io.Copy(io.Discard, r.Body)
+			offset += n
+			json.NewEncoder(w).Encode(UploadSession{ID: "u1", Offset: offset})
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	big, small := filepath.Join(dir, "big.bin"), filepath.Join(dir, "small.txt")
+	if err := os.WriteFile(big, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(small, []byte("hi"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	ctx := WithIdempotencyKey(context.Background(), "K")
+	if _, err := c.UploadFile(ctx, "p", big, UploadOptions{ChunkSize: 4}); err != nil {
+		t.Fatalf("UploadFile(big) error: %v", err)
+	}
+	if _, err := c.UploadFile(ctx, "p", small, UploadOptions{}); err != nil {
+		t.Fatalf("UploadFile(small) error: %v", err)
+	}
+
+	want := map[string]string{
+		"/projects/p/uploads":             "K:big.bin:create",
+		"/projects/p/uploads/u1/complete": "K:big.bin:complete",
+		"/projects/p/files":               "K:small.txt",
+	}
+	for path, key := range want {
+		if keys[path] != key {
+			t.Errorf("Idempotency-Key for POST %s = %q, want %q", path, keys[path], key)
+		}
+	}
+}
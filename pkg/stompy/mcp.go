@@ -0,0 +1,384 @@
+package stompy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/banton/stompy-cli/internal/httpclient"
+)
+
+// MCPClient speaks the MCP Streamable HTTP transport to the Stompy MCP
+// endpoint. The initialize handshake runs lazily before the first call and its
+// session is reused for every later call made through the same client.
+type MCPClient struct {
+	BaseURL    string // e.g., "https://api.stompy.ai/mcp"
+	AuthToken  string
+	Tokens     TokenSource // overrides AuthToken and enables refresh on 401
+	UserAgent  string
+	Version    string // CLI version, sent as clientInfo.version
+	HTTPClient *http.Client
+	MaxRetries int // retries for 429 responses
+	Verbose    bool
+	nextID     int64
+
+	// RateLimiter, when set, throttles every outgoing request (including retries).
+	RateLimiter *RateLimiter
+
+	initMu    sync.Mutex // serializes the initialize handshake
+	mu        sync.Mutex // guards the session fields below
+	session   *MCPInitializeResult
+	sessionID string // Mcp-Session-Id assigned by the server, if any
+	tools     []MCPTool
+}
+
+// jsonRPCRequest is a JSON-RPC 2.0 request envelope.
+type jsonRPCRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// jsonRPCNotification is a JSON-RPC 2.0 request that expects no response.
+type jsonRPCNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// jsonRPCResponse is a JSON-RPC 2.0 response envelope.
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// mcpToolResult is the MCP tools/call result envelope.
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// NewMCPClient creates a new MCP client.
+// mcpURL should be the full MCP endpoint (e.g., "https://api.stompy.ai/mcp").
+func NewMCPClient(mcpURL string, opts ...Option) *MCPClient {
+	o := newOptions(opts)
+	hc := o.httpClient
+	if hc == nil {
+		hc = &http.Client{
+			Timeout:   60 * time.Second, // MCP tools may take longer than REST
+			Transport: httpclient.Transport(),
+		}
+	}
+	return &MCPClient{
+		BaseURL:     strings.TrimRight(mcpURL, "/"),
+		AuthToken:   o.token,
+		Tokens:      o.tokens,
+		UserAgent:   o.userAgent,
+		Version:     o.version,
+		HTTPClient:  hc,
+		MaxRetries:  o.maxRetries,
+		Verbose:     o.verbose,
+		RateLimiter: o.limiter,
+	}
+}
+
+// MCPBaseURL derives the MCP endpoint URL from a REST base URL.
+// "https://api.stompy.ai/api/v1" → "https://api.stompy.ai/mcp"
+func MCPBaseURL(restBaseURL string) string {
+	u := strings.TrimRight(restBaseURL, "/")
+	if idx := strings.Index(u, "/api/v1"); idx != -1 {
+		u = u[:idx]
+	}
+	return u + "/mcp"
+}
+
+// CallTool sends a tools/call JSON-RPC request and returns the text content.
+func (m *MCPClient) CallTool(ctx context.Context, toolName string, arguments map[string]any) (string, error) {
+	var toolResult mcpToolResult
+	err := m.Call(ctx, "tools/call", map[string]any{
+		"name":      toolName,
+		"arguments": arguments,
+	}, &toolResult)
+	if err != nil {
+		return "", err
+	}
+
+	if toolResult.IsError {
+		if len(toolResult.Content) > 0 {
+			return "", fmt.Errorf("MCP tool error: %s", toolResult.Content[0].Text)
+		}
+		return "", fmt.Errorf("MCP tool returned an error")
+	}
+
+	// Concatenate all text content items
+	var texts []string
+	for _, c := range toolResult.Content {
+		if c.Type == "text" {
+			texts = append(texts, c.Text)
+		}
+	}
+
+	return strings.Join(texts, "\n"), nil
+}
+
+// CallToolTyped calls a tool and unmarshals the JSON text response into dest.
+func (m *MCPClient) CallToolTyped(ctx context.Context, toolName string, arguments map[string]any, dest any) error {
+	text, err := m.CallTool(ctx, toolName, arguments)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(text), dest); err != nil {
+		return fmt.Errorf("decoding tool response as JSON: %w (raw: %.200s)", err, text)
+	}
+	return nil
+}
+
+// Call sends a JSON-RPC request within the client's session, initializing it
+// first if needed, and decodes the result into result (if non-nil). A session
+// the server has expired (404) is renegotiated once.
+func (m *MCPClient) Call(ctx context.Context, method string, params, result any) error {
+	if _, err := m.Initialize(ctx); err != nil {
+		return err
+	}
+
+	raw, err := m.roundTrip(ctx, method, params)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && m.resetSession() {
+		if m.Verbose {
+			fmt.Fprintln(os.Stderr, "[DEBUG]     MCP session expired; reinitializing")
+		}
+		if _, err := m.Initialize(ctx); err != nil {
+			return err
+		}
+		raw, err = m.roundTrip(ctx, method, params)
+	}
+	if err != nil {
+		return err
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(raw, result); err != nil {
+			return fmt.Errorf("decoding MCP %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// roundTrip sends one JSON-RPC request and returns its raw result. The server
+// may answer with a single JSON body or a text/event-stream.
+func (m *MCPClient) roundTrip(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := atomic.AddInt64(&m.nextID, 1)
+	reqBytes, err := json.Marshal(jsonRPCRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling MCP request: %w", err)
+	}
+
+	if m.Verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] --> MCP POST %s (%s)\n", m.BaseURL, describeRPC(method, params))
+		preview := string(reqBytes)
+		if len(preview) > 300 {
+			preview = preview[:300] + "..."
+		}
+		fmt.Fprintf(os.Stderr, "[DEBUG]     Body: %s\n", preview)
+	}
+
+	resp, err := m.post(ctx, reqBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBytes, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("MCP endpoint returned %d: %s", resp.StatusCode, string(respBytes)),
+		}
+	}
+
+	if sid := resp.Header.Get("Mcp-Session-Id"); sid != "" && method == "initialize" {
+		m.mu.Lock()
+		m.sessionID = sid
+		m.mu.Unlock()
+	}
+
+	var rpcResp *jsonRPCResponse
+	if isEventStream(resp.Header) {
+		rpcResp, err = readSSEResponse(resp.Body, id, m.Verbose)
+	} else {
+		rpcResp, err = readJSONResponse(resp.Body, m.Verbose)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rpcResp.Error != nil {
+		return nil, &RPCError{Code: rpcResp.Error.Code, Message: rpcResp.Error.Message}
+	}
+	return rpcResp.Result, nil
+}
+
+// Notify sends a JSON-RPC notification within the client's session,
+// initializing it first if needed.
+func (m *MCPClient) Notify(ctx context.Context, method string, params any) error {
+	if _, err := m.Initialize(ctx); err != nil {
+		return err
+	}
+	return m.notify(ctx, method, params)
+}
+
+// notify sends a JSON-RPC notification; the server acknowledges with 202.
+func (m *MCPClient) notify(ctx context.Context, method string, params any) error {
+	reqBytes, err := json.Marshal(jsonRPCNotification{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("marshaling MCP notification: %w", err)
+	}
+	if m.Verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] --> MCP POST %s (%s)\n", m.BaseURL, method)
+	}
+
+	resp, err := m.post(ctx, reqBytes)
+	if err != nil {
+		return err
+	}
+	drain(resp)
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("MCP endpoint returned %d for %s", resp.StatusCode, method)}
+	}
+	return nil
+}
+
+// post sends a JSON-RPC payload to the MCP endpoint, retrying when the server
+// responds 429 Too Many Requests and replaying once with a refreshed token on
+// 401. JSON-RPC calls are not otherwise retried since tool invocations may
+// have side effects. The caller must close the returned response body.
+func (m *MCPClient) post(ctx context.Context, reqBytes []byte) (*http.Response, error) {
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		if m.RateLimiter != nil {
+			if err := m.RateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.BaseURL, bytes.NewReader(reqBytes))
+		if err != nil {
+			return nil, fmt.Errorf("creating MCP request: %w", err)
+		}
+
+		token, err := currentToken(m.Tokens, m.AuthToken)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		req.Header.Set("User-Agent", m.UserAgent)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		m.setSessionHeaders(req.Header)
+
+		start := time.Now()
+		resp, err := m.HTTPClient.Do(req)
+		elapsed := time.Since(start)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("executing MCP request: %w", err)
+			}
+			return nil, &NetworkError{Op: "executing MCP request", Err: err}
+		}
+
+		if m.Verbose {
+			detail := elapsed.String()
+			if ct := resp.Header.Get("Content-Type"); ct != "" {
+				detail += ", " + ct
+			}
+			fmt.Fprintf(os.Stderr, "[DEBUG] <-- %d %s (%s)\n", resp.StatusCode, http.StatusText(resp.StatusCode), detail)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && !refreshed {
+			refreshed = true
+			if refreshToken(ctx, m.Tokens, token, m.Verbose) {
+				// The replay does not count against the retry budget
+				drain(resp)
+				attempt--
+				continue
+			}
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < m.MaxRetries {
+			if delay, ok := retryDelay(attempt+1, resp.Header); ok {
+				drain(resp)
+				if m.Verbose {
+					fmt.Fprintf(os.Stderr, "[DEBUG]     Retry %d/%d after %s\n", attempt+1, m.MaxRetries, delay)
+				}
+				if err := sleepCtx(ctx, delay); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+// drain discards and closes a response body so the connection can be reused.
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+	resp.Body.Close()
+}
+
+// readJSONResponse decodes a single JSON-RPC response body.
+func readJSONResponse(body io.Reader, verbose bool) (*jsonRPCResponse, error) {
+	respBytes, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("reading MCP response: %w", err)
+	}
+	if verbose {
+		preview := string(respBytes)
+		if len(preview) > 300 {
+			preview = preview[:300] + "..."
+		}
+		fmt.Fprintf(os.Stderr, "[DEBUG]     Body: %s\n", preview)
+	}
+
+	var rpcResp jsonRPCResponse
+	if err := json.Unmarshal(respBytes, &rpcResp); err != nil {
+		return nil, fmt.Errorf("decoding MCP response: %w", err)
+	}
+	return &rpcResp, nil
+}
+
+// describeRPC summarizes a request for verbose logs, e.g. "tools/call: project_brief".
+func describeRPC(method string, params any) string {
+	if p, ok := params.(map[string]any); ok {
+		if name, ok := p["name"].(string); ok {
+			return method + ": " + name
+		}
+	}
+	return method
+}
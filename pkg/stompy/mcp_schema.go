@@ -0,0 +1,265 @@
+package stompy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ToolSchema is the subset of JSON Schema used to describe MCP tool inputs:
+// enough to validate arguments and coerce command-line strings to the types a
+// tool expects.
+type ToolSchema struct {
+	Type                 SchemaType             `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*ToolSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *ToolSchema            `json:"items,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	AdditionalProperties *bool                  `json:"-"`
+}
+
+// SchemaType holds a JSON Schema "type", which may be a single name or a list
+// such as ["string", "null"].
+type SchemaType []string
+
+func (t *SchemaType) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = SchemaType{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return fmt.Errorf("schema type must be a string or list of strings: %w", err)
+	}
+	*t = many
+	return nil
+}
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// String renders the type for help output, e.g. "string" or "string|null".
+func (t SchemaType) String() string {
+	if len(t) == 0 {
+		return "any"
+	}
+	return strings.Join(t, "|")
+}
+
+func (t SchemaType) has(name string) bool {
+	return len(t) == 0 || slices.Contains(t, name)
+}
+
+func (s *ToolSchema) UnmarshalJSON(b []byte) error {
+	type plain ToolSchema
+	var raw struct {
+		plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*s = ToolSchema(raw.plain)
+	// additionalProperties may be a schema; only an explicit false restricts.
+	if string(raw.AdditionalProperties) == "false" {
+		f := false
+		s.AdditionalProperties = &f
+	}
+	return nil
+}
+
+// Schema parses the tool's input schema. Tools without one accept any object.
+func (t *MCPTool) Schema() (*ToolSchema, error) {
+	s := &ToolSchema{}
+	if len(t.InputSchema) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(t.InputSchema, s); err != nil {
+		return nil, fmt.Errorf("parsing input schema of MCP tool %q: %w", t.Name, err)
+	}
+	return s, nil
+}
+
+// PropertyNames returns the schema's property names, required ones first.
+func (s *ToolSchema) PropertyNames() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		ra, rb := slices.Contains(s.Required, a), slices.Contains(s.Required, b)
+		switch {
+		case ra && !rb:
+			return -1
+		case rb && !ra:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	return names
+}
+
+// Coerce converts a command-line string to the type the property named key
+// declares. Properties the schema does not describe stay strings.
+func (s *ToolSchema) Coerce(key, value string) (any, error) {
+	prop, ok := s.Properties[key]
+	if !ok {
+		return value, nil
+	}
+	v, err := prop.coerce(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: argument %q: %w", ErrValidation, key, err)
+	}
+	return v, nil
+}
+
+func (s *ToolSchema) coerce(value string) (any, error) {
+	if value == "null" && s.Type.has("null") && len(s.Type) > 0 {
+		return nil, nil
+	}
+	// Try the declared types in a fixed order so "1" becomes a number before
+	// it falls back to a string.
+	for _, typ := range []string{"boolean", "integer", "number", "array", "object", "string"} {
+		if len(s.Type) > 0 && !s.Type.has(typ) {
+			continue
+		}
+		switch typ {
+		case "boolean":
+			if b, err := strconv.ParseBool(value); err == nil {
+				return b, nil
+			}
+		case "integer":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return n, nil
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return f, nil
+			}
+		case "array":
+			if strings.HasPrefix(strings.TrimSpace(value), "[") {
+				var arr []any
+				if err := json.Unmarshal([]byte(value), &arr); err != nil {
+					return nil, fmt.Errorf("invalid JSON array: %w", err)
+				}
+				return arr, nil
+			}
+			// Comma-separated list, items coerced to the item type; an
+			// empty value is an empty list, not null
+			arr := []any{}
+			if value != "" {
+				for _, part := range strings.Split(value, ",") {
+					item := any(strings.TrimSpace(part))
+					if s.Items != nil {
+						v, err := s.Items.coerce(strings.TrimSpace(part))
+						if err != nil {
+							return nil, err
+						}
+						item = v
+					}
+					arr = append(arr, item)
+				}
+			}
+			return arr, nil
+		case "object":
+			var obj map[string]any
+			if err := json.Unmarshal([]byte(value), &obj); err == nil {
+				return obj, nil
+			}
+		case "string":
+			return value, nil
+		}
+	}
+	if len(s.Type) == 0 {
+		return value, nil
+	}
+	return nil, fmt.Errorf("cannot convert %q to %s", value, s.Type)
+}
+
+// Validate checks args against the schema: required properties, declared
+// types, enums and, when additionalProperties is false, unknown keys. All
+// problems are reported together; the error matches ErrValidation.
+func (s *ToolSchema) Validate(args map[string]any) error {
+	var problems []string
+	for _, name := range s.Required {
+		if _, ok := args[name]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required argument %q", name))
+		}
+	}
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		prop, ok := s.Properties[k]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				problems = append(problems, fmt.Sprintf("unknown argument %q (accepted: %s)", k, strings.Join(s.PropertyNames(), ", ")))
+			}
+			continue
+		}
+		problems = append(problems, prop.check(k, args[k])...)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrValidation, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// check validates a single value, returning human-readable problems.
+func (s *ToolSchema) check(path string, v any) []string {
+	if !s.Type.has(jsonType(v)) && !(jsonType(v) == "integer" && s.Type.has("number")) {
+		return []string{fmt.Sprintf("argument %q must be %s, got %s", path, s.Type, jsonType(v))}
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return equalJSON(e, v) }) {
+		return []string{fmt.Sprintf("argument %q must be one of %v", path, s.Enum)}
+	}
+	var problems []string
+	if arr, ok := v.([]any); ok && s.Items != nil {
+		for i, item := range arr {
+			problems = append(problems, s.Items.check(fmt.Sprintf("%s[%d]", path, i), item)...)
+		}
+	}
+	return problems
+}
+
+// jsonType names the JSON Schema type of a decoded JSON value.
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case int, int64:
+		return "integer"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func equalJSON(a, b any) bool {
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	return errors.Join(errA, errB) == nil && string(ab) == string(bb)
+}
@@ -0,0 +1,95 @@
+package stompy
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testToolSchema = `{
+	"type": "object",
+	"properties": {
+		"project": {"type": "string"},
+		"limit":   {"type": "integer"},
+		"score":   {"type": "number"},
+		"verbose": {"type": "boolean"},
+		"topics":  {"type": "array", "items": {"type": "string"}},
+		"ids":     {"type": "array", "items": {"type": "integer"}},
+		"filter":  {"type": "object"},
+		"detail":  {"type": "string", "enum": ["summary", "verbose"]},
+		"note":    {"type": ["string", "null"]}
+	},
+	"required": ["project"],
+	"additionalProperties": false
+}`
+
+func testSchema(t *testing.T) *ToolSchema {
+	t.Helper()
+	tool := MCPTool{Name: "test", InputSchema: json.RawMessage(testToolSchema)}
+	s, err := tool.Schema()
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+	return s
+}
+
+func TestToolSchema_Coerce(t *testing.T) {
+	s := testSchema(t)
+	cases := []struct {
+		key, value string
+		want       any
+	}{
+		{"project", "42", "42"},
+		{"limit", "10", int64(10)},
+		{"score", "0.5", 0.5},
+		{"verbose", "true", true},
+		{"topics", "a, b", []any{"a", "b"}},
+		{"topics", `["x"]`, []any{"x"}},
+		{"topics", "", []any{}},
+		{"ids", "1,2", []any{int64(1), int64(2)}},
+		{"filter", `{"k":1}`, map[string]any{"k": float64(1)}},
+		{"note", "null", nil},
+		{"undeclared", "7", "7"},
+	}
+	for _, tc := range cases {
+		got, err := s.Coerce(tc.key, tc.value)
+		if err != nil {
+			t.Errorf("Coerce(%q, %q) failed: %v", tc.key, tc.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Coerce(%q, %q) = %#v, want %#v", tc.key, tc.value, got, tc.want)
+		}
+	}
+
+	if _, err := s.Coerce("limit", "ten"); !errors.Is(err, ErrValidation) {
+		t.Errorf("Coerce(limit, ten) error = %v, want ErrValidation", err)
+	}
+}
+
+func TestToolSchema_Validate(t *testing.T) {
+	s := testSchema(t)
+
+	if err := s.Validate(map[string]any{"project": "p", "limit": float64(3), "detail": "summary"}); err != nil {
+		t.Errorf("valid args rejected: %v", err)
+	}
+
+	err := s.Validate(map[string]any{"limit": "x", "detail": "full", "bogus": 1})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("error = %v, want ErrValidation", err)
+	}
+	for _, want := range []string{`missing required argument "project"`, `"limit" must be integer`, `"detail" must be one of`, `unknown argument "bogus"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestToolSchema_PropertyNames(t *testing.T) {
+	got := testSchema(t).PropertyNames()
+	if got[0] != "project" || got[1] != "detail" {
+		t.Errorf("PropertyNames = %v, want required first then alphabetical", got)
+	}
+}
@@ -0,0 +1,210 @@
+package stompy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// MCPProtocolVersion is the newest MCP protocol revision the client speaks.
+const MCPProtocolVersion = "2025-06-18"
+
+// supportedMCPVersions lists every revision the client accepts from a server,
+// newest first.
+var supportedMCPVersions = []string{MCPProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// MCPInitializeResult is the server's answer to the initialize handshake.
+type MCPInitializeResult struct {
+	ProtocolVersion string            `json:"protocolVersion"`
+	Capabilities    map[string]any    `json:"capabilities,omitempty"`
+	ServerInfo      MCPImplementation `json:"serverInfo"`
+	Instructions    string            `json:"instructions,omitempty"`
+}
+
+// MCPImplementation names an MCP client or server.
+type MCPImplementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// MCPTool describes a tool advertised by tools/list.
+type MCPTool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// Initialize performs the initialize / notifications/initialized handshake if
+// it has not happened yet and returns the negotiated session. It is called
+// automatically by Call; calling it directly is only needed to inspect the
+// server's capabilities.
+func (m *MCPClient) Initialize(ctx context.Context) (*MCPInitializeResult, error) {
+	m.initMu.Lock()
+	defer m.initMu.Unlock()
+
+	m.mu.Lock()
+	session := m.session
+	m.mu.Unlock()
+	if session != nil {
+		return session, nil
+	}
+
+	version := m.Version
+	if version == "" {
+		version = "dev"
+	}
+	raw, err := m.roundTrip(ctx, "initialize", map[string]any{
+		"protocolVersion": MCPProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      MCPImplementation{Name: "stompy-cli", Version: version},
+	})
+
+	// Servers predating the handshake reject it; fall back to sessionless calls.
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == -32601 {
+		if m.Verbose {
+			fmt.Fprintln(os.Stderr, "[DEBUG]     MCP server does not support initialize; continuing without a session")
+		}
+		session = &MCPInitializeResult{}
+		m.mu.Lock()
+		m.session = session
+		m.mu.Unlock()
+		return session, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("initializing MCP session: %w", err)
+	}
+
+	session = &MCPInitializeResult{}
+	if err := json.Unmarshal(raw, session); err != nil {
+		return nil, fmt.Errorf("decoding MCP initialize result: %w", err)
+	}
+	if !slices.Contains(supportedMCPVersions, session.ProtocolVersion) {
+		m.resetSession()
+		return nil, fmt.Errorf("MCP server negotiated unsupported protocol version %q (supported: %v)", session.ProtocolVersion, supportedMCPVersions)
+	}
+
+	m.mu.Lock()
+	m.session = session
+	m.mu.Unlock()
+
+	if err := m.notify(ctx, "notifications/initialized", nil); err != nil {
+		m.resetSession()
+		return nil, fmt.Errorf("initializing MCP session: %w", err)
+	}
+
+	if m.Verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG]     MCP session ready (protocol %s, server %s %s)\n",
+			session.ProtocolVersion, session.ServerInfo.Name, session.ServerInfo.Version)
+	}
+	return session, nil
+}
+
+// ListTools returns the tools advertised by the server. The list, including
+// input schemas, is fetched once per session and cached.
+func (m *MCPClient) ListTools(ctx context.Context) ([]MCPTool, error) {
+	m.mu.Lock()
+	cached := m.tools
+	m.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	tools := []MCPTool{}
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []MCPTool `json:"tools"`
+			NextCursor string    `json:"nextCursor,omitempty"`
+		}
+		if err := m.Call(ctx, "tools/list", params, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	m.mu.Lock()
+	m.tools = tools
+	m.mu.Unlock()
+	return tools, nil
+}
+
+// Tool returns the named tool from ListTools.
+func (m *MCPClient) Tool(ctx context.Context, name string) (*MCPTool, error) {
+	tools, err := m.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tools {
+		if tools[i].Name == name {
+			return &tools[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown MCP tool %q: %w", name, ErrNotFound)
+}
+
+// Close ends the session on the server. It is a no-op when no session was
+// established; servers that do not support explicit termination are ignored.
+func (m *MCPClient) Close(ctx context.Context) error {
+	m.mu.Lock()
+	sessionID := m.sessionID
+	m.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, m.BaseURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating MCP request: %w", err)
+	}
+	token, err := currentToken(m.Tokens, m.AuthToken)
+	if err == nil && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("User-Agent", m.UserAgent)
+	m.setSessionHeaders(req.Header)
+	m.resetSession()
+
+	resp, err := m.HTTPClient.Do(req)
+	if err != nil {
+		return &NetworkError{Op: "closing MCP session", Err: err}
+	}
+	drain(resp)
+	return nil
+}
+
+// setSessionHeaders adds the session ID and negotiated protocol version, once
+// known, to an outgoing request.
+func (m *MCPClient) setSessionHeaders(h http.Header) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessionID != "" {
+		h.Set("Mcp-Session-Id", m.sessionID)
+	}
+	if m.session != nil && m.session.ProtocolVersion != "" {
+		h.Set("MCP-Protocol-Version", m.session.ProtocolVersion)
+	}
+}
+
+// resetSession forgets the current session, reporting whether there was a
+// server-assigned session to forget.
+func (m *MCPClient) resetSession() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	had := m.sessionID != ""
+	m.session, m.sessionID, m.tools = nil, "", nil
+	return had
+}
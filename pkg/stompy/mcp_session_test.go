@@ -0,0 +1,208 @@
+package stompy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMCPClient_SessionLifecycle(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			methods = append(methods, "DELETE "+r.Header.Get("Mcp-Session-Id"))
+			return
+		}
+		var req jsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		methods = append(methods, req.Method)
+
+		if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			t.Errorf("Accept = %q, want text/event-stream included", r.Header.Get("Accept"))
+		}
+		if req.Method != "initialize" {
+			if got := r.Header.Get("Mcp-Session-Id"); got != "abc" {
+				t.Errorf("%s: Mcp-Session-Id = %q, want abc", req.Method, got)
+			}
+			if got := r.Header.Get("MCP-Protocol-Version"); got != "2025-03-26" {
+				t.Errorf("%s: MCP-Protocol-Version = %q, want 2025-03-26", req.Method, got)
+			}
+		}
+
+		switch req.Method {
+		case "initialize":
+			params := req.Params.(map[string]any)
+			if params["protocolVersion"] != MCPProtocolVersion {
+				t.Errorf("protocolVersion = %v", params["protocolVersion"])
+			}
+			w.Header().Set("Mcp-Session-Id", "abc")
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{
+				"protocolVersion": "2025-03-26",
+				"serverInfo":      map[string]any{"name": "stompy", "version": "6.0.0"},
+			}})
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{
+				"content": []map[string]any{{"type": "text", "text": "ok"}},
+			}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"), WithVersion("1.2.3"))
+	for range 2 {
+		if _, err := client.CallTool(context.Background(), "project_brief", nil); err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+	}
+	session, err := client.Initialize(context.Background())
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if session.ServerInfo.Name != "stompy" || session.ProtocolVersion != "2025-03-26" {
+		t.Errorf("unexpected session: %+v", session)
+	}
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	want := []string{"initialize", "notifications/initialized", "tools/call", "tools/call", "DELETE abc"}
+	if fmt.Sprint(methods) != fmt.Sprint(want) {
+		t.Errorf("methods = %v, want %v", methods, want)
+	}
+}
+
+func TestMCPClient_UnsupportedProtocolVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{
+			"protocolVersion": "1999-01-01",
+		}})
+	}))
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	_, err := client.CallTool(context.Background(), "project_brief", nil)
+	if err == nil || !strings.Contains(err.Error(), "unsupported protocol version") {
+		t.Fatalf("err = %v, want unsupported protocol version", err)
+	}
+}
+
+func TestMCPClient_SSEResponse(t *testing.T) {
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{\"progress\":1}}\n\n")
+		fmt.Fprintf(w, "event: message\nid: 7\ndata: {\"jsonrpc\":\"2.0\",\"id\":%d,\n", req.ID)
+		fmt.Fprint(w, "data: \"result\":{\"content\":[{\"type\":\"text\",\"text\":\"streamed\"}]}}\n\n")
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	text, err := client.CallTool(context.Background(), "project_brief", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if text != "streamed" {
+		t.Errorf("text = %q, want streamed", text)
+	}
+}
+
+func TestMCPClient_SSEWithoutResponse(t *testing.T) {
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"id\":999,\"result\":{}}\n\n")
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	if _, err := client.CallTool(context.Background(), "project_brief", nil); err == nil {
+		t.Fatal("expected error when the stream has no matching response")
+	}
+}
+
+func TestMCPClient_ReinitializesExpiredSession(t *testing.T) {
+	inits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "initialize":
+			inits++
+			w.Header().Set("Mcp-Session-Id", fmt.Sprintf("s%d", inits))
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{
+				"protocolVersion": MCPProtocolVersion,
+			}})
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			if r.Header.Get("Mcp-Session-Id") != "s2" {
+				http.Error(w, "session not found", http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{
+				"content": []map[string]any{{"type": "text", "text": "ok"}},
+			}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	if _, err := client.CallTool(context.Background(), "project_brief", nil); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if inits != 2 {
+		t.Errorf("initialize called %d times, want 2", inits)
+	}
+}
+
+func TestMCPClient_ListToolsCachesAndPaginates(t *testing.T) {
+	lists := 0
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		if req.Method != "tools/list" {
+			t.Errorf("method = %s, want tools/list", req.Method)
+		}
+		lists++
+		params, _ := req.Params.(map[string]any)
+		result := map[string]any{
+			"tools":      []map[string]any{{"name": "project_brief", "inputSchema": map[string]any{"type": "object"}}},
+			"nextCursor": "page2",
+		}
+		if params["cursor"] == "page2" {
+			result = map[string]any{"tools": []map[string]any{{"name": "recall_batch"}}}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	for range 2 {
+		tools, err := client.ListTools(context.Background())
+		if err != nil {
+			t.Fatalf("ListTools failed: %v", err)
+		}
+		if len(tools) != 2 || tools[1].Name != "recall_batch" {
+			t.Fatalf("tools = %+v", tools)
+		}
+	}
+	if lists != 2 {
+		t.Errorf("tools/list called %d times, want 2 (one per page, then cached)", lists)
+	}
+
+	tool, err := client.Tool(context.Background(), "project_brief")
+	if err != nil {
+		t.Fatalf("Tool failed: %v", err)
+	}
+	if string(tool.InputSchema) != `{"type":"object"}` {
+		t.Errorf("InputSchema = %s", tool.InputSchema)
+	}
+	if _, err := client.Tool(context.Background(), "nope"); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+}
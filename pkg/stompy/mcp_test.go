@@ -0,0 +1,289 @@
+package stompy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMCPBaseURL(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"https://api.stompy.ai/api/v1", "https://api.stompy.ai/mcp"},
+		{"https://api.stompy.ai/api/v1/", "https://api.stompy.ai/mcp"},
+		{"https://api-staging.stompy.ai/api/v1", "https://api-staging.stompy.ai/mcp"},
+		{"http://localhost:8000/api/v1", "http://localhost:8000/mcp"},
+		{"http://localhost:8000", "http://localhost:8000/mcp"},
+	}
+
+	for _, tt := range tests {
+		got := MCPBaseURL(tt.input)
+		if got != tt.want {
+			t.Errorf("MCPBaseURL(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// mcpServer returns an MCP endpoint that completes the initialize handshake
+// and hands every other request to handle.
+func mcpServer(t *testing.T, handle func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "sess-1")
+			json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      req.ID,
+				"result": map[string]any{
+					"protocolVersion": MCPProtocolVersion,
+					"capabilities":    map[string]any{"tools": map[string]any{}},
+					"serverInfo":      map[string]any{"name": "test", "version": "1"},
+				},
+			})
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			handle(w, r, req)
+		}
+	}))
+}
+
+func TestMCPClient_CallTool(t *testing.T) {
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		// Verify headers
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("expected Authorization header, got %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %q", r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Mcp-Session-Id") != "sess-1" {
+			t.Errorf("expected Mcp-Session-Id sess-1, got %q", r.Header.Get("Mcp-Session-Id"))
+		}
+
+		if req.JSONRPC != "2.0" {
+			t.Errorf("expected jsonrpc 2.0, got %q", req.JSONRPC)
+		}
+		if req.Method != "tools/call" {
+			t.Errorf("expected method tools/call, got %q", req.Method)
+		}
+
+		params := req.Params.(map[string]any)
+		if params["name"] != "project_brief" {
+			t.Errorf("expected tool name project_brief, got %q", params["name"])
+		}
+
+		// Respond with MCP result
+		resp := map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result": map[string]any{
+				"content": []map[string]any{
+					{"type": "text", "text": `{"summary":"Test project brief"}`},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("test-token"), WithVersion("0.2.0"))
+	text, err := client.CallTool(context.Background(), "project_brief", map[string]any{"project": "test"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+
+	expected := `{"summary":"Test project brief"}`
+	if text != expected {
+		t.Errorf("CallTool returned %q, want %q", text, expected)
+	}
+}
+
+func TestMCPClient_CallToolTyped(t *testing.T) {
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		resp := map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result": map[string]any{
+				"content": []map[string]any{
+					{"type": "text", "text": `{"name":"test","context_count":5}`},
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+
+	var dest struct {
+		Name         string `json:"name"`
+		ContextCount int    `json:"context_count"`
+	}
+	if err := client.CallToolTyped(context.Background(), "project_brief", map[string]any{"project": "test"}, &dest); err != nil {
+		t.Fatalf("CallToolTyped failed: %v", err)
+	}
+	if dest.Name != "test" || dest.ContextCount != 5 {
+		t.Errorf("unexpected result: %+v", dest)
+	}
+}
+
+func TestMCPClient_CallToolError(t *testing.T) {
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		resp := map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result": map[string]any{
+				"content": []map[string]any{
+					{"type": "text", "text": "Tool error: project not found"},
+				},
+				"isError": true,
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	_, err := client.CallTool(context.Background(), "project_brief", map[string]any{"project": "nonexistent"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if err.Error() != "MCP tool error: Tool error: project not found" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMCPClient_RPCError(t *testing.T) {
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		resp := map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"error": map[string]any{
+				"code":    -32601,
+				"message": "Method not found",
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	_, err := client.CallTool(context.Background(), "nonexistent", nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestMCPClient_CallTool_CancelledContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not reach the server with a cancelled context")
+	}))
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.CallTool(ctx, "project_brief", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestMCPClient_CallTool_Retries429(t *testing.T) {
+	attempts := 0
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result": map[string]any{
+				"content": []map[string]any{{"type": "text", "text": "ok"}},
+			},
+		})
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	text, err := client.CallTool(context.Background(), "project_brief", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if text != "ok" {
+		t.Errorf("text = %q, want ok", text)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
+
+func TestMCPClient_RefreshDoesNotUseRetry(t *testing.T) {
+	attempts := 0
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		attempts++
+		switch {
+		case r.Header.Get("Authorization") != "Bearer fresh":
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		case attempts <= 1+DefaultMaxRetries:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result": map[string]any{
+				"content": []map[string]any{{"type": "text", "text": "ok"}},
+			},
+		})
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL)
+	client.Tokens = &stubTokens{current: "stale", next: "fresh"}
+	if _, err := client.CallTool(context.Background(), "project_brief", nil); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if want := 2 + DefaultMaxRetries; attempts != want {
+		t.Errorf("attempts = %d, want %d", attempts, want)
+	}
+}
+
+func TestMCPClient_RPCErrorTyped(t *testing.T) {
+	server := mcpServer(t, func(w http.ResponseWriter, r *http.Request, req jsonRPCRequest) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"error":   map[string]any{"code": -32602, "message": "Invalid params"},
+		})
+	})
+	defer server.Close()
+
+	client := NewMCPClient(server.URL, WithToken("token"))
+	_, err := client.CallTool(context.Background(), "project_brief", nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+		t.Fatalf("err = %v, want *RPCError with code -32602", err)
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Error("invalid params should match ErrValidation")
+	}
+}
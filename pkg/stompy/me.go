@@ -0,0 +1,31 @@
+package stompy
+
+import (
+	"context"
+	"time"
+)
+
+// MeResponse describes the principal a request is authenticated as.
+type MeResponse struct {
+	UserID string   `json:"user_id"`
+	Email  string   `json:"email,omitempty"`
+	Name   string   `json:"name,omitempty"`
+	Org    string   `json:"org,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+	// AuthMethod is "oauth" or "api_key".
+	AuthMethod string `json:"auth_method,omitempty"`
+	// APIKeyID identifies the key when AuthMethod is "api_key".
+	APIKeyID string `json:"api_key_id,omitempty"`
+	// ExpiresAt is when the presented credential stops working, if ever.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Me returns the identity behind the client's credential. It is the cheapest
+// way to check that a token or API key is valid.
+func (c *Client) Me(ctx context.Context) (*MeResponse, error) {
+	var resp MeResponse
+	if err := c.Get(ctx, "/me", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,91 @@
+package stompy
+
+import "net/http"
+
+// DefaultMaxRetries is how many times a request is retried when no
+// WithRetries option is given.
+const DefaultMaxRetries = 2
+
+// Option configures a Client or MCPClient.
+type Option func(*options)
+
+type options struct {
+	token      string
+	tokens     TokenSource
+	httpClient *http.Client
+	userAgent  string
+	version    string
+	maxRetries int
+	limiter    *RateLimiter
+	verbose    bool
+	onWarning  func(string)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{maxRetries: DefaultMaxRetries}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.userAgent == "" {
+		o.userAgent = "stompy-cli/dev"
+		if o.version != "" && o.version != "dev" {
+			o.userAgent = "stompy-cli/" + o.version
+		}
+	}
+	return o
+}
+
+// WithToken authenticates with a fixed bearer token: an API key or an OAuth
+// access token.
+func WithToken(token string) Option {
+	return func(o *options) { o.token = token }
+}
+
+// WithTokenSource authenticates with tokens from src, which is asked for a new
+// token when the server rejects one. It takes precedence over WithToken.
+func WithTokenSource(src TokenSource) Option {
+	return func(o *options) { o.tokens = src }
+}
+
+// WithHTTPClient sends requests through hc instead of a default client with a
+// 30s (REST) or 60s (MCP) timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) { o.httpClient = hc }
+}
+
+// WithUserAgent sets the User-Agent header. The default is derived from
+// WithVersion.
+func WithUserAgent(ua string) Option {
+	return func(o *options) { o.userAgent = ua }
+}
+
+// WithVersion sets the version reported to the server, used in the default
+// User-Agent, MCP clientInfo and compatibility warnings.
+func WithVersion(version string) Option {
+	return func(o *options) { o.version = version }
+}
+
+// WithRetries sets how many times a failed request is retried: network
+// errors and gateway failures on idempotent requests, and 429 responses.
+// Zero disables retries.
+func WithRetries(n int) Option {
+	return func(o *options) { o.maxRetries = max(n, 0) }
+}
+
+// WithRateLimiter throttles every outgoing request, including retries. Share
+// one limiter between clients to cap their combined rate.
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(o *options) { o.limiter = l }
+}
+
+// WithVerbose logs each request and response to stderr.
+func WithVerbose(verbose bool) Option {
+	return func(o *options) { o.verbose = verbose }
+}
+
+// WithWarningHandler receives warnings the server's responses give rise to,
+// such as a client version below the minimum the server supports. Without
+// one, warnings are dropped.
+func WithWarningHandler(fn func(msg string)) Option {
+	return func(o *options) { o.onWarning = fn }
+}
@@ -0,0 +1,43 @@
+package stompy
+
+import (
+	"context"
+	"iter"
+)
+
+// DefaultPageSize is the page size used by Paginate when pageSize <= 0.
+const DefaultPageSize = 100
+
+// PageFetcher fetches a single page of a limit/offset-paginated list,
+// returning the page's items and the server-reported total.
+type PageFetcher[T any] func(ctx context.Context, limit, offset int) ([]T, int, error)
+
+// Paginate returns an iterator over every item of a paginated list, fetching
+// pageSize items at a time until the server-reported total is reached.
+// Iteration stops at the first error, which is yielded with a zero item.
+func Paginate[T any](ctx context.Context, pageSize int, fetch PageFetcher[T]) iter.Seq2[T, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return func(yield func(T, error) bool) {
+		offset := 0
+		for {
+			items, total, err := fetch(ctx, pageSize, offset)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			offset += len(items)
+			// An empty page guards against servers whose total overcounts
+			if len(items) == 0 || offset >= total {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package stompy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestPaginate_WalksUntilTotal(t *testing.T) {
+	data := []int{1, 2, 3, 4, 5, 6, 7}
+	var offsets []int
+	fetch := func(ctx context.Context, limit, offset int) ([]int, int, error) {
+		offsets = append(offsets, offset)
+		end := min(offset+limit, len(data))
+		return data[offset:end], len(data), nil
+	}
+
+	var got []int
+	for n, err := range Paginate(context.Background(), 3, fetch) {
+		if err != nil {
+			t.Fatalf("Paginate() error: %v", err)
+		}
+		got = append(got, n)
+	}
+
+	if len(got) != len(data) {
+		t.Errorf("got %d items, want %d", len(got), len(data))
+	}
+	if want := []int{0, 3, 6}; !slices.Equal(offsets, want) {
+		t.Errorf("offsets = %v, want %v", offsets, want)
+	}
+}
+
+func TestPaginate_DefaultPageSize(t *testing.T) {
+	var gotLimit int
+	fetch := func(ctx context.Context, limit, offset int) ([]int, int, error) {
+		gotLimit = limit
+		return nil, 0, nil
+	}
+	for range Paginate(context.Background(), 0, fetch) {
+	}
+	if gotLimit != DefaultPageSize {
+		t.Errorf("limit = %d, want %d", gotLimit, DefaultPageSize)
+	}
+}
+
+func TestPaginate_StopsOnEmptyPage(t *testing.T) {
+	calls := 0
+	fetch := func(ctx context.Context, limit, offset int) ([]int, int, error) {
+		calls++
+		if offset == 0 {
+			return []int{1, 2}, 10, nil // total overcounts
+		}
+		return nil, 10, nil
+	}
+	count := 0
+	for range Paginate(context.Background(), 2, fetch) {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestPaginate_YieldsError(t *testing.T) {
+	wantErr := errors.New("boom")
+	fetch := func(ctx context.Context, limit, offset int) ([]int, int, error) {
+		if offset > 0 {
+			return nil, 0, wantErr
+		}
+		return []int{1}, 5, nil
+	}
+	var items int
+	var gotErr error
+	for _, err := range Paginate(context.Background(), 1, fetch) {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		items++
+	}
+	if items != 1 {
+		t.Errorf("items = %d, want 1", items)
+	}
+	if !errors.Is(gotErr, wantErr) {
+		t.Errorf("err = %v, want %v", gotErr, wantErr)
+	}
+}
+
+func TestPaginate_EarlyBreakStopsFetching(t *testing.T) {
+	calls := 0
+	fetch := func(ctx context.Context, limit, offset int) ([]int, int, error) {
+		calls++
+		return []int{1, 2}, 100, nil
+	}
+	for range Paginate(context.Background(), 2, fetch) {
+		break
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestClient_AllTickets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if limit != 2 {
+			t.Errorf("limit = %d, want 2", limit)
+		}
+		var tickets []TicketResponse
+		for i := offset; i < min(offset+limit, 5); i++ {
+			tickets = append(tickets, TicketResponse{ID: i + 1})
+		}
+		json.NewEncoder(w).Encode(TicketListResponse{Tickets: tickets, Total: 5})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	var ids []int
+	for tk, err := range c.AllTickets(context.Background(), "proj", "", "", "", 2) {
+		if err != nil {
+			t.Fatalf("AllTickets() error: %v", err)
+		}
+		ids = append(ids, tk.ID)
+	}
+	if !slices.Equal(ids, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("ids = %v, want [1 2 3 4 5]", ids)
+	}
+}
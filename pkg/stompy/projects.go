@@ -1,6 +1,7 @@
-package api
+package stompy
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"time"
@@ -35,39 +36,39 @@ type ProjectListResponse struct {
 	Total    int               `json:"total"`
 }
 
-func (c *Client) ListProjects(withStats bool) (*ProjectListResponse, error) {
+func (c *Client) ListProjects(ctx context.Context, withStats bool) (*ProjectListResponse, error) {
 	params := url.Values{}
 	if withStats {
 		params.Set("stats", "true")
 	}
 	var resp ProjectListResponse
-	if err := c.Get("/projects", params, &resp); err != nil {
+	if err := c.Get(ctx, "/projects", params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) GetProject(name string, withStats bool) (*ProjectResponse, error) {
+func (c *Client) GetProject(ctx context.Context, name string, withStats bool) (*ProjectResponse, error) {
 	params := url.Values{}
 	if withStats {
 		params.Set("stats", "true")
 	}
 	var resp ProjectResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s", url.PathEscape(name)), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s", url.PathEscape(name)), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) CreateProject(req ProjectCreate) (*ProjectResponse, error) {
+func (c *Client) CreateProject(ctx context.Context, req ProjectCreate) (*ProjectResponse, error) {
 	var resp ProjectResponse
-	if err := c.Post("/projects", req, &resp); err != nil {
+	if err := c.Post(ensureIdempotencyKey(ctx), "/projects", req, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) DeleteProject(name string) error {
+func (c *Client) DeleteProject(ctx context.Context, name string) error {
 	params := url.Values{"confirm": {"true"}}
-	return c.Delete(fmt.Sprintf("/projects/%s", url.PathEscape(name)), params)
+	return c.Delete(ctx, fmt.Sprintf("/projects/%s", url.PathEscape(name)), params)
 }
@@ -1,6 +1,7 @@
-package api
+package stompy
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -31,8 +32,8 @@ func TestListProjects(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.ListProjects(true)
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.ListProjects(context.Background(), true)
 	if err != nil {
 		t.Fatalf("ListProjects() error: %v", err)
 	}
@@ -53,8 +54,8 @@ func TestListProjects_WithoutStats(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	_, err := c.ListProjects(false)
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, err := c.ListProjects(context.Background(), false)
 	if err != nil {
 		t.Fatalf("ListProjects() error: %v", err)
 	}
@@ -80,8 +81,8 @@ func TestGetProject(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.GetProject("myproj", true)
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.GetProject(context.Background(), "myproj", true)
 	if err != nil {
 		t.Fatalf("GetProject() error: %v", err)
 	}
@@ -114,8 +115,8 @@ func TestCreateProject(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.CreateProject(ProjectCreate{Name: "newproj"})
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.CreateProject(context.Background(), ProjectCreate{Name: "newproj"})
 	if err != nil {
 		t.Fatalf("CreateProject() error: %v", err)
 	}
@@ -142,8 +143,8 @@ func TestDeleteProject(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	err := c.DeleteProject("oldproj")
+	c := NewClient(srv.URL, WithToken("tok"))
+	err := c.DeleteProject(context.Background(), "oldproj")
 	if err != nil {
 		t.Fatalf("DeleteProject() error: %v", err)
 	}
@@ -156,8 +157,8 @@ func TestGetProject_Error(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	_, err := c.GetProject("missing", false)
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, err := c.GetProject(context.Background(), "missing", false)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
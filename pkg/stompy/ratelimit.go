@@ -0,0 +1,83 @@
+package stompy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token-bucket limiter shared by the REST and MCP clients so
+// bulk scripts stay under the server's request quota.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter allowing perSecond requests on average with
+// bursts of up to burst requests. burst is raised to 1 if smaller.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// ParseRateLimit parses a rate such as "10/s", "600/m" or "5000/h" into a
+// limiter. The burst size equals one second's worth of requests (at least 1).
+func ParseRateLimit(s string) (*RateLimiter, error) {
+	countStr, unit, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid rate_limit %q: expected <count>/<s|m|h>, e.g. 10/s", s)
+	}
+	count, err := strconv.ParseFloat(strings.TrimSpace(countStr), 64)
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("invalid rate_limit %q: count must be a positive number", s)
+	}
+
+	var per time.Duration
+	switch strings.TrimSpace(unit) {
+	case "s", "sec", "second":
+		per = time.Second
+	case "m", "min", "minute":
+		per = time.Minute
+	case "h", "hour":
+		per = time.Hour
+	default:
+		return nil, fmt.Errorf("invalid rate_limit %q: unit must be s, m or h", s)
+	}
+
+	perSecond := count / per.Seconds()
+	return NewRateLimiter(perSecond, int(perSecond)), nil
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if err := sleepCtx(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package stompy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantRate  float64
+		wantBurst float64
+	}{
+		{"10/s", 10, 10},
+		{"120/m", 2, 2},
+		{"60/min", 1, 1},
+		{"3600/h", 1, 1},
+		{"30/m", 0.5, 1},
+	}
+	for _, tt := range tests {
+		l, err := ParseRateLimit(tt.input)
+		if err != nil {
+			t.Errorf("ParseRateLimit(%q) error: %v", tt.input, err)
+			continue
+		}
+		if l.rate != tt.wantRate || l.burst != tt.wantBurst {
+			t.Errorf("ParseRateLimit(%q) = rate %v burst %v, want rate %v burst %v", tt.input, l.rate, l.burst, tt.wantRate, tt.wantBurst)
+		}
+	}
+}
+
+func TestParseRateLimit_Invalid(t *testing.T) {
+	for _, input := range []string{"", "10", "ten/s", "0/s", "-1/s", "10/d"} {
+		if _, err := ParseRateLimit(input); err == nil {
+			t.Errorf("ParseRateLimit(%q) expected error", input)
+		}
+	}
+}
+
+func TestRateLimiter_Wait_Throttles(t *testing.T) {
+	l := NewRateLimiter(20, 1) // one token every 50ms
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("Wait() error: %v", err)
+		}
+	}
+	// First token is immediate, the next two each wait ~50ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 waits took %s, want >= ~100ms", elapsed)
+	}
+}
+
+func TestRateLimiter_Wait_HonorsContext(t *testing.T) {
+	l := NewRateLimiter(0.1, 1) // one token every 10s
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("first Wait() error: %v", err)
+	}
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second Wait() = %v, want context.DeadlineExceeded", err)
+	}
+}
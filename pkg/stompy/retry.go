@@ -0,0 +1,57 @@
+package stompy
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRetryAfter caps how long we are willing to wait on a server-supplied
+// Retry-After. Longer waits are surfaced as errors instead of hanging the CLI.
+const maxRetryAfter = 60 * time.Second
+
+// backoffDelay returns the jittered exponential delay before retry attempt n (n >= 1).
+// Half of the delay is fixed and half is random so concurrent clients spread out.
+func backoffDelay(n int) time.Duration {
+	d := retryBaseDelay * time.Duration(1<<(n-1))
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// parseRetryAfter interprets a Retry-After header value given as either
+// delay-seconds or an HTTP-date. Returns false if the header is absent or invalid.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	d := t.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// retryDelay picks the wait before retry attempt n, preferring the server's
+// Retry-After header when present. ok is false when the server asked us to
+// wait longer than maxRetryAfter.
+func retryDelay(n int, header http.Header) (d time.Duration, ok bool) {
+	if header != nil {
+		if ra, found := parseRetryAfter(header.Get("Retry-After"), time.Now()); found {
+			return ra, ra <= maxRetryAfter
+		}
+	}
+	return backoffDelay(n), true
+}
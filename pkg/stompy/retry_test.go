@@ -0,0 +1,62 @@
+package stompy
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"seconds", "5", 5 * time.Second, true},
+		{"zero", "0", 0, true},
+		{"http date", now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{"date in past", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"empty", "", 0, false},
+		{"negative", "-3", 0, false},
+		{"garbage", "soon", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %s, %v; want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBackoffDelay_JitterBounds(t *testing.T) {
+	for n := 1; n <= 3; n++ {
+		full := retryBaseDelay * time.Duration(1<<(n-1))
+		for i := 0; i < 50; i++ {
+			d := backoffDelay(n)
+			if d < full/2 || d > full {
+				t.Fatalf("backoffDelay(%d) = %s, want within [%s, %s]", n, d, full/2, full)
+			}
+		}
+	}
+}
+
+func TestRetryDelay_PrefersRetryAfter(t *testing.T) {
+	h := http.Header{}
+	h.Set("Retry-After", "2")
+	d, ok := retryDelay(1, h)
+	if !ok || d != 2*time.Second {
+		t.Errorf("retryDelay() = %s, %v; want 2s, true", d, ok)
+	}
+}
+
+func TestRetryDelay_RejectsLongRetryAfter(t *testing.T) {
+	h := http.Header{}
+	h.Set("Retry-After", "3600")
+	if _, ok := retryDelay(1, h); ok {
+		t.Error("retryDelay() ok = true for Retry-After beyond maxRetryAfter, want false")
+	}
+}
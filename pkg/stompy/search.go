@@ -1,6 +1,7 @@
-package api
+package stompy
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -24,7 +25,7 @@ type SearchResponse struct {
 }
 
 // Search performs hybrid semantic + keyword search across a project.
-func (c *Client) Search(project, query string, limit int) (*SearchResponse, error) {
+func (c *Client) Search(ctx context.Context, project, query string, limit int) (*SearchResponse, error) {
 	params := url.Values{}
 	params.Set("q", query)
 	if limit > 0 {
@@ -32,7 +33,7 @@ func (c *Client) Search(project, query string, limit int) (*SearchResponse, erro
 	}
 
 	var resp SearchResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/search", project), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/search", project), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
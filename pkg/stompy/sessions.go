@@ -0,0 +1,58 @@
+package stompy
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// SessionResponse is an OAuth session: one login of a CLI or other client,
+// alive for as long as its refresh token.
+type SessionResponse struct {
+	ID         string     `json:"id"`
+	ClientID   string     `json:"client_id"`
+	DeviceName string     `json:"device_name,omitempty"`
+	IPAddress  string     `json:"ip_address,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	// Current marks the session the request was made with.
+	Current bool `json:"current"`
+}
+
+// SessionListResponse wraps a list of sessions.
+type SessionListResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+	Total    int               `json:"total"`
+}
+
+// SessionRevokeResponse reports how many sessions were revoked.
+type SessionRevokeResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+// ListSessions returns the caller's active sessions.
+func (c *Client) ListSessions(ctx context.Context) (*SessionListResponse, error) {
+	var resp SessionListResponse
+	if err := c.Get(ctx, "/auth/sessions", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RevokeSession ends one session, invalidating its tokens.
+func (c *Client) RevokeSession(ctx context.Context, id string) error {
+	return c.Delete(ctx, fmt.Sprintf("/auth/sessions/%s", url.PathEscape(id)), nil)
+}
+
+// RevokeAllSessions ends every session of the caller, including the one
+// the request is made with.
+func (c *Client) RevokeAllSessions(ctx context.Context) (*SessionRevokeResponse, error) {
+	var resp SessionRevokeResponse
+	if err := c.DeleteWithResult(ctx, "/auth/sessions", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
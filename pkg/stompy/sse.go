@@ -0,0 +1,91 @@
+package stompy
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// isEventStream reports whether a response carries a text/event-stream body.
+func isEventStream(h http.Header) bool {
+	mt, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mt == "text/event-stream"
+}
+
+// readSSEResponse reads server-sent events until the JSON-RPC response with
+// the given id arrives. Server notifications and requests interleaved on the
+// stream (progress, logging) are skipped.
+func readSSEResponse(body io.Reader, id int64, verbose bool) (*jsonRPCResponse, error) {
+	var found *jsonRPCResponse
+	err := readSSE(body, func(data string) (bool, error) {
+		var msg struct {
+			jsonRPCResponse
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal([]byte(data), &msg); err != nil {
+			return false, fmt.Errorf("decoding MCP event: %w", err)
+		}
+		if msg.Method != "" {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "[DEBUG]     Event: %s\n", msg.Method)
+			}
+			return false, nil
+		}
+		if msg.ID != id {
+			return false, nil
+		}
+		if verbose {
+			preview := data
+			if len(preview) > 300 {
+				preview = preview[:300] + "..."
+			}
+			fmt.Fprintf(os.Stderr, "[DEBUG]     Event: %s\n", preview)
+		}
+		found = &msg.jsonRPCResponse
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, errors.New("MCP event stream ended without a response")
+	}
+	return found, nil
+}
+
+// readSSE parses a text/event-stream and calls fn with the data of each event
+// until fn returns done or the stream ends.
+func readSSE(body io.Reader, fn func(data string) (done bool, err error)) error {
+	r := bufio.NewReader(body)
+	var data []string
+	for {
+		line, readErr := r.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("reading MCP event stream: %w", readErr)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if field, value, _ := strings.Cut(line, ":"); field == "data" {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+		// "event", "id", "retry" and ":" comments carry nothing we need.
+
+		// A blank line, or the end of the stream, dispatches the pending event.
+		if (line == "" || readErr == io.EOF) && len(data) > 0 {
+			done, err := fn(strings.Join(data, "\n"))
+			if err != nil || done {
+				return err
+			}
+			data = data[:0]
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package stompy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadSSE(t *testing.T) {
+	stream := ": comment\r\n" +
+		"event: message\r\n" +
+		"data: first\r\n" +
+		"\r\n" +
+		"id: 2\n" +
+		"data: multi\n" +
+		"data:line\n" +
+		"\n" +
+		"data: unterminated"
+
+	var got []string
+	err := readSSE(strings.NewReader(stream), func(data string) (bool, error) {
+		got = append(got, data)
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("readSSE() error: %v", err)
+	}
+	want := []string{"first", "multi\nline", "unterminated"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestReadSSE_StopsWhenDone(t *testing.T) {
+	n := 0
+	err := readSSE(strings.NewReader("data: a\n\ndata: b\n\n"), func(string) (bool, error) {
+		n++
+		return true, nil
+	})
+	if err != nil || n != 1 {
+		t.Errorf("n = %d, err = %v; want 1 event", n, err)
+	}
+}
@@ -1,7 +1,9 @@
-package api
+package stompy
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"net/url"
 	"strconv"
 )
@@ -88,7 +90,7 @@ type TransitionRequest struct {
 	Status string `json:"status"`
 }
 
-func (c *Client) ListTickets(project string, status, ticketType, priority string, limit, offset int) (*TicketListResponse, error) {
+func (c *Client) ListTickets(ctx context.Context, project string, status, ticketType, priority string, limit, offset int) (*TicketListResponse, error) {
 	params := url.Values{}
 	if status != "" {
 		params.Set("status", status)
@@ -106,46 +108,57 @@ func (c *Client) ListTickets(project string, status, ticketType, priority string
 		params.Set("offset", strconv.Itoa(offset))
 	}
 	var resp TicketListResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/tickets", url.PathEscape(project)), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/tickets", url.PathEscape(project)), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) GetTicket(project string, id int) (*TicketResponse, error) {
+// AllTickets iterates over every ticket matching the filters, fetching pageSize at a time.
+func (c *Client) AllTickets(ctx context.Context, project string, status, ticketType, priority string, pageSize int) iter.Seq2[TicketResponse, error] {
+	return Paginate(ctx, pageSize, func(ctx context.Context, limit, offset int) ([]TicketResponse, int, error) {
+		resp, err := c.ListTickets(ctx, project, status, ticketType, priority, limit, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Tickets, resp.Total, nil
+	})
+}
+
+func (c *Client) GetTicket(ctx context.Context, project string, id int) (*TicketResponse, error) {
 	var resp TicketResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/tickets/%d", url.PathEscape(project), id), nil, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/tickets/%d", url.PathEscape(project), id), nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) CreateTicket(project string, req TicketCreate) (*TicketResponse, error) {
+func (c *Client) CreateTicket(ctx context.Context, project string, req TicketCreate) (*TicketResponse, error) {
 	var resp TicketResponse
-	if err := c.Post(fmt.Sprintf("/projects/%s/tickets", url.PathEscape(project)), req, &resp); err != nil {
+	if err := c.Post(ensureIdempotencyKey(ctx), fmt.Sprintf("/projects/%s/tickets", url.PathEscape(project)), req, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) UpdateTicket(project string, id int, req TicketUpdate) (*TicketResponse, error) {
+func (c *Client) UpdateTicket(ctx context.Context, project string, id int, req TicketUpdate) (*TicketResponse, error) {
 	var resp TicketResponse
-	if err := c.Put(fmt.Sprintf("/projects/%s/tickets/%d", url.PathEscape(project), id), req, &resp); err != nil {
+	if err := c.Put(ctx, fmt.Sprintf("/projects/%s/tickets/%d", url.PathEscape(project), id), req, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) TransitionTicket(project string, id int, status string) (*TicketResponse, error) {
+func (c *Client) TransitionTicket(ctx context.Context, project string, id int, status string) (*TicketResponse, error) {
 	body := TransitionRequest{Status: status}
 	var resp TicketResponse
-	if err := c.Post(fmt.Sprintf("/projects/%s/tickets/%d/move", url.PathEscape(project), id), body, &resp); err != nil {
+	if err := c.Post(ensureIdempotencyKey(ctx), fmt.Sprintf("/projects/%s/tickets/%d/move", url.PathEscape(project), id), body, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) SearchTickets(project, query string, ticketType, status string, limit int) (*TicketSearchResponse, error) {
+func (c *Client) SearchTickets(ctx context.Context, project, query string, ticketType, status string, limit int) (*TicketSearchResponse, error) {
 	params := url.Values{}
 	params.Set("query", query)
 	if ticketType != "" {
@@ -158,13 +171,13 @@ func (c *Client) SearchTickets(project, query string, ticketType, status string,
 		params.Set("limit", strconv.Itoa(limit))
 	}
 	var resp TicketSearchResponse
-	if err := c.Get(fmt.Sprintf("/projects/%s/tickets/search", url.PathEscape(project)), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/tickets/search", url.PathEscape(project)), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) GetBoard(project string, view, ticketType, status string) (*BoardView, error) {
+func (c *Client) GetBoard(ctx context.Context, project string, view, ticketType, status string) (*BoardView, error) {
 	params := url.Values{}
 	if view != "" {
 		params.Set("view", view)
@@ -176,28 +189,28 @@ func (c *Client) GetBoard(project string, view, ticketType, status string) (*Boa
 		params.Set("status", status)
 	}
 	var resp BoardView
-	if err := c.Get(fmt.Sprintf("/projects/%s/tickets/board", url.PathEscape(project)), params, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/tickets/board", url.PathEscape(project)), params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) AddLink(project string, ticketID int, req LinkCreate) (*TicketLinkResp, error) {
+func (c *Client) AddLink(ctx context.Context, project string, ticketID int, req LinkCreate) (*TicketLinkResp, error) {
 	var resp TicketLinkResp
-	if err := c.Post(fmt.Sprintf("/projects/%s/tickets/%d/links", url.PathEscape(project), ticketID), req, &resp); err != nil {
+	if err := c.Post(ensureIdempotencyKey(ctx), fmt.Sprintf("/projects/%s/tickets/%d/links", url.PathEscape(project), ticketID), req, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
-func (c *Client) ListLinks(project string, ticketID int) ([]TicketLinkResp, error) {
+func (c *Client) ListLinks(ctx context.Context, project string, ticketID int) ([]TicketLinkResp, error) {
 	var resp []TicketLinkResp
-	if err := c.Get(fmt.Sprintf("/projects/%s/tickets/%d/links", url.PathEscape(project), ticketID), nil, &resp); err != nil {
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/tickets/%d/links", url.PathEscape(project), ticketID), nil, &resp); err != nil {
 		return nil, err
 	}
 	return resp, nil
 }
 
-func (c *Client) RemoveLink(project string, ticketID, linkID int) error {
-	return c.Delete(fmt.Sprintf("/projects/%s/tickets/%d/links/%d", url.PathEscape(project), ticketID, linkID), nil)
+func (c *Client) RemoveLink(ctx context.Context, project string, ticketID, linkID int) error {
+	return c.Delete(ctx, fmt.Sprintf("/projects/%s/tickets/%d/links/%d", url.PathEscape(project), ticketID, linkID), nil)
 }
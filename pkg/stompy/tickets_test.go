@@ -1,6 +1,7 @@
-package api
+package stompy
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -28,8 +29,8 @@ func TestListTickets(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.ListTickets("proj", "open", "", "", 0, 0)
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.ListTickets(context.Background(), "proj", "open", "", "", 0, 0)
 	if err != nil {
 		t.Fatalf("ListTickets() error: %v", err)
 	}
@@ -52,8 +53,8 @@ func TestGetTicket(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.GetTicket("proj", 42)
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.GetTicket(context.Background(), "proj", 42)
 	if err != nil {
 		t.Fatalf("GetTicket() error: %v", err)
 	}
@@ -77,9 +78,9 @@ func TestCreateTicket(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
+	c := NewClient(srv.URL, WithToken("tok"))
 	desc := "detailed description"
-	resp, err := c.CreateTicket("proj", TicketCreate{
+	resp, err := c.CreateTicket(context.Background(), "proj", TicketCreate{
 		Title:       "New ticket",
 		Description: &desc,
 		Type:        "task",
@@ -111,9 +112,9 @@ func TestUpdateTicket(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
+	c := NewClient(srv.URL, WithToken("tok"))
 	title := "Updated"
-	resp, err := c.UpdateTicket("proj", 1, TicketUpdate{Title: &title})
+	resp, err := c.UpdateTicket(context.Background(), "proj", 1, TicketUpdate{Title: &title})
 	if err != nil {
 		t.Fatalf("UpdateTicket() error: %v", err)
 	}
@@ -139,8 +140,8 @@ func TestTransitionTicket(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.TransitionTicket("proj", 1, "in_progress")
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.TransitionTicket(context.Background(), "proj", 1, "in_progress")
 	if err != nil {
 		t.Fatalf("TransitionTicket() error: %v", err)
 	}
@@ -168,8 +169,8 @@ func TestSearchTickets(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.SearchTickets("proj", "auth", "", "", 0)
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.SearchTickets(context.Background(), "proj", "auth", "", "", 0)
 	if err != nil {
 		t.Fatalf("SearchTickets() error: %v", err)
 	}
@@ -199,8 +200,8 @@ func TestGetBoard(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.GetBoard("proj", "summary", "", "")
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.GetBoard(context.Background(), "proj", "summary", "", "")
 	if err != nil {
 		t.Fatalf("GetBoard() error: %v", err)
 	}
@@ -230,8 +231,8 @@ func TestAddLink(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.AddLink("proj", 1, LinkCreate{TargetID: 2, LinkType: "blocks"})
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.AddLink(context.Background(), "proj", 1, LinkCreate{TargetID: 2, LinkType: "blocks"})
 	if err != nil {
 		t.Fatalf("AddLink() error: %v", err)
 	}
@@ -254,8 +255,8 @@ func TestListLinks(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	resp, err := c.ListLinks("proj", 1)
+	c := NewClient(srv.URL, WithToken("tok"))
+	resp, err := c.ListLinks(context.Background(), "proj", 1)
 	if err != nil {
 		t.Fatalf("ListLinks() error: %v", err)
 	}
@@ -279,8 +280,8 @@ func TestRemoveLink(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	err := c.RemoveLink("proj", 1, 10)
+	c := NewClient(srv.URL, WithToken("tok"))
+	err := c.RemoveLink(context.Background(), "proj", 1, 10)
 	if err != nil {
 		t.Fatalf("RemoveLink() error: %v", err)
 	}
@@ -293,8 +294,8 @@ func TestListTickets_Error(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewClient(srv.URL, "tok", "dev", false)
-	_, err := c.ListTickets("proj", "", "", "", 0, 0)
+	c := NewClient(srv.URL, WithToken("tok"))
+	_, err := c.ListTickets(context.Background(), "proj", "", "", "", 0, 0)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -0,0 +1,44 @@
+package stompy
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// TokenSource supplies bearer tokens to Client and MCPClient. When set, it
+// takes precedence over the static AuthToken field.
+type TokenSource interface {
+	// Token returns the token to send with the next request.
+	Token() (string, error)
+	// Refresh is called at most once per request, after the server answered
+	// 401 to a request carrying rejected. It returns a token to replay with.
+	Refresh(ctx context.Context, rejected string) (string, error)
+}
+
+// currentToken returns the token from src, or static if src is nil.
+func currentToken(src TokenSource, static string) (string, error) {
+	if src == nil {
+		return static, nil
+	}
+	return src.Token()
+}
+
+// refreshToken asks src for a replacement for rejected, reporting whether the
+// request should be replayed.
+func refreshToken(ctx context.Context, src TokenSource, rejected string, verbose bool) bool {
+	if src == nil {
+		return false
+	}
+	tok, err := src.Refresh(ctx, rejected)
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "[DEBUG]     Token refresh failed: %v\n", err)
+		}
+		return false
+	}
+	if verbose {
+		fmt.Fprintln(os.Stderr, "[DEBUG]     Token rejected; refreshed, replaying request")
+	}
+	return tok != ""
+}
@@ -0,0 +1,162 @@
+package stompy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubTokens is a TokenSource that swaps to next on Refresh.
+type stubTokens struct {
+	current, next string
+	refreshes     int
+	err           error
+}
+
+func (s *stubTokens) Token() (string, error) { return s.current, nil }
+
+func (s *stubTokens) Refresh(_ context.Context, rejected string) (string, error) {
+	s.refreshes++
+	if s.err != nil {
+		return "", s.err
+	}
+	s.current = s.next
+	return s.current, nil
+}
+
+func TestDo_RefreshesTokenOn401(t *testing.T) {
+	var auths []string
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body["topic"])
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	tokens := &stubTokens{current: "stale", next: "fresh"}
+	c := NewClient(srv.URL)
+	c.Tokens = tokens
+
+	// POST without an idempotency key: the replay must still happen, since a
+	// 401 means the request was never processed.
+	if _, _, err := c.Do(context.Background(), http.MethodPost, "/x", map[string]string{"topic": "t"}, nil); err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	if tokens.refreshes != 1 {
+		t.Errorf("refreshes = %d, want 1", tokens.refreshes)
+	}
+	if len(auths) != 2 || auths[0] != "Bearer stale" || auths[1] != "Bearer fresh" {
+		t.Errorf("Authorization headers = %v", auths)
+	}
+	if bodies[1] != "t" {
+		t.Errorf("replayed body topic = %q, want t", bodies[1])
+	}
+}
+
+func TestDo_RefreshesOnlyOnce(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	tokens := &stubTokens{current: "a", next: "b"}
+	c := NewClient(srv.URL)
+	c.Tokens = tokens
+
+	_, _, err := c.Do(context.Background(), http.MethodGet, "/x", nil, nil)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if tokens.refreshes != 1 || attempts != 2 {
+		t.Errorf("refreshes = %d, attempts = %d; want 1 and 2", tokens.refreshes, attempts)
+	}
+}
+
+func TestDo_RefreshFailureReturns401(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.Tokens = &stubTokens{current: "a", err: errors.New("refresh token revoked")}
+
+	_, status, err := c.Do(context.Background(), http.MethodGet, "/x", nil, nil)
+	if status != http.StatusUnauthorized || !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("status = %d, err = %v; want 401 unauthorized", status, err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestDo_StaticTokenNoRefresh(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithToken("api-key"))
+	if _, _, err := c.Do(context.Background(), http.MethodGet, "/x", nil, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestMCPClient_RefreshesTokenOn401(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req jsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == "initialize" {
+			// Answer as a server predating the handshake.
+			json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      req.ID,
+				"error":   map[string]any{"code": -32601, "message": "Method not found"},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result": map[string]any{
+				"content": []map[string]any{{"type": "text", "text": "ok"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	tokens := &stubTokens{current: "stale", next: "fresh"}
+	client := NewMCPClient(server.URL)
+	client.Tokens = tokens
+
+	text, err := client.CallTool(context.Background(), "project_brief", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if text != "ok" || tokens.refreshes != 1 {
+		t.Errorf("text = %q, refreshes = %d", text, tokens.refreshes)
+	}
+}
@@ -0,0 +1,506 @@
+package stompy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultChunkSize is the resumable upload chunk size used when neither the
+// caller nor the server picks one. Files no larger than one chunk are sent in
+// a single request.
+const DefaultChunkSize = 8 << 20
+
+// maxChunkAttempts bounds the consecutive failed attempts at one chunk before
+// a resumable upload gives up. A state file lets a later run pick it up again.
+const maxChunkAttempts = 5
+
+// errResumableUnsupported means the server has no resumable upload endpoint.
+var errResumableUnsupported = errors.New("resumable uploads not supported")
+
+// UploadOptions controls how UploadFile sends a file.
+type UploadOptions struct {
+	// Filename overrides the name the file is stored under, which defaults
+	// to the base name of the local path.
+	Filename string
+	Label    string
+	Metadata map[string]string
+
+	// Progress, if set, is called as bytes are sent with the number of bytes
+	// of the file sent so far and the file size.
+	Progress func(sent, total int64)
+
+	// ChunkSize overrides the chunk size for resumable uploads.
+	ChunkSize int64
+
+	// StateFile, if set, records the resumable upload session so that a later
+	// UploadFile of the same unchanged file continues where this one stopped.
+	// It is removed once the upload completes.
+	StateFile string
+}
+
+// UploadSession is a resumable upload in progress on the server.
+type UploadSession struct {
+	ID        string `json:"upload_id"`
+	Offset    int64  `json:"offset"`               // bytes the server has stored
+	ChunkSize int64  `json:"chunk_size,omitempty"` // server's preferred chunk size
+}
+
+// UploadSessionRequest opens a resumable upload.
+type UploadSessionRequest struct {
+	Filename  string            `json:"filename"`
+	SizeBytes int64             `json:"size_bytes"`
+	Label     string            `json:"label,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// uploadState is persisted to UploadOptions.StateFile.
+type uploadState struct {
+	Project  string    `json:"project"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	UploadID string    `json:"upload_id"`
+}
+
+// UploadFile uploads a file without reading it into memory. Files larger than
+// one chunk go through a resumable upload session, sent chunk by chunk and
+// retried from the server's offset after a failure; servers without
+// resumable uploads, and small files, get a single streamed multipart POST.
+func (c *Client) UploadFile(ctx context.Context, project, filePath string, opts UploadOptions) (*FileResponse, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("opening file: %s is a directory", filePath)
+	}
+
+	if opts.Filename == "" {
+		opts.Filename = info.Name()
+	}
+	// One key may cover a whole directory of uploads; scope it to this file
+	ctx = idempotencyStep(ctx, opts.Filename)
+
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if info.Size() > chunkSize {
+		resp, err := c.uploadResumable(ctx, project, filePath, info, opts)
+		if !errors.Is(err, errResumableUnsupported) {
+			return resp, err
+		}
+		if c.Verbose {
+			fmt.Fprintln(os.Stderr, "[DEBUG]     Server does not support resumable uploads; sending in one request")
+		}
+	}
+	return c.uploadMultipart(ctx, project, filePath, info, opts)
+}
+
+// uploadMultipart streams the file as a multipart form through an io.Pipe.
+func (c *Client) uploadMultipart(ctx context.Context, project, filePath string, info os.FileInfo, opts UploadOptions) (*FileResponse, error) {
+	var metadata []byte
+	if len(opts.Metadata) > 0 {
+		var err error
+		if metadata, err = json.Marshal(opts.Metadata); err != nil {
+			return nil, fmt.Errorf("encoding metadata: %w", err)
+		}
+	}
+
+	// The boundary is fixed up front so a replayed body matches its header.
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	newBody := func() (io.ReadCloser, error) {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("opening file: %w", err)
+		}
+		pr, pw := io.Pipe()
+		go func() {
+			defer f.Close()
+			w := multipart.NewWriter(pw)
+			w.SetBoundary(boundary) //nolint:errcheck
+			pw.CloseWithError(writeMultipart(w, f, info.Size(), opts, metadata))
+		}()
+		return pr, nil
+	}
+
+	u := c.BaseURL + fmt.Sprintf("/projects/%s/files", project)
+	idemKey := idempotencyKey(ensureIdempotencyKey(ctx))
+	if c.Verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] --> POST %s (multipart, file: %s, %d bytes)\n", u, filePath, info.Size())
+		fmt.Fprintf(os.Stderr, "[DEBUG]     Idempotency-Key: %s\n", idemKey)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	header.Set("Idempotency-Key", idemKey)
+	resp, err := c.sendBody(ctx, http.MethodPost, u, header, -1, newBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileResp FileResponse
+	if err := c.decodeUploadResponse(resp, &fileResp); err != nil {
+		return nil, err
+	}
+	return &fileResp, nil
+}
+
+// writeMultipart writes the form fields and file part, then closes w. The
+// filename is repeated in a form field since multipart parsers commonly strip
+// directories from the part's filename.
+func writeMultipart(w *multipart.Writer, f io.Reader, size int64, opts UploadOptions, metadata []byte) error {
+	if err := w.WriteField("filename", opts.Filename); err != nil {
+		return fmt.Errorf("writing filename field: %w", err)
+	}
+	if opts.Label != "" {
+		if err := w.WriteField("label", opts.Label); err != nil {
+			return fmt.Errorf("writing label field: %w", err)
+		}
+	}
+	if len(metadata) > 0 {
+		if err := w.WriteField("metadata", string(metadata)); err != nil {
+			return fmt.Errorf("writing metadata field: %w", err)
+		}
+	}
+	part, err := w.CreateFormFile("file", opts.Filename)
+	if err != nil {
+		return fmt.Errorf("creating form file: %w", err)
+	}
+	if _, err := io.Copy(part, &progressReader{r: f, total: size, fn: opts.Progress}); err != nil {
+		return fmt.Errorf("copying file data: %w", err)
+	}
+	return w.Close()
+}
+
+// uploadResumable sends the file through a resumable upload session, reusing
+// the one recorded in opts.StateFile when it still matches the file.
+func (c *Client) uploadResumable(ctx context.Context, project, filePath string, info os.FileInfo, opts UploadOptions) (*FileResponse, error) {
+	base := fmt.Sprintf("/projects/%s/uploads", project)
+
+	session := c.resumeSession(ctx, project, filePath, info, opts.StateFile)
+	if session == nil {
+		session = &UploadSession{}
+		err := c.Post(ensureIdempotencyKey(idempotencyStep(ctx, "create")), base, UploadSessionRequest{
+			Filename:  opts.Filename,
+			SizeBytes: info.Size(),
+			Label:     opts.Label,
+			Metadata:  opts.Metadata,
+		}, session)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound ||
+			apiErr.StatusCode == http.StatusMethodNotAllowed || apiErr.StatusCode == http.StatusNotImplemented) {
+			return nil, errResumableUnsupported
+		}
+		if err != nil {
+			return nil, err
+		}
+		if opts.StateFile != "" {
+			if err := saveUploadState(opts.StateFile, project, filePath, info, session.ID); err != nil && c.Verbose {
+				fmt.Fprintf(os.Stderr, "[DEBUG]     Could not save upload state: %v\n", err)
+			}
+		}
+	}
+
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = session.ChunkSize
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer f.Close()
+
+	sessionPath := base + "/" + session.ID
+	size := info.Size()
+	offset := session.Offset
+	if opts.Progress != nil {
+		opts.Progress(offset, size)
+	}
+	for failures := 0; offset < size; {
+		end := min(offset+chunkSize, size)
+		next, err := c.putChunk(ctx, sessionPath, f, offset, end, size, opts.Progress)
+		if err == nil {
+			offset, failures = next, 0
+			continue
+		}
+
+		failures++
+		if ctx.Err() != nil || !isRetryableUploadError(err) || failures >= maxChunkAttempts {
+			return nil, fmt.Errorf("uploading %s at byte %d: %w", opts.Filename, offset, err)
+		}
+		delay := backoffDelay(failures)
+		if c.Verbose {
+			fmt.Fprintf(os.Stderr, "[DEBUG]     Chunk failed (%v); retry %d/%d after %s\n", err, failures, maxChunkAttempts-1, delay)
+		}
+		if err := sleepCtx(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		// Continue from whatever the server kept of the failed chunk
+		var s UploadSession
+		if err := c.Get(ctx, sessionPath, nil, &s); err == nil {
+			offset = s.Offset
+		}
+	}
+
+	var fileResp FileResponse
+	if err := c.Post(ensureIdempotencyKey(idempotencyStep(ctx, "complete")), sessionPath+"/complete", nil, &fileResp); err != nil {
+		return nil, err
+	}
+	if opts.StateFile != "" {
+		os.Remove(opts.StateFile) //nolint:errcheck
+	}
+	return &fileResp, nil
+}
+
+// putChunk sends bytes [start, end) of f and returns the server's new offset.
+func (c *Client) putChunk(ctx context.Context, sessionPath string, f *os.File, start, end, size int64, progress func(sent, total int64)) (int64, error) {
+	u := c.BaseURL + sessionPath
+	if c.Verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] --> PUT %s (bytes %d-%d/%d)\n", u, start, end-1, size)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, size))
+	resp, err := c.sendBody(ctx, http.MethodPut, u, header, end-start, func() (io.ReadCloser, error) {
+		return io.NopCloser(&progressReader{
+			r:     io.NewSectionReader(f, start, end-start),
+			sent:  start,
+			total: size,
+			fn:    progress,
+		}), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var s UploadSession
+	if err := c.decodeUploadResponse(resp, &s); err != nil {
+		return 0, err
+	}
+	if s.Offset <= start {
+		return 0, &APIError{StatusCode: http.StatusInternalServerError, Message: fmt.Sprintf("server did not store chunk at byte %d", start)}
+	}
+	return s.Offset, nil
+}
+
+// resumeSession returns the session recorded in stateFile if it was started
+// for this project and unchanged file and the server still has it.
+func (c *Client) resumeSession(ctx context.Context, project, filePath string, info os.FileInfo, stateFile string) *UploadSession {
+	if stateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		return nil
+	}
+	var st uploadState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil
+	}
+	abs, _ := filepath.Abs(filePath)
+	if st.Project != project || st.Path != abs || st.Size != info.Size() || !st.ModTime.Equal(info.ModTime()) {
+		return nil
+	}
+
+	var s UploadSession
+	if err := c.Get(ctx, fmt.Sprintf("/projects/%s/uploads/%s", project, st.UploadID), nil, &s); err != nil {
+		return nil
+	}
+	if c.Verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG]     Resuming upload %s at byte %d\n", s.ID, s.Offset)
+	}
+	return &s
+}
+
+func saveUploadState(stateFile, project, filePath string, info os.FileInfo, uploadID string) error {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(uploadState{
+		Project:  project,
+		Path:     abs,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		UploadID: uploadID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(stateFile), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(stateFile, data, 0o600)
+}
+
+// sendBody sends a request whose body comes from newBody, which is called
+// again for every attempt. It retries like Do: network errors and gateway
+// failures for idempotent methods or requests carrying an Idempotency-Key,
+// 429 always, and a rejected token is refreshed once. length is the body
+// size, or -1 if unknown. File transfers are bounded by ctx rather than the
+// client's timeout. The caller must close the returned response body.
+func (c *Client) sendBody(ctx context.Context, method, u string, header http.Header, length int64, newBody func() (io.ReadCloser, error)) (*http.Response, error) {
+	client := *c.HTTPClient
+	client.Timeout = 0
+
+	idempotent := isIdempotent(method) || header.Get("Idempotency-Key") != ""
+
+	var lastErr error
+	var delay time.Duration
+	refreshed, replay := false, false
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 && !replay {
+			if c.Verbose {
+				fmt.Fprintf(os.Stderr, "[DEBUG]     Retry %d/%d after %s\n", attempt, c.MaxRetries, delay)
+			}
+			if err := sleepCtx(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+
+		replay = false
+
+		if c.RateLimiter != nil {
+			if err := c.RateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		body, err := newBody()
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u, body)
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("creating %s request: %w", method, err)
+		}
+		req.ContentLength = length
+
+		token, err := currentToken(c.Tokens, c.AuthToken)
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("User-Agent", c.UserAgent)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		elapsed := time.Since(start)
+		if err != nil {
+			if c.Verbose {
+				fmt.Fprintf(os.Stderr, "[DEBUG] <-- ERROR after %s: %v\n", elapsed, err)
+			}
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("executing %s request: %w", method, err)
+			}
+			lastErr = &NetworkError{Op: "executing " + method + " request", Err: err}
+			if !idempotent {
+				return nil, lastErr
+			}
+			delay = backoffDelay(attempt + 1)
+			continue
+		}
+		if c.Verbose {
+			fmt.Fprintf(os.Stderr, "[DEBUG] <-- %d %s (%s)\n", resp.StatusCode, http.StatusText(resp.StatusCode), elapsed)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && !refreshed {
+			refreshed = true
+			if refreshToken(ctx, c.Tokens, token, c.Verbose) {
+				drain(resp)
+				attempt--
+				replay = true
+				continue
+			}
+		}
+
+		// The last failed attempt is handed back to the caller, which reads
+		// the error message from its body.
+		if attempt < c.MaxRetries && isRetryableStatus(resp.StatusCode) && (idempotent || resp.StatusCode == http.StatusTooManyRequests) {
+			if d, ok := retryDelay(attempt+1, resp.Header); ok {
+				drain(resp)
+				delay = d
+				continue
+			}
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+// decodeUploadResponse reads an upload response into dest, or returns the
+// APIError it carries.
+func (c *Client) decodeUploadResponse(resp *http.Response, dest any) error {
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading upload response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return responseError(resp.StatusCode, respBody)
+	}
+	if err := json.Unmarshal(respBody, dest); err != nil {
+		return fmt.Errorf("decoding upload response: %w", err)
+	}
+	return nil
+}
+
+// responseError builds the APIError for a failed response body.
+func responseError(status int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: status}
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = string(body)
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(status)
+	}
+	return apiErr
+}
+
+// isRetryableUploadError reports whether a failed chunk is worth resending.
+// A conflict means the server already has more of the file than we thought,
+// e.g. a replayed chunk it had stored, so the upload resyncs its offset.
+func isRetryableUploadError(err error) bool {
+	return errors.Is(err, ErrNetwork) || errors.Is(err, ErrServer) || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrConflict)
+}
+
+// progressReader reports bytes read through fn.
+type progressReader struct {
+	r     io.Reader
+	sent  int64
+	total int64
+	fn    func(sent, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 && p.fn != nil {
+		p.sent += int64(n)
+		p.fn(p.sent, p.total)
+	}
+	return n, err
+}
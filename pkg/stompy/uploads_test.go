@@ -0,0 +1,105 @@
+package stompy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadFile_StreamsMultipart(t *testing.T) {
+	content := strings.Repeat("x", 100<<10)
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm: %v", err)
+		}
+		part, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("FormFile: %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		got = append(got, header.Filename, r.FormValue("label"), r.FormValue("metadata"))
+		json.NewEncoder(w).Encode(FileResponse{ID: 1, Filename: header.Filename, SizeBytes: len(data)})
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "design.fig")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(srv.URL)
+	c.Tokens = &stubTokens{current: "stale", next: "fresh"}
+	var last int64
+	resp, err := c.UploadFile(context.Background(), "p", path, UploadOptions{
+		Label:    "v2",
+		Metadata: map[string]string{"team": "ux"},
+		Progress: func(sent, total int64) { last = sent },
+	})
+	if err != nil {
+		t.Fatalf("UploadFile() error: %v", err)
+	}
+	if resp.SizeBytes != len(content) {
+		t.Errorf("server received %d bytes after replay, want %d", resp.SizeBytes, len(content))
+	}
+	if want := []string{"design.fig", "v2", `{"team":"ux"}`}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("form = %q, want %q", got, want)
+	}
+	if last != int64(len(content)) {
+		t.Errorf("last progress = %d, want %d", last, len(content))
+	}
+}
+
+func TestUploadFile_Retries429WithRetryAfter(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm on retry: %v", err)
+		}
+		part, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("FormFile: %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		json.NewEncoder(w).Encode(FileResponse{ID: 1, Filename: header.Filename, SizeBytes: len(data)})
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(srv.URL, WithToken("tok"))
+	start := time.Now()
+	resp, err := c.UploadFile(context.Background(), "p", path, UploadOptions{})
+	if err != nil {
+		t.Fatalf("UploadFile() error: %v", err)
+	}
+	if resp.SizeBytes != 5 {
+		t.Errorf("server received %d bytes on retry, want 5", resp.SizeBytes)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > retryBaseDelay/2 {
+		t.Errorf("UploadFile() took %s, want Retry-After: 0 to skip backoff", elapsed)
+	}
+}